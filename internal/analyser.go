@@ -2,15 +2,17 @@ package internal
 
 import (
 	"container/heap"
+	"context"
 	"fmt"
 	"go/types"
-	"log"
 	"strings"
+	"time"
 
 	"symbolic-execution-course/internal/memory"
+	"symbolic-execution-course/internal/solver"
+	"symbolic-execution-course/internal/ssabuilder"
 	"symbolic-execution-course/internal/symbolic"
 	"symbolic-execution-course/internal/translator"
-	"symbolic-execution-course/internal/ssabuilder"
 
 	"golang.org/x/tools/go/ssa"
 )
@@ -21,8 +23,110 @@ type Analyser struct {
 	PathSelector PathSelector
 	Results      []*Interpreter
 	Z3Translator *translator.Z3Translator
+	Solver       solver.Solver
+	SolverCache  *solver.Cache
 	maxSteps     int
 	stepsCounter int
+
+	Scheduler *Scheduler
+	// Globals - режим моделирования глобальных переменных
+	Globals GlobalsMode
+	// Merger - слияние состояний в точках слияния потока управления, nil - без слияния
+	Merger *StateMerger
+
+	// Root - корень дерева исполнения
+	Root         *ExecutionNode
+	nodesCounter int
+	// initialStates - нетронутые копии начальных состояний для переисполнения путей
+	initialStates []*Interpreter
+
+	// Truncated выставляется, если какие-то состояния были отброшены
+	// из-за ограничений или остановились на неподдерживаемой конструкции,
+	// а не исследованы до конца
+	Truncated bool
+	// Cuts - сколько путей оборвано каждым ограничением, включая
+	// отброшенные состояния и оставшиеся в очереди после исчерпания бюджета
+	Cuts map[Limit]int
+
+	// options - настройки анализа со значениями по умолчанию
+	options Options
+	// function - анализируемая функция
+	function *ssa.Function
+	// ctx - контекст анализа: его отмена или истечение останавливает обход
+	ctx context.Context
+}
+
+// isSatisfiable спрашивает решатель, выполнимо ли условие ветвления вместе с условием пути.
+// В запрос попадают только конъюнкты пути, зависящие от условия ветвления.
+// Без решателя, а также при ошибке или ответе unknown путь считается выполнимым.
+func (analyser *Analyser) isSatisfiable(pathCondition *PathCondition, cond symbolic.SymbolicExpression) bool {
+	if analyser == nil {
+		return true
+	}
+	return checkSatisfiable(analyser.Solver, analyser.SolverCache, pathCondition, cond)
+}
+
+func checkSatisfiable(s solver.Solver, cache *solver.Cache, pathCondition *PathCondition, cond symbolic.SymbolicExpression) bool {
+	if s == nil {
+		return true
+	}
+
+	constraints := solver.IndependentSlice(pathCondition.Constraints(), cond)
+
+	var (
+		res solver.Result
+		err error
+	)
+	if cache != nil {
+		res, _, err = cache.Check(constraints)
+	} else {
+		res, _, err = solver.CheckConstraints(s, constraints)
+	}
+	if err != nil {
+		return true
+	}
+	return res != solver.Unsat
+}
+
+// push кладёт состояние в очередь с приоритетом селектора
+func (analyser *Analyser) push(state *Interpreter) {
+	heap.Push(&analyser.StatesQueue, &Item{
+		value:    *state,
+		priority: analyser.PathSelector.CalculatePriority(*state),
+	})
+}
+
+// nextItem снимает с очереди следующее состояние: выбранное селектором,
+// если он умеет выбирать сам, иначе - с наибольшим приоритетом
+func (analyser *Analyser) nextItem() *Item {
+	var item *Item
+	if chooser, ok := analyser.PathSelector.(StateChooser); ok {
+		item = heap.Remove(&analyser.StatesQueue, chooser.Choose(analyser.StatesQueue)).(*Item)
+	} else {
+		item = heap.Pop(&analyser.StatesQueue).(*Item)
+	}
+	analyser.forget(item)
+	return item
+}
+
+// forget сообщает селектору, что состояние покинуло очередь
+func (analyser *Analyser) forget(item *Item) {
+	if forgetter, ok := analyser.PathSelector.(StateForgetter); ok {
+		forgetter.Forget(&item.value)
+	}
+}
+
+// reprioritize пересчитывает приоритеты всех состояний в очереди
+func (analyser *Analyser) reprioritize() {
+	reprioritizer, partial := analyser.PathSelector.(Reprioritizer)
+	for _, item := range analyser.StatesQueue {
+		if partial {
+			item.priority = reprioritizer.Reprioritize(item.value)
+		} else {
+			item.priority = analyser.PathSelector.CalculatePriority(item.value)
+		}
+	}
+	heap.Init(&analyser.StatesQueue)
 }
 
 func isContradiction(cond symbolic.SymbolicExpression) bool {
@@ -298,244 +402,286 @@ func Analyse(source string, functionName string) []*Interpreter {
 }
 
 func AnalysePackage(sources map[string]string, functionName string) []*Interpreter {
-	return AnalysePackageWithOptions(sources, functionName, &DfsPathSelector{}, 2000)
+	return AnalysePackageWithOptions(sources, functionName, &DfsPathSelector{}, defaultMaxSteps)
 }
 
 func AnalysePackageWithOptions(sources map[string]string, functionName string, selector PathSelector, maxSteps int) []*Interpreter {
-	builder := ssabuilder.NewBuilder()
-	fn, err := builder.ParseAndBuildSSA(sources["test.go"], functionName)
+	fn, err := BuildFunction(sources, functionName)
 	if err != nil {
-		log.Printf("you are doing something wrong: %v", err)
+		Logger.Error("failed to build function", "function", functionName, "err", err)
 		return nil
 	}
+	results, err := ExploreFunction(context.Background(), fn, Options{Selector: selector, MaxSteps: maxSteps})
+	if err != nil {
+		Logger.Error("failed to start analysis", "function", fn.String(), "err", err)
+	}
+	return results
+}
+
+func AnalyseWithOptions(source string, functionName string, selector PathSelector, maxSteps int) []*Interpreter {
+	return AnalysePackageWithOptions(map[string]string{"main.go": source}, functionName, selector, maxSteps)
+}
+
+// BuildFunction строит SSA пакета из исходников (ключ - имя файла) и находит в нём функцию
+func BuildFunction(sources map[string]string, functionName string) (*ssa.Function, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no sources to analyse")
+	}
+	return ssabuilder.NewBuilder().ParseFilesAndBuildSSA(sources, functionName)
+}
 
+// newAnalyser создаёт анализатор функции и кладёт в очередь начальные состояния
+func newAnalyser(ctx context.Context, fn *ssa.Function, options Options) (*Analyser, error) {
+	options = options.withDefaults()
+	z3Translator, z3Solver, err := newSolver(options.Solver, options.SolverTimeout)
+	if err != nil {
+		return nil, err
+	}
 	analyser := &Analyser{
 		Package:      fn.Pkg,
 		StatesQueue:  make(PriorityQueue, 0),
-		PathSelector: selector,
+		PathSelector: options.Selector,
 		Results:      make([]*Interpreter, 0),
-		Z3Translator: translator.NewZ3Translator(),
-		maxSteps:     maxSteps,
+		Z3Translator: z3Translator,
+		Solver:       z3Solver,
+		SolverCache:  solver.NewCache(z3Solver),
+		Scheduler:    options.Scheduler,
+		Globals:      options.Globals,
+		Merger:       options.Merger,
+		maxSteps:     options.MaxSteps,
 		stepsCounter: 0,
+		options:      options,
+		ctx:          ctx,
+		function:     fn,
 	}
 
-	initialInterpreter := createInitialInterpreter(fn, analyser, false)
+	if options.Resume != nil {
+		if err := analyser.resume(fn, options.Resume); err != nil {
+			analyser.close()
+			return nil, err
+		}
+		return analyser, nil
+	}
 
-	heap.Init(&analyser.StatesQueue)
+	initialInterpreter, err := createInitialInterpreter(fn, analyser, false)
+	if err != nil {
+		analyser.close()
+		return nil, err
+	}
 
-	heap.Push(&analyser.StatesQueue, &Item{
-		value:    *initialInterpreter,
-		priority: analyser.PathSelector.CalculatePriority(*initialInterpreter),
-	})
+	initialStates := []*Interpreter{initialInterpreter}
 
 	// HACK
-	if functionName == "Aliasing" || functionName == "ArrayAliasing" {
-		aliasingInterpreter := createInitialInterpreter(fn, analyser, true)
-		heap.Push(&analyser.StatesQueue, &Item{
-			value:    *aliasingInterpreter,
-			priority: analyser.PathSelector.CalculatePriority(*aliasingInterpreter),
-		})
+	if fn.Name() == "Aliasing" || fn.Name() == "ArrayAliasing" {
+		aliased, err := createInitialInterpreter(fn, analyser, true)
+		if err != nil {
+			analyser.close()
+			return nil, err
+		}
+		initialStates = append(initialStates, aliased)
 	}
 
-	const maxQueueSize = 100
-
-	for analyser.StatesQueue.Len() > 0 && analyser.stepsCounter < analyser.maxSteps {
-		item := heap.Pop(&analyser.StatesQueue).(*Item)
-		interpreter := item.value
-		interpreter.Analyser = analyser
-		analyser.stepsCounter++
+	analyser.Root = analyser.newNode(nil, initialInterpreter.CurrentBlock)
+	analyser.attach(analyser.Root, initialStates)
 
-		if isContradiction(interpreter.PathCondition) {
-			continue
-		}
+	heap.Init(&analyser.StatesQueue)
+	for _, state := range initialStates {
+		pristine := state.Copy()
+		analyser.initialStates = append(analyser.initialStates, pristine)
+		heap.Push(&analyser.StatesQueue, &Item{
+			value:    *state,
+			priority: analyser.PathSelector.CalculatePriority(*state),
+		})
+	}
 
-		pathCondString := interpreter.PathCondition.String()
-		if strings.Count(pathCondString, "&&") > 50 || strings.Count(pathCondString, "(") > 100 || len(pathCondString) > 500 {
-			continue
-		}
+	return analyser, nil
+}
 
-		if interpreter.ExecutionSteps > 1000 {
-			interpreter.CurrentBlock = nil
-			analyser.Results = append(analyser.Results, &interpreter)
-			continue
-		}
+// explore обрабатывает состояния из очереди, пока они не кончатся,
+// не исчерпается бюджет шагов, не будет отменён контекст анализа или
+// селектор не попросит остановиться. Анализ идёт раундами: из очереди
+// берётся по состоянию на исполнителя, исполнители делают по шагу, затем
+// новые состояния кладутся в очередь.
+func (analyser *Analyser) explore() {
+	if analyser.options.Timeout > 0 {
+		ctx, cancel := context.WithTimeout(analyser.ctx, analyser.options.Timeout)
+		defer cancel()
+		analyser.ctx = ctx
+	}
+	workers := analyser.startWorkers()
+	defer analyser.stopWorkers(workers)
 
-		path_condition := pathCondString
-		if len(pathCondString) > 200 {
-			path_condition = pathCondString[:200] + "..."
+	// Отмена прерывает и запросы, которые решатели исполняют в этот момент.
+	// Контексты Z3 исполнителей освобождаются только после прерывания.
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(analyser.ctx, func() {
+		defer close(interrupted)
+		for _, w := range workers {
+			w.interrupt()
 		}
-		fmt.Printf("\n======== STEP %d =========\n", analyser.stepsCounter)
-		fmt.Printf("Path condition: %s\n", path_condition)
-
-		if interpreter.IsFinished() {
-			analyser.Results = append(analyser.Results, &interpreter)
-			continue
+	})
+	defer func() {
+		if !stop() {
+			<-interrupted
 		}
+	}()
 
-		nextInstruction := interpreter.GetNextInstruction()
-		if nextInstruction != nil {
-			fmt.Printf("Instr: %T: %s\n", nextInstruction, nextInstruction.String())
-
-			if ifInstr, ok := nextInstruction.(*ssa.If); ok {
-				fmt.Printf("  Condition If: %T, name: %s\n", ifInstr.Cond, ifInstr.Cond.Name())
+	stopped := false
+	lastCheckpoint := time.Now()
+	for !stopped && analyser.hasStates() && analyser.stepsCounter < analyser.maxSteps && !analyser.expired() {
+		var tasks []*task
+		tasks, stopped = analyser.collectRound(len(workers))
+		if len(tasks) > 0 {
+			runRound(workers, tasks)
+			for _, task := range tasks {
+				analyser.enqueue(&task.interpreter, task.instr, task.next)
 			}
+			analyser.Scheduler.admit(analyser)
 		}
-		if nextInstruction == nil {
-			analyser.Results = append(analyser.Results, &interpreter)
-			continue
+		if analyser.options.Checkpoint != "" && time.Since(lastCheckpoint) >= analyser.options.CheckpointInterval {
+			analyser.saveCheckpoint()
+			lastCheckpoint = time.Now()
 		}
+	}
+	// Последняя точка снимается до того, как оставшиеся состояния будут
+	// отмечены оборванными, чтобы их можно было исследовать дальше
+	if analyser.options.Checkpoint != "" {
+		analyser.saveCheckpoint()
+	}
 
-		newStates := interpreter.interpretDynamically(nextInstruction)
-
-		for _, newState := range newStates {
-			newState.Analyser = analyser
-			if isContradiction(newState.PathCondition) {
-				continue
-			}
-
-			if len(strings.Split(newState.PathCondition.String(), "&&")) > 100 {
-				continue
-			}
-
-			if analyser.StatesQueue.Len() >= maxQueueSize {
-				continue
-			}
+	remaining := analyser.StatesQueue.Len() + analyser.Scheduler.Suspended()
+	if analyser.Merger != nil {
+		remaining += analyser.Merger.Parked()
+	}
+	switch {
+	case remaining == 0:
+	case stopped:
+		// Селектор остановил анализ сам, ограничения тут ни при чём
+		analyser.Truncated = true
+	case analyser.expired():
+		analyser.cut(contextLimit(analyser.ctx), remaining)
+	default:
+		analyser.cut(LimitSteps, remaining)
+	}
+}
 
-			heap.Push(&analyser.StatesQueue, &Item{
-				value:    *newState,
-				priority: analyser.PathSelector.CalculatePriority(*newState),
-			})
+// collectRound снимает с очереди до n состояний, готовых к следующему шагу.
+// stop сообщает, что селектор попросил остановить анализ.
+func (analyser *Analyser) collectRound(n int) (tasks []*task, stop bool) {
+	for len(tasks) < n && analyser.hasStates() && analyser.stepsCounter < analyser.maxSteps && !analyser.expired() {
+		interpreter, instr, stop := analyser.prepare(analyser.nextItem())
+		if stop {
+			return tasks, true
+		}
+		if interpreter != nil {
+			tasks = append(tasks, &task{interpreter: *interpreter, instr: instr})
 		}
 	}
-
-	fmt.Printf("Overall states found: %d\n", len(analyser.Results))
-
-	return analyser.Results
+	return tasks, false
 }
 
-func AnalyseWithOptions(source string, functionName string, selector PathSelector, maxSteps int) []*Interpreter {
-	builder := ssabuilder.NewBuilder()
-	fn, err := builder.ParseAndBuildSSA(source, functionName)
-	if err != nil {
-		log.Printf("you are doing something wrong: %v", err)
-		return nil
-	}
+// prepare проверяет ограничения для снятого с очереди состояния и возвращает
+// его вместе со следующей инструкцией, если состояние нужно исполнять дальше
+func (analyser *Analyser) prepare(item *Item) (*Interpreter, ssa.Instruction, bool) {
+	interpreter := item.value
+	interpreter.Analyser = analyser
+	analyser.stepsCounter++
 
-	analyser := &Analyser{
-		Package:      fn.Pkg,
-		StatesQueue:  make(PriorityQueue, 0),
-		PathSelector: selector,
-		Results:      make([]*Interpreter, 0),
-		Z3Translator: translator.NewZ3Translator(),
-		maxSteps:     maxSteps,
-		stepsCounter: 0,
+	if isContradiction(interpreter.PathCondition.Expression()) {
+		return nil, nil, false
 	}
 
-	initialInterpreter := createInitialInterpreter(fn, analyser, false)
-
-	heap.Init(&analyser.StatesQueue)
-
-	heap.Push(&analyser.StatesQueue, &Item{
-		value:    *initialInterpreter,
-		priority: analyser.PathSelector.CalculatePriority(*initialInterpreter),
-	})
-
-	if functionName == "Aliasing" || functionName == "ArrayAliasing" {
-		aliasingInterpreter := createInitialInterpreter(fn, analyser, true)
-		heap.Push(&analyser.StatesQueue, &Item{
-			value:    *aliasingInterpreter,
-			priority: analyser.PathSelector.CalculatePriority(*aliasingInterpreter),
-		})
+	if interpreter.PathCondition.exceeds(analyser.options.MaxPathLength, analyser.options.MaxPathDepth) {
+		analyser.cut(LimitPathLength, 1)
+		return nil, nil, false
 	}
 
-	const maxQueueSize = 100
+	if interpreter.ExecutionSteps > analyser.options.MaxPathSteps {
+		interpreter.truncate(LimitPathSteps)
+		analyser.finish(&interpreter)
+		return nil, nil, false
+	}
 
-	for analyser.StatesQueue.Len() > 0 && analyser.stepsCounter < analyser.maxSteps {
-		item := heap.Pop(&analyser.StatesQueue).(*Item)
-		interpreter := item.value
-		interpreter.Analyser = analyser
-		analyser.stepsCounter++
+	if interpreter.IsFinished() {
+		logStep(analyser.stepsCounter, &interpreter, nil)
+		analyser.finish(&interpreter)
+		return nil, nil, false
+	}
 
-		if isContradiction(interpreter.PathCondition) {
-			continue
-		}
+	nextInstruction := interpreter.GetNextInstruction()
+	logStep(analyser.stepsCounter, &interpreter, nextInstruction)
+	if nextInstruction == nil {
+		analyser.finish(&interpreter)
+		return nil, nil, false
+	}
+	interpreter.enterBlock()
 
-		pcStr := interpreter.PathCondition.String()
-		if strings.Count(pcStr, "&&") > 50 || strings.Count(pcStr, "(") > 100 || len(pcStr) > 500 {
-			continue
+	if analyser.Merger != nil {
+		merged := analyser.Merger.arrive(analyser, &interpreter)
+		if merged == nil {
+			return nil, nil, false
 		}
+		interpreter = *merged
+	}
 
-		if interpreter.ExecutionSteps > 1000 {
-			interpreter.CurrentBlock = nil
-			analyser.Results = append(analyser.Results, &interpreter)
-			continue
-		}
+	if stopper, ok := analyser.PathSelector.(ExplorationStopper); ok && stopper.ShouldStop(&interpreter, nextInstruction) {
+		analyser.Results = append(analyser.Results, &interpreter)
+		return nil, nil, true
+	}
 
+	if observer, ok := analyser.PathSelector.(CoverageObserver); ok && observer.ObserveStep(&interpreter) {
+		analyser.reprioritize()
+	}
 
-		displayPC := pcStr
-		if len(pcStr) > 200 {
-			displayPC = pcStr[:200] + "..."
-		}
-		fmt.Printf("\n========= STEP %d =========\n", analyser.stepsCounter)
-		fmt.Printf("Path condition: %s\n", displayPC)
+	return &interpreter, nextInstruction, false
+}
 
+// enqueue привязывает состояния, полученные шагом parent на инструкции instr,
+// к дереву исполнения и кладёт в очередь те, что не отброшены ограничениями
+func (analyser *Analyser) enqueue(parent *Interpreter, instr ssa.Instruction, newStates []*Interpreter) {
+	if len(newStates) > 1 {
+		parent.Node.Fork = instr
+	}
+	analyser.attach(parent.Node, newStates)
 
-		if interpreter.IsFinished() {
-			analyser.Results = append(analyser.Results, &interpreter)
+	for _, newState := range newStates {
+		newState.Analyser = analyser
+		newState.worker = nil
+		if isContradiction(newState.PathCondition.Expression()) {
 			continue
 		}
 
-		nextInstruction := interpreter.GetNextInstruction()
-		if nextInstruction != nil {
-			fmt.Printf("Instruction: %T: %s\n", nextInstruction, nextInstruction.String())
-
-			if ifInstr, ok := nextInstruction.(*ssa.If); ok {
-				fmt.Printf("  Condition If: %T, name: %s\n", ifInstr.Cond, ifInstr.Cond.Name())
-			}
-		}
-		if nextInstruction == nil {
-			analyser.Results = append(analyser.Results, &interpreter)
+		if newState.PathCondition.exceeds(analyser.options.MaxPathLength, analyser.options.MaxPathDepth) {
+			analyser.cut(LimitPathLength, 1)
 			continue
 		}
 
-		newStates := interpreter.interpretDynamically(nextInstruction)
-
-		for _, newState := range newStates {
-			newState.Analyser = analyser
-			if isContradiction(newState.PathCondition) {
-				continue
-			}
-
-			if len(strings.Split(newState.PathCondition.String(), "&&")) > 100 {
-				continue
-			}
-
-			if analyser.StatesQueue.Len() >= maxQueueSize {
-				continue
-			}
-
-			heap.Push(&analyser.StatesQueue, &Item{
-				value:    *newState,
-				priority: analyser.PathSelector.CalculatePriority(*newState),
-			})
-		}
+		analyser.push(newState)
 	}
+}
 
-	fmt.Printf("\n=================================\n")
-	fmt.Printf("Overall found states: %d\n", len(analyser.Results))
-
-	for i, result := range analyser.Results {
-		fmt.Printf("\nState %d:\n", i)
-		fmt.Printf("  Path condition: %s\n", result.PathCondition.String())
-		if frame := result.GetCurrentFrame(); frame != nil && frame.ReturnValue != nil {
-			fmt.Printf("  Return value: %s\n", frame.ReturnValue.String())
-		}
+// finish добавляет завершённый путь к результатам
+func (analyser *Analyser) finish(interpreter *Interpreter) {
+	switch interpreter.Terminal {
+	case TerminalTruncated:
+		analyser.cut(interpreter.Limit, 1)
+	case TerminalUnsupported:
+		analyser.Truncated = true
 	}
-
-	return analyser.Results
+	// Непрозрачный результат вызова не исследует его тело, поэтому
+	// путь, пропустивший вызов, не исследован до конца
+	if interpreter.SkippedCalls > 0 {
+		analyser.cut(LimitCallDepth, 1)
+	}
+	analyser.Results = append(analyser.Results, interpreter)
 }
 
-func createInitialInterpreter(fn *ssa.Function, analyser *Analyser, createAliases bool) *Interpreter {
+// createInitialInterpreter создаёт состояние на входе в fn с символьными
+// входами. Если у входа неподдерживаемый тип, возвращается UnsupportedError.
+func createInitialInterpreter(fn *ssa.Function, analyser *Analyser, createAliases bool) (*Interpreter, error) {
+	if len(fn.Blocks) == 0 {
+		return nil, &UnsupportedError{Pos: fn.Prog.Fset.Position(fn.Pos()), What: "function without body " + fn.String()}
+	}
 	mem := memory.NewSymbolicMemory()
 
 	initialFrame := CallStackFrame{
@@ -549,10 +695,29 @@ func createInitialInterpreter(fn *ssa.Function, analyser *Analyser, createAliase
 
 	refCounter := 0
 
+	// Символьные входы: параметры, включая получатель метода,
+	// и свободные переменные, если точка входа - замыкание
+	inputs := make([]ssa.Value, 0, len(fn.Params)+len(fn.FreeVars))
 	for _, param := range fn.Params {
+		inputs = append(inputs, param)
+	}
+	for _, freeVar := range fn.FreeVars {
+		inputs = append(inputs, freeVar)
+	}
+
+	for _, param := range inputs {
 		switch t := param.Type().(type) {
 		case *types.Pointer:
-			ref := mem.Allocate(symbolic.ObjType, param.Name(), symbolic.NewSymbolicVariable(param.Name(), symbolic.ObjType))
+			var ref *symbolic.SymbolicPointer
+			if elem, ok := t.Elem().Underlying().(*types.Basic); ok {
+				// Указатель на простое значение, например захваченная замыканием переменная:
+				// единственное поле объекта - символьное значение
+				ref = mem.AllocateFullStruct(param.Name(), []symbolic.SymbolicExpression{
+					symbolic.NewSymbolicVariable("*"+param.Name(), basicSymbolicType(elem)),
+				})
+			} else {
+				ref = mem.Allocate(symbolic.ObjType, param.Name(), symbolic.NewSymbolicVariable(param.Name(), symbolic.ObjType))
+			}
 			initialFrame.LocalMemory[param.Name()] = ref
 			refCounter++
 
@@ -565,12 +730,16 @@ func createInitialInterpreter(fn *ssa.Function, analyser *Analyser, createAliase
 				initialFrame.LocalMemory[param.Name()] = symbolic.NewSymbolicVariable(param.Name(), symbolic.IntType)
 			}
 		case *types.Slice:
+			elem, ok := t.Elem().(*types.Basic)
+			if !ok {
+				return nil, &UnsupportedError{Pos: fn.Prog.Fset.Position(param.Pos()), What: fmt.Sprintf("type %s of input %s", t, param.Name())}
+			}
 			var elType = symbolic.IntType;
-			if t.Elem().(*types.Basic).Kind() == types.Int {
+			if elem.Kind() == types.Int {
 				elType = symbolic.IntType
-			} else if t.Elem().(*types.Basic).Kind() == types.Bool {
+			} else if elem.Kind() == types.Bool {
 				elType = symbolic.BoolType
-			} else if t.Elem().(*types.Basic).Kind() == types.Float32 {
+			} else if elem.Kind() == types.Float32 {
 				elType = symbolic.FloatType
 			}
 			// } else if t.Elem().(*types.Basic).Kind() == types.String {
@@ -586,6 +755,8 @@ func createInitialInterpreter(fn *ssa.Function, analyser *Analyser, createAliase
 			ref := mem.Allocate(symbolic.ObjType, param.Name(), symbolic.NewSymbolicVariable(param.Name(), symbolic.ObjType))
 			initialFrame.LocalMemory[param.Name()] = ref
 		case *types.Named:
+			// Структура по значению, включая получатель метода, остаётся
+			// символьной переменной: поля читаются через её копию в куче
 			if strings.Contains(t.String(), "error") {
 				initialFrame.LocalMemory[param.Name()] = symbolic.NewSymbolicVariable(param.Name(), symbolic.AddrType)
 			} else {
@@ -612,14 +783,14 @@ func createInitialInterpreter(fn *ssa.Function, analyser *Analyser, createAliase
 	interpreter := &Interpreter{
 		CallStack:        []CallStackFrame{initialFrame},
 		Analyser:         analyser,
-		PathCondition:    symbolic.NewBoolConstant(true),
+		PathCondition:    NewPathCondition(),
 		Heap:             mem,
 		CurrentBlock:     fn.Blocks[0],
 		InstrIndex:       0,
 		LoopCounters:     make(map[string]int),
-		MaxLoopUnroll:    10,
+		MaxLoopUnroll:    analyser.options.LoopBound,
 		VisitedBlocks:    make(map[string]bool),
-		MaxCallDepth:     50,
+		MaxCallDepth:     analyser.options.MaxCallDepth,
 		CurrentCallDepth: 0,
 		VisitedFunctions: make(map[string]bool),
 		BlockVisitCount:  make(map[string]int),
@@ -627,5 +798,9 @@ func createInitialInterpreter(fn *ssa.Function, analyser *Analyser, createAliase
 		ExecutionSteps:   0,
 	}
 
-	return interpreter
+	if analyser != nil && analyser.Globals == GlobalsInit {
+		interpreter.pushInit(fn)
+	}
+
+	return interpreter, nil
 }
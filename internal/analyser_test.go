@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// exploreFile исследует функцию из файла с настройками по умолчанию
+func exploreFile(t *testing.T, path, function string) []*Interpreter {
+	t.Helper()
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	fn, err := BuildFunction(map[string]string{filepath.Base(path): string(source)}, function)
+	if err != nil {
+		t.Fatalf("build %s: %v", function, err)
+	}
+	results, err := ExploreFunction(context.Background(), fn, Options{})
+	if err != nil {
+		t.Fatalf("explore %s: %v", function, err)
+	}
+	return results
+}
+
+func TestNamedStructParameters(t *testing.T) {
+	tests := []struct {
+		file     string
+		function string
+		paths    int
+	}{
+		{"../final_tests/structs.go", "TestPathConstraintMutability", 3},
+		{"../homework3/examples/test_functions.go", "testPathConstraintMutability", 3},
+		{"../homework3/examples/test_functions.go", "testStructModification", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			results := exploreFile(t, tt.file, tt.function)
+			if len(results) != tt.paths {
+				t.Errorf("got %d paths, want %d", len(results), tt.paths)
+			}
+			for _, result := range results {
+				if result.Terminal == TerminalUnsupported || result.Terminal == TerminalTruncated {
+					t.Errorf("path %s ended with %s", result.PathCondition, result.Terminal)
+				}
+			}
+		})
+	}
+}
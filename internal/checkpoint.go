@@ -0,0 +1,800 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"symbolic-execution-course/internal/memory"
+	"symbolic-execution-course/internal/solver"
+	"symbolic-execution-course/internal/symbolic"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+const (
+	checkpointVersion = 1
+
+	defaultCheckpointInterval = time.Minute
+)
+
+// Checkpoint - контрольная точка анализа функции: дерево исполнения,
+// очередь состояний, завершённые пути, вытесненные и ожидающие слияния
+// состояния и кэш решателя. Функции, блоки и инструкции записаны именами
+// и номерами, поэтому продолжить анализ можно только на той же SSA
+// программе. Выражения всех состояний хранятся в общей таблице, так что
+// общие подвыражения и префиксы условий путей остаются общими.
+type Checkpoint struct {
+	Version   int           `json:"version"`
+	Function  string        `json:"function"`
+	Steps     int           `json:"steps"`
+	Truncated bool          `json:"truncated,omitempty"`
+	Cuts      map[Limit]int `json:"cuts,omitempty"`
+
+	Expressions []symbolic.Node    `json:"expressions"`
+	Conditions  []encodedCondition `json:"conditions"`
+	Trails      []encodedTrail     `json:"trails,omitempty"`
+	Tree        []encodedNode      `json:"tree"`
+	NextNodeID  int                `json:"nextNodeId"`
+
+	Initial []encodedState `json:"initial"`
+	Queue   []encodedState `json:"queue,omitempty"`
+	Results []encodedState `json:"results,omitempty"`
+
+	Parked         []encodedState   `json:"parked,omitempty"`
+	Released       []encodedRelease `json:"released,omitempty"`
+	Merges         int              `json:"merges,omitempty"`
+	MergesRejected int              `json:"mergesRejected,omitempty"`
+
+	Evictions []encodedEviction  `json:"evictions,omitempty"`
+	Suspended []encodedSuspended `json:"suspended,omitempty"`
+
+	Cache *solver.CacheState `json:"cache,omitempty"`
+}
+
+// blockRef - базовый блок функции
+type blockRef struct {
+	Function string `json:"function"`
+	Block    int    `json:"block"`
+}
+
+// instrRef - инструкция базового блока
+type instrRef struct {
+	blockRef
+	Index int `json:"index"`
+}
+
+// encodedCondition - узел условия пути; номера - в таблицах контрольной точки
+type encodedCondition struct {
+	Parent     int `json:"parent"`
+	Constraint int `json:"constraint"`
+	Length     int `json:"length"`
+	Depth      int `json:"depth"`
+}
+
+type encodedTrail struct {
+	Block  blockRef `json:"block"`
+	Parent int      `json:"parent"`
+	Merged int      `json:"merged"`
+}
+
+type encodedNode struct {
+	ID         int       `json:"id"`
+	Parent     int       `json:"parent"`
+	Block      *blockRef `json:"block,omitempty"`
+	MergedWith int       `json:"mergedWith"`
+	Fork       *instrRef `json:"fork,omitempty"`
+	Branch     int       `json:"branch,omitempty"`
+	Condition  int       `json:"condition"`
+}
+
+type encodedFrame struct {
+	Function      string         `json:"function"`
+	LocalMemory   map[string]int `json:"localMemory,omitempty"`
+	ReturnValue   int            `json:"returnValue"`
+	Block         *blockRef      `json:"block,omitempty"`
+	ReturnToIndex int            `json:"returnToIndex,omitempty"`
+	ReturnVarName string         `json:"returnVarName,omitempty"`
+}
+
+type encodedState struct {
+	CallStack     []encodedFrame        `json:"callStack"`
+	PathCondition int                   `json:"pathCondition"`
+	Heap          *memory.EncodedMemory `json:"heap,omitempty"`
+	Block         *blockRef             `json:"block,omitempty"`
+	InstrIndex    int                   `json:"instrIndex,omitempty"`
+	PrevBlock     *blockRef             `json:"prevBlock,omitempty"`
+
+	LoopCounters     map[string]int  `json:"loopCounters,omitempty"`
+	MaxLoopUnroll    int             `json:"maxLoopUnroll,omitempty"`
+	VisitedBlocks    map[string]bool `json:"visitedBlocks,omitempty"`
+	BlockVisitCount  map[string]int  `json:"blockVisitCount,omitempty"`
+	MaxCallDepth     int             `json:"maxCallDepth,omitempty"`
+	CurrentCallDepth int             `json:"currentCallDepth,omitempty"`
+	VisitedFunctions map[string]bool `json:"visitedFunctions,omitempty"`
+	ExecutionSteps   int             `json:"executionSteps,omitempty"`
+	SkippedCalls     int             `json:"skippedCalls,omitempty"`
+
+	Node    int            `json:"node"`
+	Globals map[string]int `json:"globals,omitempty"`
+
+	Terminal      TerminalKind `json:"terminal"`
+	TerminalInstr *instrRef    `json:"terminalInstr,omitempty"`
+	Limit         Limit        `json:"limit,omitempty"`
+	Reason        string       `json:"reason,omitempty"`
+
+	FreshVars int `json:"freshVars,omitempty"`
+	Trail     int `json:"trail"`
+}
+
+type encodedRelease struct {
+	Node  int      `json:"node"`
+	Block blockRef `json:"block"`
+}
+
+type encodedEviction struct {
+	NodeID   int            `json:"nodeId"`
+	Priority int            `json:"priority"`
+	Reason   string         `json:"reason"`
+	Limit    Limit          `json:"limit"`
+	Policy   EvictionPolicy `json:"policy"`
+	Restored bool           `json:"restored,omitempty"`
+	Err      string         `json:"err,omitempty"`
+}
+
+type encodedSuspended struct {
+	Eviction  int    `json:"eviction"`
+	Decisions []int  `json:"decisions,omitempty"`
+	SpillFile string `json:"spillFile,omitempty"`
+}
+
+// ReadCheckpoint читает контрольную точку из файла
+func ReadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("read checkpoint %s: %w", path, err)
+	}
+	if checkpoint.Version != checkpointVersion {
+		return nil, fmt.Errorf("read checkpoint %s: unsupported version %d", path, checkpoint.Version)
+	}
+	return &checkpoint, nil
+}
+
+// WriteFile записывает контрольную точку. Файл заменяется целиком,
+// так что прерванная запись не портит предыдущую точку.
+func (checkpoint *Checkpoint) WriteFile(path string) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// saveCheckpoint записывает контрольную точку в файл из настроек
+func (analyser *Analyser) saveCheckpoint() {
+	if err := analyser.checkpoint().WriteFile(analyser.options.Checkpoint); err != nil {
+		Logger.Warn("failed to write checkpoint", "path", analyser.options.Checkpoint, "err", err)
+		return
+	}
+	Logger.Info("checkpoint written", "path", analyser.options.Checkpoint, "steps", analyser.stepsCounter,
+		"states", analyser.StatesQueue.Len(), "paths", len(analyser.Results))
+}
+
+// checkpointEncoder записывает состояния анализатора в таблицы контрольной точки
+type checkpointEncoder struct {
+	checkpoint *Checkpoint
+	exprs      *symbolic.Encoder
+	conditions map[*PathCondition]int
+	trails     map[*blockTrail]int
+}
+
+// checkpoint снимает контрольную точку; вызывается между раундами анализа
+func (analyser *Analyser) checkpoint() *Checkpoint {
+	checkpoint := &Checkpoint{
+		Version:    checkpointVersion,
+		Function:   analyser.function.String(),
+		Steps:      analyser.stepsCounter,
+		Truncated:  analyser.Truncated,
+		Cuts:       analyser.Cuts,
+		NextNodeID: analyser.nodesCounter,
+	}
+	enc := &checkpointEncoder{
+		checkpoint: checkpoint,
+		exprs:      symbolic.NewEncoder(),
+		conditions: make(map[*PathCondition]int),
+		trails:     make(map[*blockTrail]int),
+	}
+
+	enc.tree(analyser.Root)
+	checkpoint.Initial = enc.states(analyser.initialStates)
+	for _, item := range analyser.StatesQueue {
+		checkpoint.Queue = append(checkpoint.Queue, enc.state(&item.value))
+	}
+	checkpoint.Results = enc.states(analyser.Results)
+
+	if merger := analyser.Merger; merger != nil {
+		for _, states := range merger.parked {
+			checkpoint.Parked = append(checkpoint.Parked, enc.states(states)...)
+		}
+		for release := range merger.released {
+			checkpoint.Released = append(checkpoint.Released, encodedRelease{Node: nodeID(release.node), Block: *encodeBlock(release.block)})
+		}
+		checkpoint.Merges, checkpoint.MergesRejected = merger.Merges, merger.Rejected
+	}
+
+	if scheduler := analyser.Scheduler; scheduler != nil {
+		evictions := make(map[*Eviction]int)
+		for i, eviction := range scheduler.Evictions {
+			evictions[eviction] = i
+			encoded := encodedEviction{
+				NodeID:   eviction.NodeID,
+				Priority: eviction.Priority,
+				Reason:   eviction.Reason,
+				Limit:    eviction.Limit,
+				Policy:   eviction.Policy,
+				Restored: eviction.Restored,
+			}
+			if eviction.Err != nil {
+				encoded.Err = eviction.Err.Error()
+			}
+			checkpoint.Evictions = append(checkpoint.Evictions, encoded)
+		}
+		for _, suspended := range scheduler.suspended {
+			checkpoint.Suspended = append(checkpoint.Suspended, encodedSuspended{
+				Eviction:  evictions[suspended.eviction],
+				Decisions: suspended.decisions,
+				SpillFile: suspended.spillFile,
+			})
+		}
+	}
+
+	if analyser.SolverCache != nil {
+		checkpoint.Cache = analyser.SolverCache.Save(enc.exprs)
+	}
+	checkpoint.Expressions = enc.exprs.Nodes
+	return checkpoint
+}
+
+func (enc *checkpointEncoder) tree(root *ExecutionNode) {
+	var walk func(node *ExecutionNode)
+	walk = func(node *ExecutionNode) {
+		enc.checkpoint.Tree = append(enc.checkpoint.Tree, encodedNode{
+			ID:         node.ID,
+			Parent:     nodeID(node.Parent),
+			Block:      encodeBlock(node.Block),
+			MergedWith: nodeID(node.MergedWith),
+			Fork:       encodeInstr(node.Fork),
+			Branch:     node.Branch,
+			Condition:  enc.exprs.Encode(node.Condition),
+		})
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	if root != nil {
+		walk(root)
+	}
+}
+
+func (enc *checkpointEncoder) states(states []*Interpreter) []encodedState {
+	res := make([]encodedState, len(states))
+	for i, state := range states {
+		res[i] = enc.state(state)
+	}
+	return res
+}
+
+func (enc *checkpointEncoder) state(interpreter *Interpreter) encodedState {
+	state := encodedState{
+		PathCondition:    enc.condition(interpreter.PathCondition),
+		Block:            encodeBlock(interpreter.CurrentBlock),
+		InstrIndex:       interpreter.InstrIndex,
+		PrevBlock:        encodeBlock(interpreter.PrevBlock),
+		LoopCounters:     interpreter.LoopCounters,
+		MaxLoopUnroll:    interpreter.MaxLoopUnroll,
+		VisitedBlocks:    interpreter.VisitedBlocks,
+		BlockVisitCount:  interpreter.BlockVisitCount,
+		MaxCallDepth:     interpreter.MaxCallDepth,
+		CurrentCallDepth: interpreter.CurrentCallDepth,
+		VisitedFunctions: interpreter.VisitedFunctions,
+		ExecutionSteps:   interpreter.ExecutionSteps,
+		SkippedCalls:     interpreter.SkippedCalls,
+		Node:             nodeID(interpreter.Node),
+		Terminal:         interpreter.Terminal,
+		TerminalInstr:    encodeInstr(interpreter.TerminalInstr),
+		Limit:            interpreter.Limit,
+		Reason:           interpreter.Reason,
+		FreshVars:        interpreter.freshVars,
+		Trail:            enc.trail(interpreter.trail),
+	}
+	if interpreter.Heap != nil {
+		state.Heap = interpreter.Heap.Encode(enc.exprs)
+	}
+	for _, frame := range interpreter.CallStack {
+		encoded := encodedFrame{
+			Function:      frame.Function.String(),
+			LocalMemory:   make(map[string]int, len(frame.LocalMemory)),
+			ReturnValue:   enc.exprs.Encode(frame.ReturnValue),
+			Block:         encodeBlock(frame.CurrentBlock),
+			ReturnToIndex: frame.ReturnToIndex,
+			ReturnVarName: frame.ReturnVarName,
+		}
+		for name, value := range frame.LocalMemory {
+			encoded.LocalMemory[name] = enc.exprs.Encode(value)
+		}
+		state.CallStack = append(state.CallStack, encoded)
+	}
+	if len(interpreter.Globals) > 0 {
+		state.Globals = make(map[string]int, len(interpreter.Globals))
+		for global, value := range interpreter.Globals {
+			state.Globals[global.String()] = enc.exprs.Encode(value)
+		}
+	}
+	return state
+}
+
+// condition записывает условие пути вместе с префиксом; общий префикс
+// разных состояний записывается один раз
+func (enc *checkpointEncoder) condition(pc *PathCondition) int {
+	if pc == nil {
+		return -1
+	}
+	if id, ok := enc.conditions[pc]; ok {
+		return id
+	}
+	encoded := encodedCondition{
+		Parent:     enc.condition(pc.parent),
+		Constraint: enc.exprs.Encode(pc.constraint),
+		Length:     pc.length,
+		Depth:      pc.depth,
+	}
+	id := len(enc.checkpoint.Conditions)
+	enc.checkpoint.Conditions = append(enc.checkpoint.Conditions, encoded)
+	enc.conditions[pc] = id
+	return id
+}
+
+func (enc *checkpointEncoder) trail(trail *blockTrail) int {
+	if trail == nil {
+		return -1
+	}
+	if id, ok := enc.trails[trail]; ok {
+		return id
+	}
+	encoded := encodedTrail{
+		Block:  *encodeBlock(trail.block),
+		Parent: enc.trail(trail.parent),
+		Merged: enc.trail(trail.merged),
+	}
+	id := len(enc.checkpoint.Trails)
+	enc.checkpoint.Trails = append(enc.checkpoint.Trails, encoded)
+	enc.trails[trail] = id
+	return id
+}
+
+func nodeID(node *ExecutionNode) int {
+	if node == nil {
+		return -1
+	}
+	return node.ID
+}
+
+func encodeBlock(block *ssa.BasicBlock) *blockRef {
+	if block == nil {
+		return nil
+	}
+	return &blockRef{Function: block.Parent().String(), Block: block.Index}
+}
+
+func encodeInstr(instr ssa.Instruction) *instrRef {
+	if instr == nil || instr.Block() == nil {
+		return nil
+	}
+	for i, other := range instr.Block().Instrs {
+		if other == instr {
+			return &instrRef{blockRef: *encodeBlock(instr.Block()), Index: i}
+		}
+	}
+	return nil
+}
+
+// checkpointDecoder восстанавливает состояния из таблиц контрольной точки
+type checkpointDecoder struct {
+	checkpoint *Checkpoint
+	analyser   *Analyser
+	exprs      *symbolic.Decoder
+	functions  map[string]*ssa.Function
+	globals    map[string]*ssa.Global
+	nodes      map[int]*ExecutionNode
+	conditions []*PathCondition
+	trails     []*blockTrail
+}
+
+// resume восстанавливает анализатор из контрольной точки вместо начальных
+// состояний функции fn
+func (analyser *Analyser) resume(fn *ssa.Function, checkpoint *Checkpoint) error {
+	if checkpoint.Function != fn.String() {
+		return fmt.Errorf("checkpoint is for %s, not %s", checkpoint.Function, fn)
+	}
+	exprs, err := symbolic.NewDecoder(checkpoint.Expressions)
+	if err != nil {
+		return err
+	}
+	dec := &checkpointDecoder{
+		checkpoint: checkpoint,
+		analyser:   analyser,
+		exprs:      exprs,
+		functions:  make(map[string]*ssa.Function),
+		globals:    make(map[string]*ssa.Global),
+		nodes:      make(map[int]*ExecutionNode),
+	}
+	dec.index(fn.Prog)
+
+	if err := dec.tree(); err != nil {
+		return err
+	}
+	if err := dec.tables(); err != nil {
+		return err
+	}
+
+	if analyser.initialStates, err = dec.states(checkpoint.Initial); err != nil {
+		return err
+	}
+	queue, err := dec.states(checkpoint.Queue)
+	if err != nil {
+		return err
+	}
+	for _, state := range queue {
+		analyser.push(state)
+	}
+	if analyser.Results, err = dec.states(checkpoint.Results); err != nil {
+		return err
+	}
+
+	if merger := analyser.Merger; merger != nil {
+		parked, err := dec.states(checkpoint.Parked)
+		if err != nil {
+			return err
+		}
+		for _, state := range parked {
+			key := mergeKey(state)
+			merger.parked[key] = append(merger.parked[key], state)
+		}
+		for _, release := range checkpoint.Released {
+			block, err := dec.block(&release.Block)
+			if err != nil {
+				return err
+			}
+			merger.released[mergeRelease{node: dec.nodes[release.Node], block: block}] = true
+		}
+		merger.Merges, merger.Rejected = checkpoint.Merges, checkpoint.MergesRejected
+	} else if len(checkpoint.Parked) > 0 {
+		// Без слияния ожидавшие его состояния просто исследуются дальше
+		parked, err := dec.states(checkpoint.Parked)
+		if err != nil {
+			return err
+		}
+		for _, state := range parked {
+			analyser.push(state)
+		}
+	}
+
+	if scheduler := analyser.Scheduler; scheduler != nil {
+		for _, encoded := range checkpoint.Evictions {
+			eviction := &Eviction{
+				NodeID:   encoded.NodeID,
+				Priority: encoded.Priority,
+				Reason:   encoded.Reason,
+				Limit:    encoded.Limit,
+				Policy:   encoded.Policy,
+				Restored: encoded.Restored,
+			}
+			if encoded.Err != "" {
+				eviction.Err = errors.New(encoded.Err)
+			}
+			scheduler.Evictions = append(scheduler.Evictions, eviction)
+		}
+		for _, encoded := range checkpoint.Suspended {
+			if encoded.Eviction < 0 || encoded.Eviction >= len(scheduler.Evictions) {
+				return fmt.Errorf("checkpoint: no eviction %d", encoded.Eviction)
+			}
+			scheduler.suspended = append(scheduler.suspended, &suspendedState{
+				eviction:  scheduler.Evictions[encoded.Eviction],
+				decisions: encoded.Decisions,
+				spillFile: encoded.SpillFile,
+			})
+		}
+	}
+
+	if checkpoint.Cache != nil && analyser.SolverCache != nil {
+		if err := analyser.SolverCache.Load(checkpoint.Cache, exprs); err != nil {
+			return err
+		}
+	}
+
+	analyser.stepsCounter = checkpoint.Steps
+	analyser.Truncated = checkpoint.Truncated
+	for limit, n := range checkpoint.Cuts {
+		analyser.cut(limit, n)
+	}
+	analyser.nodesCounter = checkpoint.NextNodeID
+	return nil
+}
+
+// index находит функции и глобальные переменные программы по именам
+func (dec *checkpointDecoder) index(prog *ssa.Program) {
+	for fn := range ssautil.AllFunctions(prog) {
+		if _, ok := dec.functions[fn.String()]; !ok {
+			dec.functions[fn.String()] = fn
+		}
+	}
+	for _, pkg := range prog.AllPackages() {
+		for _, member := range pkg.Members {
+			if global, ok := member.(*ssa.Global); ok {
+				dec.globals[global.String()] = global
+			}
+		}
+	}
+}
+
+func (dec *checkpointDecoder) function(name string) (*ssa.Function, error) {
+	fn, ok := dec.functions[name]
+	if !ok {
+		return nil, fmt.Errorf("checkpoint: no function %s in the program", name)
+	}
+	return fn, nil
+}
+
+func (dec *checkpointDecoder) block(ref *blockRef) (*ssa.BasicBlock, error) {
+	if ref == nil {
+		return nil, nil
+	}
+	fn, err := dec.function(ref.Function)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Block < 0 || ref.Block >= len(fn.Blocks) {
+		return nil, fmt.Errorf("checkpoint: no block %d in %s", ref.Block, ref.Function)
+	}
+	return fn.Blocks[ref.Block], nil
+}
+
+func (dec *checkpointDecoder) instr(ref *instrRef) (ssa.Instruction, error) {
+	if ref == nil {
+		return nil, nil
+	}
+	block, err := dec.block(&ref.blockRef)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Index < 0 || ref.Index >= len(block.Instrs) {
+		return nil, fmt.Errorf("checkpoint: no instruction %d in block %d of %s", ref.Index, ref.Block, ref.Function)
+	}
+	return block.Instrs[ref.Index], nil
+}
+
+func (dec *checkpointDecoder) node(id int) (*ExecutionNode, error) {
+	if id == -1 {
+		return nil, nil
+	}
+	node, ok := dec.nodes[id]
+	if !ok {
+		return nil, fmt.Errorf("checkpoint: no execution node %d", id)
+	}
+	return node, nil
+}
+
+// tree восстанавливает дерево исполнения; узлы записаны в прямом порядке
+// обхода, так что родитель всегда раньше детей
+func (dec *checkpointDecoder) tree() error {
+	for _, encoded := range dec.checkpoint.Tree {
+		node := &ExecutionNode{ID: encoded.ID, Branch: encoded.Branch}
+		var err error
+		if node.Parent, err = dec.node(encoded.Parent); err != nil {
+			return err
+		}
+		if node.Block, err = dec.block(encoded.Block); err != nil {
+			return err
+		}
+		if node.Fork, err = dec.instr(encoded.Fork); err != nil {
+			return err
+		}
+		if node.Condition, err = dec.exprs.Decode(encoded.Condition); err != nil {
+			return err
+		}
+		if node.Parent == nil {
+			dec.analyser.Root = node
+		} else {
+			node.Parent.Children = append(node.Parent.Children, node)
+		}
+		dec.nodes[node.ID] = node
+	}
+	// Узел слияния может ссылаться на узел из другого поддерева
+	for _, encoded := range dec.checkpoint.Tree {
+		mergedWith, err := dec.node(encoded.MergedWith)
+		if err != nil {
+			return err
+		}
+		dec.nodes[encoded.ID].MergedWith = mergedWith
+	}
+	if dec.analyser.Root == nil {
+		return fmt.Errorf("checkpoint: empty execution tree")
+	}
+	return nil
+}
+
+// tables восстанавливает общие таблицы условий путей и пройденных блоков;
+// префикс записан раньше продолжения
+func (dec *checkpointDecoder) tables() error {
+	for i, encoded := range dec.checkpoint.Conditions {
+		constraint, err := dec.exprs.Decode(encoded.Constraint)
+		if err != nil {
+			return err
+		}
+		pc := &PathCondition{constraint: constraint, length: encoded.Length, depth: encoded.Depth}
+		if encoded.Parent != -1 {
+			if encoded.Parent < 0 || encoded.Parent >= i {
+				return fmt.Errorf("checkpoint: bad path condition %d", i)
+			}
+			pc.parent = dec.conditions[encoded.Parent]
+		}
+		dec.conditions = append(dec.conditions, pc)
+	}
+
+	trail := func(i, id int) (*blockTrail, error) {
+		if id == -1 {
+			return nil, nil
+		}
+		if id < 0 || id >= i {
+			return nil, fmt.Errorf("checkpoint: bad block trail %d", i)
+		}
+		return dec.trails[id], nil
+	}
+	for i, encoded := range dec.checkpoint.Trails {
+		block, err := dec.block(&encoded.Block)
+		if err != nil {
+			return err
+		}
+		t := &blockTrail{block: block}
+		if t.parent, err = trail(i, encoded.Parent); err != nil {
+			return err
+		}
+		if t.merged, err = trail(i, encoded.Merged); err != nil {
+			return err
+		}
+		dec.trails = append(dec.trails, t)
+	}
+	return nil
+}
+
+func (dec *checkpointDecoder) states(encoded []encodedState) ([]*Interpreter, error) {
+	res := make([]*Interpreter, 0, len(encoded))
+	for _, state := range encoded {
+		interpreter, err := dec.state(state)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, interpreter)
+	}
+	return res, nil
+}
+
+func (dec *checkpointDecoder) state(state encodedState) (*Interpreter, error) {
+	interpreter := &Interpreter{
+		Analyser:         dec.analyser,
+		InstrIndex:       state.InstrIndex,
+		LoopCounters:     orEmpty(state.LoopCounters),
+		MaxLoopUnroll:    state.MaxLoopUnroll,
+		VisitedBlocks:    orEmpty(state.VisitedBlocks),
+		BlockVisitCount:  orEmpty(state.BlockVisitCount),
+		MaxCallDepth:     state.MaxCallDepth,
+		CurrentCallDepth: state.CurrentCallDepth,
+		VisitedFunctions: orEmpty(state.VisitedFunctions),
+		ExecutionSteps:   state.ExecutionSteps,
+		SkippedCalls:     state.SkippedCalls,
+		Terminal:         state.Terminal,
+		Limit:            state.Limit,
+		Reason:           state.Reason,
+		freshVars:        state.FreshVars,
+	}
+
+	var err error
+	if interpreter.CurrentBlock, err = dec.block(state.Block); err != nil {
+		return nil, err
+	}
+	if interpreter.PrevBlock, err = dec.block(state.PrevBlock); err != nil {
+		return nil, err
+	}
+	if interpreter.TerminalInstr, err = dec.instr(state.TerminalInstr); err != nil {
+		return nil, err
+	}
+	if interpreter.Node, err = dec.node(state.Node); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case state.PathCondition == -1:
+	case state.PathCondition < 0 || state.PathCondition >= len(dec.conditions):
+		return nil, fmt.Errorf("checkpoint: no path condition %d", state.PathCondition)
+	default:
+		interpreter.PathCondition = dec.conditions[state.PathCondition]
+	}
+	switch {
+	case state.Trail == -1:
+	case state.Trail < 0 || state.Trail >= len(dec.trails):
+		return nil, fmt.Errorf("checkpoint: no block trail %d", state.Trail)
+	default:
+		interpreter.trail = dec.trails[state.Trail]
+	}
+
+	if state.Heap != nil {
+		if interpreter.Heap, err = state.Heap.Decode(dec.exprs); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, encoded := range state.CallStack {
+		frame := CallStackFrame{
+			LocalMemory:   make(map[string]symbolic.SymbolicExpression, len(encoded.LocalMemory)),
+			ReturnToIndex: encoded.ReturnToIndex,
+			ReturnVarName: encoded.ReturnVarName,
+		}
+		if frame.Function, err = dec.function(encoded.Function); err != nil {
+			return nil, err
+		}
+		if frame.CurrentBlock, err = dec.block(encoded.Block); err != nil {
+			return nil, err
+		}
+		if frame.ReturnValue, err = dec.exprs.Decode(encoded.ReturnValue); err != nil {
+			return nil, err
+		}
+		for name, id := range encoded.LocalMemory {
+			if frame.LocalMemory[name], err = dec.exprs.Decode(id); err != nil {
+				return nil, err
+			}
+		}
+		interpreter.CallStack = append(interpreter.CallStack, frame)
+	}
+
+	if len(state.Globals) > 0 {
+		interpreter.Globals = make(map[*ssa.Global]*symbolic.SymbolicPointer, len(state.Globals))
+		for name, id := range state.Globals {
+			global, ok := dec.globals[name]
+			if !ok {
+				return nil, fmt.Errorf("checkpoint: no global %s in the program", name)
+			}
+			if interpreter.Globals[global], err = dec.exprs.DecodePointer(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return interpreter, nil
+}
+
+func orEmpty[V any](m map[string]V) map[string]V {
+	if m == nil {
+		return make(map[string]V)
+	}
+	return m
+}
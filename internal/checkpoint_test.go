@@ -0,0 +1,224 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"symbolic-execution-course/internal/memory"
+	"symbolic-execution-course/internal/symbolic"
+)
+
+const checkpointSource = `package main
+
+var total int
+
+type Account struct {
+	balance int
+	limit   int
+}
+
+func Transfer(a, b, c int) int {
+	acc := &Account{limit: 10}
+	if a > 0 {
+		acc.balance += a
+		total += a
+	}
+	if b > 0 {
+		acc.balance -= b
+	}
+	if c > 0 {
+		total -= c
+	}
+	if acc.balance > acc.limit {
+		return 1
+	}
+	if total > 5 {
+		return 2
+	}
+	return 0
+}
+`
+
+// describeExpr записывает выражение вместе с тем, на что указывает указатель
+func describeExpr(expr symbolic.SymbolicExpression) string {
+	switch e := expr.(type) {
+	case nil:
+		return "nil"
+	case *symbolic.SymbolicPointer:
+		return fmt.Sprintf("%s(%s %s -> %s)", e, e.PointerType, e.Name, describeExpr(e.Expr))
+	}
+	return expr.String()
+}
+
+func describeMemory(mem *memory.SymbolicMemory) []string {
+	if mem == nil {
+		return nil
+	}
+	lines := []string{fmt.Sprintf("ids %d %d %d", mem.ObjectId, mem.ArrayId, mem.AliasesId)}
+	for ptr, value := range mem.Primitives {
+		lines = append(lines, fmt.Sprintf("primitive %s = %s", describeExpr(&ptr), describeExpr(value)))
+	}
+	for kind, cells := range map[string]map[memory.Id]map[memory.Id]symbolic.SymbolicExpression{"object": mem.Objects, "array": mem.Arrays} {
+		for id, fields := range cells {
+			for field, value := range fields {
+				lines = append(lines, fmt.Sprintf("%s %d.%d = %s", kind, id, field, describeExpr(value)))
+			}
+		}
+	}
+	for id, addr := range mem.Aliases {
+		lines = append(lines, fmt.Sprintf("alias %d = %d", id, addr))
+	}
+	for id, length := range mem.ArrLength {
+		lines = append(lines, fmt.Sprintf("length %d = %d", id, length))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// describeState записывает состояние строками, не зависящими от адресов объектов
+func describeState(state *Interpreter) []string {
+	lines := []string{
+		fmt.Sprintf("node %d", nodeID(state.Node)),
+		fmt.Sprintf("at %v:%d after %v", encodeBlock(state.CurrentBlock), state.InstrIndex, encodeBlock(state.PrevBlock)),
+		fmt.Sprintf("pc %s", state.PathCondition),
+		fmt.Sprintf("terminal %s %v %s %q", state.Terminal, encodeInstr(state.TerminalInstr), state.Limit, state.Reason),
+		fmt.Sprintf("counters %v %v %v %v", state.LoopCounters, state.BlockVisitCount, state.VisitedFunctions, state.freshVars),
+		fmt.Sprintf("steps %d depth %d skipped %d", state.ExecutionSteps, state.CurrentCallDepth, state.SkippedCalls),
+	}
+	for _, block := range state.CoveredBlocks() {
+		lines = append(lines, fmt.Sprintf("covered %v", encodeBlock(block)))
+	}
+	for i, frame := range state.CallStack {
+		lines = append(lines, fmt.Sprintf("frame %d %s at %v returns %s", i, frame.Function, encodeBlock(frame.CurrentBlock), describeExpr(frame.ReturnValue)))
+		var locals []string
+		for name, value := range frame.LocalMemory {
+			locals = append(locals, fmt.Sprintf("local %s = %s", name, describeExpr(value)))
+		}
+		sort.Strings(locals)
+		lines = append(lines, locals...)
+	}
+	var globals []string
+	for global, ptr := range state.Globals {
+		globals = append(globals, fmt.Sprintf("global %s = %s", global, describeExpr(ptr)))
+	}
+	sort.Strings(globals)
+	lines = append(lines, globals...)
+	return append(lines, describeMemory(state.Heap)...)
+}
+
+// describeAnalyser записывает всё, что сохраняет контрольная точка
+func describeAnalyser(analyser *Analyser) map[string][]string {
+	res := map[string][]string{
+		"stats": {fmt.Sprintf("steps %d nodes %d truncated %v cuts %v", analyser.stepsCounter, analyser.nodesCounter, analyser.Truncated, analyser.Cuts)},
+	}
+	var walk func(node *ExecutionNode)
+	walk = func(node *ExecutionNode) {
+		res["tree"] = append(res["tree"], fmt.Sprintf("%d <- %d at %v fork %v/%d merged %d: %s",
+			node.ID, nodeID(node.Parent), encodeBlock(node.Block), encodeInstr(node.Fork), node.Branch, nodeID(node.MergedWith), describeExpr(node.Condition)))
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(analyser.Root)
+
+	states := func(key string, states []*Interpreter) {
+		for _, state := range states {
+			res[key] = append(res[key], describeState(state)...)
+		}
+	}
+	states("initial", analyser.initialStates)
+	states("results", analyser.Results)
+	// Порядок кучи зависит от порядка вставки, поэтому очередь сравнивается по узлам
+	var queue []*Interpreter
+	for _, item := range analyser.StatesQueue {
+		state := item.value
+		queue = append(queue, &state)
+	}
+	sort.Slice(queue, func(i, j int) bool { return queue[i].Node.ID < queue[j].Node.ID })
+	states("queue", queue)
+
+	for _, eviction := range analyser.Scheduler.Evictions {
+		res["evictions"] = append(res["evictions"], fmt.Sprintf("%+v", *eviction))
+	}
+	for _, suspended := range analyser.Scheduler.suspended {
+		res["suspended"] = append(res["suspended"], fmt.Sprintf("%d %v %q", suspended.eviction.NodeID, suspended.decisions, suspended.spillFile))
+	}
+	return res
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	fn, err := BuildFunction(map[string]string{"main.go": checkpointSource}, "Transfer")
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	options := func() Options {
+		return Options{
+			Selector:  &BfsPathSelector{},
+			Scheduler: &Scheduler{Policy: EvictReplay},
+			MaxStates: 2,
+			MaxSteps:  80,
+			Globals:   GlobalsSymbolic,
+		}
+	}
+
+	analyser, err := newAnalyser(context.Background(), fn, options())
+	if err != nil {
+		t.Fatalf("newAnalyser: %v", err)
+	}
+	defer analyser.close()
+	analyser.explore()
+
+	want := describeAnalyser(analyser)
+	// Точка должна застать все виды состояний, иначе проверять нечего
+	for _, key := range []string{"initial", "queue", "results", "evictions", "suspended"} {
+		if len(want[key]) == 0 {
+			t.Fatalf("no %s to save: %v", key, want)
+		}
+	}
+	if len(analyser.StatesQueue) < 2 {
+		t.Fatalf("%d states in the queue, want several", len(analyser.StatesQueue))
+	}
+
+	path := filepath.Join(t.TempDir(), "Transfer.checkpoint.json")
+	if err := analyser.checkpoint().WriteFile(path); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	checkpoint, err := ReadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("ReadCheckpoint: %v", err)
+	}
+	resumedOptions := options()
+	resumedOptions.Resume = checkpoint
+	resumed, err := newAnalyser(context.Background(), fn, resumedOptions)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	defer resumed.close()
+
+	got := describeAnalyser(resumed)
+	for key := range want {
+		if !reflect.DeepEqual(got[key], want[key]) {
+			t.Errorf("%s after resume:\n%v\nwant\n%v", key, got[key], want[key])
+		}
+	}
+
+	// Состояния очереди по-прежнему разделяют префиксы условий путей
+	prefixes := func(analyser *Analyser) []int {
+		var res []int
+		for i, a := range analyser.StatesQueue {
+			for _, b := range analyser.StatesQueue[i+1:] {
+				prefix := commonPrefix(a.value.PathCondition, b.value.PathCondition)
+				res = append(res, prefix.Len())
+			}
+		}
+		sort.Ints(res)
+		return res
+	}
+	if got, want := prefixes(resumed), prefixes(analyser); !reflect.DeepEqual(got, want) {
+		t.Errorf("shared prefix lengths %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"go/token"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// CoverageProfile - покрытие исходного кода найденными путями в формате
+// профиля go tool cover (mode: set). Единица покрытия - строка исходного
+// кода: строка покрыта, если пройден хотя бы один блок с её инструкциями.
+// Профиль накапливается по всем проанализированным функциям.
+type CoverageProfile struct {
+	// Files - имена файлов в профиле вместо имён из позиций SSA, например
+	// путь на диске для исходного кода, переданного строкой
+	Files map[string]string
+
+	fset  *token.FileSet
+	lines map[string]map[int]*coverLine
+	seen  map[*ssa.Function]bool
+}
+
+// coverLine - покрываемая часть строки: от первой инструкции до конца строки
+type coverLine struct {
+	startColumn int
+	endColumn   int
+	covered     bool
+}
+
+// NewCoverageProfile создаёт пустой профиль
+func NewCoverageProfile() *CoverageProfile {
+	return &CoverageProfile{
+		Files: make(map[string]string),
+		lines: make(map[string]map[int]*coverLine),
+		seen:  make(map[*ssa.Function]bool),
+	}
+}
+
+// Add добавляет в профиль блоки, пройденные путями results. Непокрытыми
+// записываются остальные строки функций пакетов точек входа, которых
+// достигли пути, и их замыканий.
+func (profile *CoverageProfile) Add(results []*Interpreter) {
+	covered := make(map[*ssa.BasicBlock]bool)
+	entries := make(map[*ssa.Package]bool)
+	for _, result := range results {
+		if len(result.CallStack) > 0 {
+			entries[result.CallStack[0].Function.Pkg] = true
+		}
+		for _, block := range result.CoveredBlocks() {
+			covered[block] = true
+		}
+	}
+
+	var fns []*ssa.Function
+	for _, block := range sortedBlocks(covered) {
+		if fn := block.Parent(); entries[fn.Pkg] && (len(fns) == 0 || fns[len(fns)-1] != fn) {
+			fns = append(fns, fn)
+		}
+	}
+
+	var add func(fn *ssa.Function)
+	add = func(fn *ssa.Function) {
+		for _, block := range fn.Blocks {
+			profile.addBlock(block, covered[block])
+		}
+		for _, anon := range fn.AnonFuncs {
+			add(anon)
+		}
+	}
+	for _, fn := range fns {
+		if profile.fset == nil {
+			profile.fset = fn.Prog.Fset
+		}
+		if !profile.seen[fn] {
+			profile.seen[fn] = true
+			add(fn)
+			continue
+		}
+		for _, block := range fn.Blocks {
+			if covered[block] {
+				profile.addBlock(block, true)
+			}
+		}
+	}
+}
+
+// addBlock отмечает строки инструкций блока
+func (profile *CoverageProfile) addBlock(block *ssa.BasicBlock, covered bool) {
+	for _, instr := range block.Instrs {
+		pos := instr.Pos()
+		if !pos.IsValid() {
+			continue
+		}
+		file := profile.fset.File(pos)
+		position := profile.fset.Position(pos)
+		name := profile.fileName(block.Parent().Pkg, position.Filename)
+
+		lines, ok := profile.lines[name]
+		if !ok {
+			lines = make(map[int]*coverLine)
+			profile.lines[name] = lines
+		}
+		line, ok := lines[position.Line]
+		if !ok {
+			line = &coverLine{startColumn: position.Column, endColumn: lineEnd(file, position.Line)}
+			lines[position.Line] = line
+		}
+		line.startColumn = min(line.startColumn, position.Column)
+		line.covered = line.covered || covered
+	}
+}
+
+// lineEnd - колонка конца строки line, не включая перевод строки
+func lineEnd(file *token.File, line int) int {
+	end := file.Size()
+	if line < file.LineCount() {
+		end = file.Offset(file.LineStart(line+1)) - 1
+	}
+	return end - file.Offset(file.LineStart(line)) + 1
+}
+
+// fileName - имя файла в профиле. Файлы пакетов модуля записываются, как
+// в профилях go test: путь импорта пакета и имя файла, чтобы профили можно
+// было объединять.
+func (profile *CoverageProfile) fileName(pkg *ssa.Package, filename string) string {
+	if name, ok := profile.Files[filename]; ok {
+		return name
+	}
+	if pkg != nil && filepath.IsAbs(filename) && pkg.Pkg.Path() != "command-line-arguments" {
+		return path.Join(pkg.Pkg.Path(), filepath.Base(filename))
+	}
+	return filename
+}
+
+// WriteTo записывает профиль, упорядочив строки по файлам и номерам
+func (profile *CoverageProfile) WriteTo(w io.Writer) (int64, error) {
+	files := make([]string, 0, len(profile.lines))
+	for name := range profile.lines {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+
+	out := bufio.NewWriter(w)
+	n, _ := fmt.Fprintln(out, "mode: set")
+	written := int64(n)
+	for _, name := range files {
+		lines := profile.lines[name]
+		numbers := make([]int, 0, len(lines))
+		for number := range lines {
+			numbers = append(numbers, number)
+		}
+		sort.Ints(numbers)
+
+		for _, number := range numbers {
+			line := lines[number]
+			count := 0
+			if line.covered {
+				count = 1
+			}
+			n, _ := fmt.Fprintf(out, "%s:%d.%d,%d.%d 1 %d\n", name, number, line.startColumn, number, line.endColumn, count)
+			written += int64(n)
+		}
+	}
+	return written, out.Flush()
+}
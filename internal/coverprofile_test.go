@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCoverageProfile(t *testing.T) {
+	profile := NewCoverageProfile()
+	profile.Files["main.go"] = "example.com/m/main.go"
+	for _, function := range []string{"Dead", "Negative", "Dead"} {
+		fn, err := BuildFunction(map[string]string{"main.go": findingsSource}, function)
+		if err != nil {
+			t.Fatalf("build: %v", err)
+		}
+		results, err := ExploreFunction(context.Background(), fn, Options{})
+		if err != nil {
+			t.Fatalf("explore: %v", err)
+		}
+		profile.Add(results)
+	}
+
+	var sb strings.Builder
+	if _, err := profile.WriteTo(&sb); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	counts := make(map[int]string)
+	for i, line := range strings.Split(strings.TrimSpace(sb.String()), "\n") {
+		if i == 0 {
+			if line != "mode: set" {
+				t.Fatalf("header = %q, want mode: set", line)
+			}
+			continue
+		}
+		var number, start, end, statements int
+		var count string
+		if _, err := fmt.Sscanf(line, "example.com/m/main.go:%d.%d,%d.%d %d %s", &number, &start, &end, &end, &statements, &count); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		counts[number] = count
+	}
+
+	dead := lineOf(t, findingsSource, "dead")
+	tests := []struct {
+		name  string
+		line  int
+		count string
+	}{
+		{"outer condition", dead - 1, "1"},
+		{"inner condition", dead, "1"},
+		{"never taken branch", dead + 1, "0"},
+		{"fall through", dead + 4, "1"},
+		{"panic", lineOf(t, findingsSource, "panic"), "1"},
+		// Функции, не достигнутые ни одним путём, в профиль не попадают
+		{"unanalysed function", lineOf(t, findingsSource, "convert"), ""},
+	}
+	for _, tt := range tests {
+		if counts[tt.line] != tt.count {
+			t.Errorf("%s: line %d count = %q, want %q\n%s", tt.name, tt.line, counts[tt.line], tt.count, sb.String())
+		}
+	}
+}
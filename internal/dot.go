@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// WriteDOT записывает дерево исполнения в формате Graphviz DOT.
+// Узлы ветвления подписаны блоком и инструкцией ветвления, рёбра - условием
+// ветки, листья - тем, чем закончился путь. Слияния показаны пунктиром.
+func WriteDOT(w io.Writer, root *ExecutionNode, results []*Interpreter) error {
+	finished := make(map[*ExecutionNode]*Interpreter, len(results))
+	for _, result := range results {
+		if result.Node != nil {
+			finished[result.Node] = result
+		}
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "digraph execution {")
+	fmt.Fprintln(out, "\tnode [shape=box, fontname=monospace];")
+
+	var walk func(node *ExecutionNode)
+	walk = func(node *ExecutionNode) {
+		fmt.Fprintf(out, "\tn%d [label=%s%s];\n", node.ID, dotQuote(nodeLabel(node, finished[node])), nodeStyle(node, finished[node]))
+		for _, child := range node.Children {
+			fmt.Fprintf(out, "\tn%d -> n%d [label=%s];\n", node.ID, child.ID, dotQuote(branchLabel(node, child)))
+			walk(child)
+		}
+		if node.MergedWith != nil {
+			fmt.Fprintf(out, "\tn%d -> n%d [style=dashed, label=\"merge\"];\n", node.MergedWith.ID, node.ID)
+		}
+	}
+	if root != nil {
+		walk(root)
+	}
+
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
+
+func nodeLabel(node *ExecutionNode, result *Interpreter) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "#%d", node.ID)
+	if node.Block != nil {
+		fmt.Fprintf(&sb, " %s block %d", node.Block.Parent().Name(), node.Block.Index)
+	}
+	if node.Fork != nil {
+		fmt.Fprintf(&sb, "\n%s", forkString(node.Fork))
+	}
+
+	switch {
+	case result != nil:
+		fmt.Fprintf(&sb, "\n%s", result.Terminal)
+		if frame := result.GetCurrentFrame(); frame != nil && frame.ReturnValue != nil {
+			fmt.Fprintf(&sb, ": %s", frame.ReturnValue.String())
+		}
+	case len(node.Children) == 0:
+		// Лист без результата: состояние отброшено ограничениями или не доисследовано
+		sb.WriteString("\nunfinished")
+	}
+	return sb.String()
+}
+
+func nodeStyle(node *ExecutionNode, result *Interpreter) string {
+	switch {
+	case result != nil && result.Terminal == TerminalPanic:
+		return ", color=red"
+	case result != nil && result.Terminal == TerminalTruncated:
+		return ", color=orange"
+	case result != nil && result.Terminal == TerminalUnsupported:
+		return ", color=gray"
+	case result != nil:
+		return ", color=darkgreen"
+	case len(node.Children) == 0:
+		return ", style=dashed"
+	}
+	return ""
+}
+
+// branchLabel подписывает ребро от узла ветвления к ветке child
+func branchLabel(node *ExecutionNode, child *ExecutionNode) string {
+	if child.MergedWith != nil {
+		return "merge"
+	}
+	if child.Condition != nil {
+		return child.Condition.String()
+	}
+	switch node.Fork.(type) {
+	case *ssa.TypeAssert:
+		if child.Branch == 0 {
+			return "ok"
+		}
+		return "fails"
+	case *ssa.Select:
+		return fmt.Sprintf("case %d", child.Branch)
+	}
+	return fmt.Sprintf("#%d", child.Branch)
+}
+
+func forkString(instr ssa.Instruction) string {
+	if ifInstr, ok := instr.(*ssa.If); ok {
+		return "if " + ifInstr.Cond.String()
+	}
+	if value, ok := instr.(ssa.Value); ok {
+		return value.Name() + " = " + value.String()
+	}
+	return instr.String()
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
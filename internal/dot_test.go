@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	tests := []struct {
+		source   string
+		function string
+		merge    bool
+		// contains - фрагменты, которые должны быть в выводе
+		contains []string
+	}{
+		{findingsSource, "Negative", false, []string{"if x < 0:int", "color=red", "color=darkgreen", "panic"}},
+		{findingsSource, "Dead", false, []string{"if x > 5:int", "return: 0"}},
+		{mergeSource, "Diamond", true, []string{`[style=dashed, label="merge"]`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			fn, err := BuildFunction(map[string]string{"main.go": tt.source}, tt.function)
+			if err != nil {
+				t.Fatalf("build: %v", err)
+			}
+			options := Options{}
+			if tt.merge {
+				options.Merger = NewStateMerger()
+			}
+			results, err := ExploreFunction(context.Background(), fn, options)
+			if err != nil || len(results) == 0 {
+				t.Fatalf("explore: %d paths, %v", len(results), err)
+			}
+
+			var sb strings.Builder
+			if err := WriteDOT(&sb, results[0].Node.Root(), results); err != nil {
+				t.Fatalf("WriteDOT: %v", err)
+			}
+			dot := sb.String()
+			if !strings.HasPrefix(dot, "digraph execution {") || !strings.HasSuffix(dot, "}\n") {
+				t.Errorf("not a digraph:\n%s", dot)
+			}
+			for _, fragment := range tt.contains {
+				if !strings.Contains(dot, fragment) {
+					t.Errorf("missing %q in\n%s", fragment, dot)
+				}
+			}
+			// Каждый путь - лист с цветом исхода
+			if leaves := strings.Count(dot, "color="); leaves != len(results) {
+				t.Errorf("%d coloured leaves, want %d:\n%s", leaves, len(results), dot)
+			}
+		})
+	}
+}
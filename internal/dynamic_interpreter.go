@@ -14,16 +14,11 @@ import (
 	"golang.org/x/tools/go/ssa"
 )
 
-// TODO: that is silly
-var VARCOUNTER = 0
-const maxTotalUnrolls = 100
-const maxLoopUnroll = 10
-const maxExecutionSteps = 10000
 
 type Interpreter struct {
 	CallStack     []CallStackFrame
 	Analyser      *Analyser
-	PathCondition symbolic.SymbolicExpression
+	PathCondition *PathCondition
 	Heap          *memory.SymbolicMemory // TODO: delete it from translater since we use it here
 	CurrentBlock  *ssa.BasicBlock
 	InstrIndex    int
@@ -36,6 +31,32 @@ type Interpreter struct {
 	CurrentCallDepth int
 	VisitedFunctions map[string]bool
 	ExecutionSteps   int
+	// SkippedCalls - сколько вызовов путь не исполнил из-за глубины вызовов
+	// или рекурсии; их результаты - непрозрачные значения
+	SkippedCalls int
+
+	// Node - узел дерева исполнения, которому принадлежит состояние
+	Node *ExecutionNode
+
+	// Globals - ячейки глобальных переменных в куче, см. globalCell
+	Globals map[*ssa.Global]*symbolic.SymbolicPointer
+
+	// Terminal - чем закончился путь, если состояние завершено
+	Terminal TerminalKind
+	// TerminalInstr - инструкция, вызвавшая панику, см. TerminalPanic,
+	// или неподдерживаемая инструкция, см. TerminalUnsupported
+	TerminalInstr ssa.Instruction
+	// Limit - ограничение, оборвавшее путь, см. TerminalTruncated
+	Limit Limit
+	// Reason - почему путь не исполнен до конца, см. TerminalUnsupported
+	Reason string
+
+	// freshVars - счётчик безымянных переменных пути, см. freshName
+	freshVars int
+	// trail - пройденные блоки, см. CoveredBlocks
+	trail *blockTrail
+	// worker - исполнитель, выполняющий текущий шаг; nil - сам анализатор
+	worker *worker
 }
 
 func (interpreter *Interpreter) TranslateAndOutput(expr symbolic.SymbolicExpression) string {
@@ -93,7 +114,7 @@ func ssaTypeToSymbolicType(ty types.Type) symbolic.ExpressionType {
 		// case types.String:
 			// return symbolic.StringType
 		default:
-			panic("unimplemented type " + ty.String())
+			unsupported("type %s", ty)
 		}
 	case *types.Pointer:
 		return symbolic.AddrType
@@ -107,13 +128,45 @@ func ssaTypeToSymbolicType(ty types.Type) symbolic.ExpressionType {
 	    // user-defined type
 		return symbolic.ObjType
 	default:
-		panic("unimplemented type " + ty.String())
+		unsupported("type %s", ty)
 	}
 
 	// like `auto` in C, huh
 	return symbolic.IntType
 }
 
+// opaqueValue - значение результата, который не вычисляется интерпретатором:
+// кортеж для нескольких результатов, иначе символьная переменная
+func opaqueValue(name string, ty types.Type) symbolic.SymbolicExpression {
+	switch t := ty.(type) {
+	case *types.Tuple:
+		elements := make([]symbolic.SymbolicExpression, t.Len())
+		for i := range elements {
+			elements[i] = opaqueValue(fmt.Sprintf("%s_%d", name, i), t.At(i).Type())
+		}
+		return symbolic.NewTuple(elements)
+	}
+	if basic, ok := ty.Underlying().(*types.Basic); ok {
+		return symbolic.NewSymbolicVariable(name, basicSymbolicType(basic))
+	}
+	return symbolic.NewSymbolicVariable(name, symbolic.IntType)
+}
+
+// fmtResult - результат функций fmt: нулевое число и нулевая ошибка
+func fmtResult(ty types.Type) symbolic.SymbolicExpression {
+	if t, ok := ty.(*types.Tuple); ok {
+		elements := make([]symbolic.SymbolicExpression, t.Len())
+		for i := range elements {
+			elements[i] = fmtResult(t.At(i).Type())
+		}
+		return symbolic.NewTuple(elements)
+	}
+	if types.Identical(ty, types.Universe.Lookup("error").Type()) {
+		return symbolic.NewSymbolicPointer(0, symbolic.AddrType)
+	}
+	return symbolic.NewIntConstant(0)
+}
+
 func (interpreter *Interpreter) LoopEval() {
 	if interpreter.LoopCounters == nil {
 		interpreter.LoopCounters = make(map[string]int)
@@ -122,7 +175,7 @@ func (interpreter *Interpreter) LoopEval() {
 		interpreter.VisitedBlocks = make(map[string]bool)
 	}
 	if interpreter.MaxLoopUnroll == 0 {
-		interpreter.MaxLoopUnroll = maxLoopUnroll
+		interpreter.MaxLoopUnroll = interpreter.options().LoopBound
 	}
 	if interpreter.BlockVisitCount == nil {
 		interpreter.BlockVisitCount = make(map[string]int)
@@ -132,10 +185,6 @@ func (interpreter *Interpreter) LoopEval() {
 //#========= HELPERS =========#
 
 func (interpreter *Interpreter) interpretDynamically(element ssa.Instruction) []*Interpreter {
-	if interpreter.ExecutionSteps >= maxExecutionSteps {
-		interpreter.CurrentBlock = nil
-		return []*Interpreter{}
-	}
 	interpreter.ExecutionSteps++
 
 	interpreter.LoopEval()
@@ -229,8 +278,7 @@ func (interpreter *Interpreter) resolveExpression(value ssa.Value) symbolic.Symb
 	default:
 		name := v.Name()
 		if name == "" {
-			name = "var" + strconv.Itoa(VARCOUNTER)
-			VARCOUNTER += 1
+			name = interpreter.freshName()
 		}
 		return symbolic.NewSymbolicVariable(name, symbolic.IntType)
 	}
@@ -246,10 +294,10 @@ func (interpreter *Interpreter) ToString() string {
 	out.WriteString("\n#========== ИНТЕРПРЕТАЦИЯ ==========#\n#\n")
 
 	out.WriteString("# PathCondition: ")
-	if interpreter.PathCondition == nil {
+	if interpreter.PathCondition.Len() == 0 {
 		out.WriteString("true\n")
 	} else {
-		conditionStr := interpreter.TranslateAndOutput(interpreter.PathCondition)
+		conditionStr := interpreter.TranslateAndOutput(interpreter.PathCondition.Expression())
 		out.WriteString(fmt.Sprintf("%s\n", conditionStr))
 	}
 
@@ -265,6 +313,8 @@ func (interpreter *Interpreter) ToString() string {
 
 func (interpreter *Interpreter) interpretPanic(instr *ssa.Panic) []*Interpreter {
 	interpreter.CurrentBlock = nil
+	interpreter.Terminal = TerminalPanic
+	interpreter.TerminalInstr = instr
 	// todo!()
 	return []*Interpreter{interpreter}
 }
@@ -363,6 +413,8 @@ func (interpreter *Interpreter) interpretTypeAssert(instr *ssa.TypeAssert) []*In
 
 	failureInterpreter := interpreter.Copy()
 	failureInterpreter.CurrentBlock = nil
+	failureInterpreter.Terminal = TerminalPanic
+	failureInterpreter.TerminalInstr = instr
 	states = append(states, failureInterpreter)
 
 	return states
@@ -375,25 +427,13 @@ func (interpreter *Interpreter) interpretExtract(instr *ssa.Extract) []*Interpre
 		return []*Interpreter{interpreter}
 	}
 
-	tuple := interpreter.ResolveExpression(instr.Tuple)
-
 	var result symbolic.SymbolicExpression
 
-	if tuple != nil {
-		resultName := fmt.Sprintf("extract_%d_from_%s", instr.Index, tuple.String())
-
-		if instr.Index == 0 {
-			result = symbolic.NewSymbolicVariable(resultName, symbolic.IntType)
-		} else if instr.Index == 1 {
-			result = symbolic.NewSymbolicVariable(resultName, symbolic.BoolType)
-		} else {
-			result = symbolic.NewSymbolicVariable(resultName, symbolic.IntType)
-		}
+	tuple, ok := interpreter.ResolveExpression(instr.Tuple).(*symbolic.Tuple)
+	if ok && instr.Index < len(tuple.Elements) {
+		result = tuple.Elements[instr.Index]
 	} else {
-		result = symbolic.NewSymbolicVariable(
-			fmt.Sprintf("extract_%d", instr.Index),
-			symbolic.IntType,
-		)
+		result = opaqueValue(fmt.Sprintf("extract_%d_from_%s", instr.Index, instr.Tuple.Name()), instr.Type())
 	}
 
 	if instr.Name() != "" {
@@ -489,6 +529,11 @@ func (interpreter *Interpreter) ResolveExpression(value ssa.Value) symbolic.Symb
 		return symbolic.NewIntConstant(0)
 	}
 
+	// Глобальные переменные разрешаются раньше локальных: имена могут совпадать
+	if global, ok := value.(*ssa.Global); ok {
+		return interpreter.resolveGlobal(global)
+	}
+
 	if value.Name() != "" {
 		frame := interpreter.GetCurrentFrame()
 		if frame != nil {
@@ -554,16 +599,14 @@ func (interpreter *Interpreter) interpretReturn(instr *ssa.Return) []*Interprete
 		return []*Interpreter{interpreter}
 	}
 
-	if len(instr.Results) > 0 {
-		if len(instr.Results) == 1 {
-			frame.ReturnValue = interpreter.ResolveExpression(instr.Results[0])
-		} else {
-			frame.ReturnValue = interpreter.ResolveExpression(instr.Results[0])
-			if len(instr.Results) > 1 {
-				errorValue := interpreter.ResolveExpression(instr.Results[1])
-				frame.LocalMemory["$error"] = errorValue
-			}
+	if len(instr.Results) == 1 {
+		frame.ReturnValue = interpreter.ResolveExpression(instr.Results[0])
+	} else if len(instr.Results) > 1 {
+		results := make([]symbolic.SymbolicExpression, len(instr.Results))
+		for i, result := range instr.Results {
+			results[i] = interpreter.ResolveExpression(result)
 		}
+		frame.ReturnValue = symbolic.NewTuple(results)
 	}
 
 	if len(interpreter.CallStack) > 1 {
@@ -611,10 +654,6 @@ func (interpreter *Interpreter) interpretRecursiveStruct(instr *ssa.Alloc) []*In
 }
 
 func (interpreter *Interpreter) interpretIf(instr *ssa.If) []*Interpreter {
-	if interpreter.ExecutionSteps >= maxExecutionSteps {
-		return []*Interpreter{}
-	}
-
 	condExpr := interpreter.ResolveExpression(instr.Cond)
 
 	condExpr = interpreter.convertToBool(condExpr)
@@ -624,21 +663,12 @@ func (interpreter *Interpreter) interpretIf(instr *ssa.If) []*Interpreter {
 
 	notCond := symbolic.NewUnaryOperation(condExpr, symbolic.NOT)
 
-	trueInterpreter.PathCondition = simplifyPathCondition(
-		symbolic.NewLogicalOperation(
-			[]symbolic.SymbolicExpression{interpreter.PathCondition, condExpr},
-			symbolic.AND,
-		))
-
-	falseInterpreter.PathCondition = simplifyPathCondition(
-		symbolic.NewLogicalOperation(
-			[]symbolic.SymbolicExpression{interpreter.PathCondition, notCond},
-			symbolic.AND,
-		))
+	trueInterpreter.PathCondition = interpreter.PathCondition.Append(condExpr)
+	falseInterpreter.PathCondition = interpreter.PathCondition.Append(notCond)
 
 	results := []*Interpreter{}
 
-	if !isContradiction(trueInterpreter.PathCondition) {
+	if interpreter.isFeasible(trueInterpreter.PathCondition, condExpr) {
 		trueInterpreter.PrevBlock = interpreter.CurrentBlock
 		if len(instr.Block().Succs) >= 2 {
 			trueInterpreter.CurrentBlock = instr.Block().Succs[0]
@@ -647,7 +677,7 @@ func (interpreter *Interpreter) interpretIf(instr *ssa.If) []*Interpreter {
 		}
 	}
 
-	if !isContradiction(falseInterpreter.PathCondition) {
+	if interpreter.isFeasible(falseInterpreter.PathCondition, notCond) {
 		falseInterpreter.PrevBlock = interpreter.CurrentBlock
 		if len(instr.Block().Succs) >= 2 {
 			falseInterpreter.CurrentBlock = instr.Block().Succs[1]
@@ -659,28 +689,41 @@ func (interpreter *Interpreter) interpretIf(instr *ssa.If) []*Interpreter {
 	return results
 }
 
+// isFeasible проверяет новое условие пути: сначала синтаксически целиком,
+// затем решателем только в части, связанной с условием ветвления
+func (interpreter *Interpreter) isFeasible(pathCondition *PathCondition, branchCond symbolic.SymbolicExpression) bool {
+	if isContradiction(pathCondition.Expression()) {
+		return false
+	}
+	if interpreter.worker != nil {
+		return interpreter.worker.isSatisfiable(interpreter.PathCondition, branchCond)
+	}
+	return interpreter.Analyser.isSatisfiable(interpreter.PathCondition, branchCond)
+}
+
 func (interpreter *Interpreter) interpretJump(instr *ssa.Jump) []*Interpreter {
 	if len(instr.Block().Succs) > 0 {
 		nextBlock := instr.Block().Succs[0]
 
 		interpreter.PrevBlock = interpreter.CurrentBlock
 
-		blockKey := fmt.Sprintf("%p", nextBlock)
+		// Ключи - имена, а не адреса, чтобы счётчики переживали контрольную точку
+		blockKey := fmt.Sprintf("%s#%d", nextBlock.Parent(), nextBlock.Index)
 		visitCount := interpreter.BlockVisitCount[blockKey]
 
+		options := interpreter.options()
 		if visitCount >= interpreter.MaxLoopUnroll {
-			interpreter.CurrentBlock = nil
+			interpreter.truncate(LimitLoopBound)
 			return []*Interpreter{interpreter}
 		}
 
-		if interpreter.totalUnrolls() >= maxTotalUnrolls {
-			interpreter.CurrentBlock = nil
+		if interpreter.totalUnrolls() >= options.MaxUnrolls {
+			interpreter.truncate(LimitUnrolls)
 			return []*Interpreter{interpreter}
 		}
 
-		pcStr := interpreter.PathCondition.String()
-		if strings.Count(pcStr, "&&") > 20 || strings.Count(pcStr, "(") > 50 || len(pcStr) > 500 {
-			interpreter.CurrentBlock = nil
+		if interpreter.PathCondition.exceeds(options.MaxLoopPathLength, options.MaxLoopPathDepth) {
+			interpreter.truncate(LimitPathLength)
 			return []*Interpreter{interpreter}
 		}
 
@@ -984,6 +1027,11 @@ func (interpreter *Interpreter) interpretStore(instr *ssa.Store) []*Interpreter
 	addr := interpreter.ResolveExpression(instr.Addr)
 	value := interpreter.ResolveExpression(instr.Val)
 
+	if interpreter.storeGlobal(instr.Addr, value) {
+		interpreter.InstrIndex++
+		return []*Interpreter{interpreter}
+	}
+
 	frame := interpreter.GetCurrentFrame()
 
 	if fieldAddr, ok := addr.(*symbolic.FieldAddr); ok {
@@ -1083,6 +1131,8 @@ func (interpreter *Interpreter) handleBuiltinCall(instr *ssa.Call, builtin *ssa.
 		}
 	case "panic":
 		interpreter.CurrentBlock = nil
+		interpreter.Terminal = TerminalPanic
+		interpreter.TerminalInstr = instr
 		return []*Interpreter{interpreter}
 	case "recover":
 		result = symbolic.NewSymbolicPointer(0, symbolic.AddrType)
@@ -1143,6 +1193,8 @@ func (interpreter *Interpreter) handleRecursiveCall(instr *ssa.Call, fn *ssa.Fun
 		return []*Interpreter{interpreter}
 	}
 
+	interpreter.SkippedCalls++
+
 	funcName := "recursive"
 	if fn != nil {
 		funcName = fn.Name()
@@ -1170,10 +1222,13 @@ func (interpreter *Interpreter) handleRecursiveCall(instr *ssa.Call, fn *ssa.Fun
 			}
 		}
 	} else {
-		result := symbolic.NewSymbolicVariable(
+		var result symbolic.SymbolicExpression = symbolic.NewSymbolicVariable(
 			fmt.Sprintf("recursive_%s_depth_%d", funcName, interpreter.CurrentCallDepth),
 			symbolic.IntType,
 		)
+		if results, ok := instr.Type().(*types.Tuple); ok {
+			result = opaqueValue(fmt.Sprintf("recursive_%s_depth_%d", funcName, interpreter.CurrentCallDepth), results)
+		}
 
 		if instr.Name() != "" {
 			frame.LocalMemory[instr.Name()] = result
@@ -1192,7 +1247,10 @@ func (interpreter *Interpreter) handleUnknownCall(instr *ssa.Call) []*Interprete
 		funcName = instr.Call.Value.Name()
 	}
 
-	result := symbolic.NewSymbolicVariable(funcName+"_result", symbolic.IntType)
+	var result symbolic.SymbolicExpression = symbolic.NewSymbolicVariable(funcName+"_result", symbolic.IntType)
+	if results, ok := instr.Type().(*types.Tuple); ok {
+		result = opaqueValue(funcName+"_result", results)
+	}
 
 	if frame != nil && instr.Name() != "" {
 		frame.LocalMemory[instr.Name()] = result
@@ -1223,7 +1281,7 @@ func (interpreter *Interpreter) handleFunctionCall(instr *ssa.Call, fn *ssa.Func
 		}
 	}
 
-	funcKey := fmt.Sprintf("%p", fn)
+	funcKey := fn.String()
 	newFrame := CallStackFrame{
 		Function:      fn,
 		LocalMemory:   make(map[string]symbolic.SymbolicExpression),
@@ -1327,18 +1385,18 @@ func (interpreter *Interpreter) interpretCall(instr *ssa.Call) []*Interpreter {
 
 		if fn.Pkg != nil && fn.Pkg.Pkg != nil && fn.Pkg.Pkg.Path() == "fmt" {
 			if instr.Name() != "" {
-				var result symbolic.SymbolicExpression
-				if strings.Contains(fn.Signature.String(), "error") {
-					result = symbolic.NewSymbolicPointer(0, symbolic.AddrType)
-				} else {
-					result = symbolic.NewIntConstant(0)
-				}
-				frame.LocalMemory[instr.Name()] = result
+				frame.LocalMemory[instr.Name()] = fmtResult(instr.Type())
 			}
 			interpreter.InstrIndex++
 			return []*Interpreter{interpreter}
 		}
 
+		// Инициализаторы импортированных пакетов не исполняются, см. GlobalsInit
+		if fn.Name() == "init" && fn.Pkg != interpreter.CallStack[0].Function.Pkg {
+			interpreter.InstrIndex++
+			return []*Interpreter{interpreter}
+		}
+
 		for _, stackFrame := range interpreter.CallStack {
 			if stackFrame.Function == fn {
 				return interpreter.handleRecursiveCall(instr, fn)
@@ -1580,37 +1638,41 @@ func (interpreter *Interpreter) interpretLoad(instr *ssa.UnOp) []*Interpreter {
 
 	var result symbolic.SymbolicExpression
 
-	switch a := addr.(type) {
-	case *symbolic.SymbolicPointer:
-		result = interpreter.Heap.GetFieldValue(a, 0, ssaTypeToSymbolicType(instr.Type()))
-		if result == nil {
+	if value, ok := interpreter.loadGlobal(instr.X); ok {
+		result = value
+	} else {
+		switch a := addr.(type) {
+		case *symbolic.SymbolicPointer:
+			result = interpreter.Heap.GetFieldValue(a, 0, ssaTypeToSymbolicType(instr.Type()))
+			if result == nil {
+				typeStr := instr.Type().String()
+				if strings.Contains(typeStr, "bool") {
+					result = symbolic.NewBoolConstant(false)
+				} else {
+					result = symbolic.NewSymbolicVariable(
+						fmt.Sprintf("*ref_%d", a.Address),
+						symbolic.IntType,
+					)
+				}
+			}
+		case *symbolic.FieldAddr:
+			result = interpreter.Heap.GetFieldValue(a.Ptr, a.FieldIndex, ssaTypeToSymbolicType(instr.Type()))
+			if result == nil {
+				result = symbolic.NewBoolConstant(false)
+			}
+		case *symbolic.IndexAddr:
+			result = interpreter.Heap.GetFromArray(a.Ptr, a.Index, ssaTypeToSymbolicType(instr.Type()))
+			if result == nil {
+				result = symbolic.NewBoolConstant(false)
+			}
+		default:
 			typeStr := instr.Type().String()
 			if strings.Contains(typeStr, "bool") {
 				result = symbolic.NewBoolConstant(false)
 			} else {
-				result = symbolic.NewSymbolicVariable(
-					fmt.Sprintf("*ref_%d", a.Address),
-					symbolic.IntType,
-				)
+				result = symbolic.NewIntConstant(0)
 			}
 		}
-	case *symbolic.FieldAddr:
-		result = interpreter.Heap.GetFieldValue(a.Ptr, a.FieldIndex, ssaTypeToSymbolicType(instr.Type()))
-		if result == nil {
-			result = symbolic.NewBoolConstant(false)
-		}
-	case *symbolic.IndexAddr:
-		result = interpreter.Heap.GetFromArray(a.Ptr, a.Index, ssaTypeToSymbolicType(instr.Type()))
-		if result == nil {
-			result = symbolic.NewBoolConstant(false)
-		}
-	default:
-		typeStr := instr.Type().String()
-		if strings.Contains(typeStr, "bool") {
-			result = symbolic.NewBoolConstant(false)
-		} else {
-			result = symbolic.NewIntConstant(0)
-		}
 	}
 
 	result = simplifyExpression(result)
@@ -1629,34 +1691,38 @@ func (interpreter *Interpreter) resolveLoad(l *ssa.UnOp) symbolic.SymbolicExpres
 
 	var result symbolic.SymbolicExpression
 
-	switch a := addr.(type) {
-	case *symbolic.SymbolicPointer:
-		result = interpreter.Heap.GetFieldValue(a, 0, ssaTypeToSymbolicType(l.Type()))
-		if result == nil {
+	if value, ok := interpreter.loadGlobal(l.X); ok {
+		result = value
+	} else {
+		switch a := addr.(type) {
+		case *symbolic.SymbolicPointer:
+			result = interpreter.Heap.GetFieldValue(a, 0, ssaTypeToSymbolicType(l.Type()))
+			if result == nil {
+				typeStr := l.Type().String()
+				if strings.Contains(typeStr, "bool") {
+					result = symbolic.NewBoolConstant(false)
+				} else {
+					result = symbolic.NewSymbolicVariable(fmt.Sprintf("*ref_%d", a.Address), symbolic.IntType)
+				}
+			}
+		case *symbolic.FieldAddr:
+			result = interpreter.Heap.GetFieldValue(a.Ptr, a.FieldIndex, ssaTypeToSymbolicType(l.Type()))
+			if result == nil {
+				result = symbolic.NewBoolConstant(false)
+			}
+		case *symbolic.IndexAddr:
+			result = interpreter.Heap.GetFromArray(a.Ptr, a.Index, ssaTypeToSymbolicType(l.Type()))
+			if result == nil {
+				result = symbolic.NewBoolConstant(false)
+			}
+		default:
 			typeStr := l.Type().String()
 			if strings.Contains(typeStr, "bool") {
 				result = symbolic.NewBoolConstant(false)
 			} else {
-				result = symbolic.NewSymbolicVariable(fmt.Sprintf("*ref_%d", a.Address), symbolic.IntType)
+				result = symbolic.NewIntConstant(0)
 			}
 		}
-	case *symbolic.FieldAddr:
-		result = interpreter.Heap.GetFieldValue(a.Ptr, a.FieldIndex, ssaTypeToSymbolicType(l.Type()))
-		if result == nil {
-			result = symbolic.NewBoolConstant(false)
-		}
-	case *symbolic.IndexAddr:
-		result = interpreter.Heap.GetFromArray(a.Ptr, a.Index, ssaTypeToSymbolicType(l.Type()))
-		if result == nil {
-			result = symbolic.NewBoolConstant(false)
-		}
-	default:
-		typeStr := l.Type().String()
-		if strings.Contains(typeStr, "bool") {
-			result = symbolic.NewBoolConstant(false)
-		} else {
-			result = symbolic.NewIntConstant(0)
-		}
 	}
 
 	if result == nil {
@@ -1998,6 +2064,9 @@ func (interpreter *Interpreter) String() string {
 	return result
 }
 
+// Copy возвращает независимую копию состояния. Указатели в локальной памяти
+// копируются, так как запись в поле меняет указатель на месте; указатели,
+// совпадающие в исходном состоянии, совпадают и в копии.
 func (interpreter *Interpreter) Copy() *Interpreter {
 	newInterpreter := &Interpreter{
 		CallStack:        make([]CallStackFrame, len(interpreter.CallStack)),
@@ -2015,6 +2084,15 @@ func (interpreter *Interpreter) Copy() *Interpreter {
 		CurrentCallDepth: interpreter.CurrentCallDepth,
 		VisitedFunctions: make(map[string]bool),
 		ExecutionSteps:   interpreter.ExecutionSteps,
+		SkippedCalls:     interpreter.SkippedCalls,
+		Node:             interpreter.Node,
+		Terminal:         interpreter.Terminal,
+		TerminalInstr:    interpreter.TerminalInstr,
+		Limit:            interpreter.Limit,
+		Reason:           interpreter.Reason,
+		freshVars:        interpreter.freshVars,
+		trail:            interpreter.trail,
+		worker:           interpreter.worker,
 	}
 
 	for k, v := range interpreter.VisitedFunctions {
@@ -2033,19 +2111,63 @@ func (interpreter *Interpreter) Copy() *Interpreter {
 		newInterpreter.BlockVisitCount[k] = v
 	}
 
+	pointers := make(map[*symbolic.SymbolicPointer]*symbolic.SymbolicPointer)
+	var copyValue func(value symbolic.SymbolicExpression) symbolic.SymbolicExpression
+	copyValue = func(value symbolic.SymbolicExpression) symbolic.SymbolicExpression {
+		if tuple, ok := value.(*symbolic.Tuple); ok {
+			elements := make([]symbolic.SymbolicExpression, len(tuple.Elements))
+			for i, element := range tuple.Elements {
+				elements[i] = copyValue(element)
+			}
+			return symbolic.NewTuple(elements)
+		}
+		ptr, ok := value.(*symbolic.SymbolicPointer)
+		if !ok || ptr == nil {
+			return value
+		}
+		if copied, ok := pointers[ptr]; ok {
+			return copied
+		}
+		copied := *ptr
+		pointers[ptr] = &copied
+		return &copied
+	}
+
 	for i, frame := range interpreter.CallStack {
 		newFrame := CallStackFrame{
-			Function:    frame.Function,
-			LocalMemory: make(map[string]symbolic.SymbolicExpression),
-			ReturnValue: frame.ReturnValue,
+			Function:      frame.Function,
+			LocalMemory:   make(map[string]symbolic.SymbolicExpression),
+			ReturnValue:   copyValue(frame.ReturnValue),
+			CurrentBlock:  frame.CurrentBlock,
+			ReturnToIndex: frame.ReturnToIndex,
+			ReturnVarName: frame.ReturnVarName,
 		}
 
 		for k, v := range frame.LocalMemory {
-			newFrame.LocalMemory[k] = v
+			newFrame.LocalMemory[k] = copyValue(v)
 		}
 
 		newInterpreter.CallStack[i] = newFrame
 	}
 
+	if interpreter.Globals != nil {
+		newInterpreter.Globals = make(map[*ssa.Global]*symbolic.SymbolicPointer, len(interpreter.Globals))
+		for global, cell := range interpreter.Globals {
+			newInterpreter.Globals[global] = copyValue(cell).(*symbolic.SymbolicPointer)
+		}
+	}
+
 	return newInterpreter
 }
+
+// freshName возвращает имя для безымянного значения. Имя строится из узла
+// дерева исполнения и счётчика пути, поэтому не зависит от порядка, в котором
+// исполнители обрабатывают состояния.
+func (interpreter *Interpreter) freshName() string {
+	name := "var" + strconv.Itoa(interpreter.freshVars)
+	if interpreter.Node != nil {
+		name = fmt.Sprintf("var%d_%d", interpreter.Node.ID, interpreter.freshVars)
+	}
+	interpreter.freshVars++
+	return name
+}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"symbolic-execution-course/internal/symbolic"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// ExecutionNode - узел дерева исполнения. Новый узел появляется, когда
+// инструкция порождает несколько состояний; пока состояние не ветвится,
+// оно остаётся в своём узле. Листья с живыми состояниями - это очередь анализатора.
+type ExecutionNode struct {
+	ID       int
+	Parent   *ExecutionNode
+	Children []*ExecutionNode
+
+	// Block - блок, в котором состояние находилось при создании узла
+	Block *ssa.BasicBlock
+	// MergedWith - узел второго состояния, если узел получен слиянием
+	MergedWith *ExecutionNode
+
+	// Fork - инструкция, на которой состояние узла разветвилось
+	Fork ssa.Instruction
+	// Branch - номер ветки среди детей родителя
+	Branch int
+	// Condition - ограничения, которыми ветка отличается от соседних;
+	// nil, если ветвление не добавило условий, как у приведения типа
+	Condition symbolic.SymbolicExpression
+}
+
+// Root возвращает корень дерева, которому принадлежит узел
+func (node *ExecutionNode) Root() *ExecutionNode {
+	for node != nil && node.Parent != nil {
+		node = node.Parent
+	}
+	return node
+}
+
+// IsMerged сообщает, получено ли состояние узла слиянием где-то на пути от корня.
+// Такое состояние нельзя восстановить переисполнением одной ветки.
+func (node *ExecutionNode) IsMerged() bool {
+	for ; node != nil; node = node.Parent {
+		if node.MergedWith != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// newNode создаёт узел с очередным идентификатором
+func (analyser *Analyser) newNode(parent *ExecutionNode, block *ssa.BasicBlock) *ExecutionNode {
+	node := &ExecutionNode{ID: analyser.nodesCounter, Parent: parent, Block: block}
+	analyser.nodesCounter++
+	if parent != nil {
+		parent.Children = append(parent.Children, node)
+	}
+	return node
+}
+
+// attach привязывает состояния, полученные из parent, к дереву исполнения:
+// единственное состояние остаётся в том же узле, при ветвлении каждое
+// получает свой дочерний узел
+func (analyser *Analyser) attach(parent *ExecutionNode, states []*Interpreter) {
+	if len(states) == 1 {
+		states[0].Node = parent
+		return
+	}
+	if len(states) == 0 {
+		return
+	}
+	prefix := states[0].PathCondition
+	for _, state := range states[1:] {
+		prefix = commonPrefix(prefix, state.PathCondition)
+	}
+	for i, state := range states {
+		state.Node = analyser.newNode(parent, state.CurrentBlock)
+		state.Node.Branch = i
+		if constraints := state.PathCondition.suffix(prefix); len(constraints) > 0 {
+			state.Node.Condition = conjunction(constraints)
+		}
+	}
+}
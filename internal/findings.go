@@ -0,0 +1,231 @@
+package internal
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// FindingKind - вид найденной проблемы
+type FindingKind int
+
+const (
+	// FindingPanic - явный вызов panic
+	FindingPanic FindingKind = iota
+	// FindingRuntimeError - паника среды исполнения, например неудачное приведение типа
+	FindingRuntimeError
+	// FindingAssertion - паника внутри функции проверки: assert, assertTrue и т.п.
+	FindingAssertion
+	// FindingUnreachable - ветка условия, не выполнимая ни на одном пути
+	FindingUnreachable
+)
+
+// FindingKinds - все виды проблем в порядке объявления
+var FindingKinds = []FindingKind{FindingPanic, FindingRuntimeError, FindingAssertion, FindingUnreachable}
+
+func (k FindingKind) String() string {
+	switch k {
+	case FindingRuntimeError:
+		return "runtime-error"
+	case FindingAssertion:
+		return "assertion"
+	case FindingUnreachable:
+		return "unreachable"
+	}
+	return "panic"
+}
+
+func (k FindingKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *FindingKind) UnmarshalText(text []byte) error {
+	for _, kind := range FindingKinds {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown finding kind %q", text)
+}
+
+// Description - краткое описание вида проблемы
+func (k FindingKind) Description() string {
+	switch k {
+	case FindingRuntimeError:
+		return "Runtime error reachable from the analysed function"
+	case FindingAssertion:
+		return "Assertion can be violated"
+	case FindingUnreachable:
+		return "Branch is never taken"
+	}
+	return "Panic reachable from the analysed function"
+}
+
+// Location - позиция в исходном коде; пустая, если позиция неизвестна
+type Location struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// FlowStep - шаг пути к проблеме: принятое решение ветвления
+type FlowStep struct {
+	Location Location `json:"location"`
+	Message  string   `json:"message"`
+}
+
+// Finding - проблема, найденная анализом функции
+type Finding struct {
+	Kind     FindingKind `json:"kind"`
+	Message  string      `json:"message"`
+	Function string      `json:"function"`
+	Location Location    `json:"location"`
+	// Flow - решения ветвлений на пути от входа в функцию к проблеме
+	Flow []FlowStep `json:"flow,omitempty"`
+	// Inputs - значения входов, на которых проблема воспроизводится
+	Inputs        map[string]interface{} `json:"inputs,omitempty"`
+	PathCondition string                 `json:"pathCondition,omitempty"`
+}
+
+// findings собирает проблемы по завершённым путям анализа
+func (analyser *Analyser) findings(fn *ssa.Function) []Finding {
+	fset := fn.Prog.Fset
+	var res []Finding
+
+	truncated := analyser.Truncated
+	covered := make(map[*ssa.BasicBlock]bool)
+	reported := make(map[ssa.Instruction]bool)
+	for _, result := range analyser.Results {
+		for _, block := range result.CoveredBlocks() {
+			covered[block] = true
+		}
+		if result.Terminal == TerminalTruncated || result.Terminal == TerminalUnsupported {
+			truncated = true
+		}
+
+		// Одна и та же паника на разных путях сообщается один раз, по первому пути
+		if result.Terminal != TerminalPanic || result.TerminalInstr == nil || reported[result.TerminalInstr] {
+			continue
+		}
+		reported[result.TerminalInstr] = true
+
+		finding := panicFinding(result.TerminalInstr)
+		finding.Function = result.TerminalInstr.Parent().String()
+		finding.Location = location(fset, instrPos(result.TerminalInstr))
+		finding.Flow = result.branchFlow(fset)
+		finding.PathCondition = result.PathCondition.String()
+		if inputs := analyser.inputsFor(result.PathCondition); inputs != nil {
+			finding.Inputs = modelValues(inputs)
+		}
+		res = append(res, finding)
+	}
+
+	// Недостижимость можно утверждать, только если все пути исследованы до конца
+	if truncated {
+		return res
+	}
+	for _, block := range sortedBlocks(covered) {
+		ifInstr, ok := block.Instrs[len(block.Instrs)-1].(*ssa.If)
+		if !ok {
+			continue
+		}
+		for i, succ := range block.Succs {
+			if covered[succ] {
+				continue
+			}
+			res = append(res, Finding{
+				Kind:     FindingUnreachable,
+				Message:  fmt.Sprintf("condition %s is never %t", ifInstr.Cond.String(), i == 0),
+				Function: block.Parent().String(),
+				Location: location(fset, instrPos(ifInstr)),
+			})
+		}
+	}
+	return res
+}
+
+// panicFinding определяет вид паники по вызвавшей её инструкции
+func panicFinding(instr ssa.Instruction) Finding {
+	if assert, ok := instr.(*ssa.TypeAssert); ok {
+		return Finding{
+			Kind:    FindingRuntimeError,
+			Message: fmt.Sprintf("interface conversion: %s is not %s", assert.X.Type(), assert.AssertedType),
+		}
+	}
+
+	message := "panic"
+	if p, ok := instr.(*ssa.Panic); ok {
+		message = "panic: " + panicValue(p.X)
+	}
+	if name := strings.ToLower(instr.Parent().Name()); strings.HasPrefix(name, "assert") {
+		return Finding{Kind: FindingAssertion, Message: fmt.Sprintf("assertion %s failed: %s", instr.Parent().Name(), message)}
+	}
+	return Finding{Kind: FindingPanic, Message: message}
+}
+
+// panicValue - аргумент panic в читаемом виде: для констант - их значение
+func panicValue(value ssa.Value) string {
+	if iface, ok := value.(*ssa.MakeInterface); ok {
+		value = iface.X
+	}
+	if c, ok := value.(*ssa.Const); ok && c.Value != nil {
+		if c.Value.Kind() == constant.String {
+			return constant.StringVal(c.Value)
+		}
+		return c.Value.String()
+	}
+	return value.Name()
+}
+
+// branchFlow восстанавливает решения ветвлений по пройденным блокам
+func (interpreter *Interpreter) branchFlow(fset *token.FileSet) []FlowStep {
+	var blocks []*ssa.BasicBlock
+	for trail := interpreter.trail; trail != nil; trail = trail.parent {
+		blocks = append(blocks, trail.block)
+	}
+
+	var flow []FlowStep
+	for i := len(blocks) - 1; i > 0; i-- {
+		from, to := blocks[i], blocks[i-1]
+		ifInstr, ok := from.Instrs[len(from.Instrs)-1].(*ssa.If)
+		if !ok || len(from.Succs) != 2 || (to != from.Succs[0] && to != from.Succs[1]) {
+			continue
+		}
+		flow = append(flow, FlowStep{
+			Location: location(fset, instrPos(ifInstr)),
+			Message:  fmt.Sprintf("%s is %t", ifInstr.Cond.String(), to == from.Succs[0]),
+		})
+	}
+	return flow
+}
+
+// instrPos - позиция инструкции; у If и некоторых других инструкций
+// своей позиции нет, тогда берётся позиция операнда или ближайшей
+// предыдущей инструкции блока
+func instrPos(instr ssa.Instruction) token.Pos {
+	if pos := instr.Pos(); pos.IsValid() {
+		return pos
+	}
+	if ifInstr, ok := instr.(*ssa.If); ok && ifInstr.Cond.Pos().IsValid() {
+		return ifInstr.Cond.Pos()
+	}
+	block := instr.Block()
+	for i := len(block.Instrs) - 1; i >= 0; i-- {
+		if pos := block.Instrs[i].Pos(); pos.IsValid() {
+			return pos
+		}
+	}
+	return instr.Parent().Pos()
+}
+
+func location(fset *token.FileSet, pos token.Pos) Location {
+	if !pos.IsValid() {
+		return Location{}
+	}
+	position := fset.Position(pos)
+	return Location{File: position.Filename, Line: position.Line, Column: position.Column}
+}
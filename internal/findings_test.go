@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"context"
+	"testing"
+)
+
+const findingsSource = `package main
+
+func Negative(x int) int {
+	if x < 0 {
+		panic("negative") // panic
+	}
+	return x
+}
+
+func assert(cond bool) {
+	if !cond {
+		panic("assertion failed") // assert
+	}
+}
+
+func Checked(x int) int {
+	assert(x != 5)
+	return x
+}
+
+func Convert(v interface{}) int {
+	return v.(int) // convert
+}
+
+func Dead(x int) int {
+	if x > 5 {
+		if x < 3 { // dead
+			return 1
+		}
+	}
+	return 0
+}
+
+func Countdown(n int) int {
+	if n > 100 {
+		return Countdown(n - 1)
+	}
+	if n > 200 { // skipped
+		return 1
+	}
+	return 0
+}
+`
+
+func TestFindings(t *testing.T) {
+	tests := []struct {
+		function string
+		kind     FindingKind
+		// marker - строка, на которую указывает находка; пусто - находок нет
+		marker string
+	}{
+		{"Negative", FindingPanic, "panic"},
+		{"Checked", FindingAssertion, "assert"},
+		{"Convert", FindingRuntimeError, "convert"},
+		{"Dead", FindingUnreachable, "dead"},
+		// Пропущенный рекурсивный вызов не даёт утверждать недостижимость
+		{"Countdown", FindingUnreachable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			report, err := AnalyseSources(context.Background(), map[string]string{"main.go": findingsSource}, tt.function, Options{})
+			if err != nil {
+				t.Fatalf("analyse: %v", err)
+			}
+			if tt.marker == "" {
+				if len(report.Findings) != 0 {
+					t.Errorf("findings = %+v, want none", report.Findings)
+				}
+				return
+			}
+			if len(report.Findings) != 1 {
+				t.Fatalf("findings = %+v, want one", report.Findings)
+			}
+			finding := report.Findings[0]
+			if finding.Kind != tt.kind || finding.Location.Line != lineOf(t, findingsSource, tt.marker) {
+				t.Errorf("finding %s at line %d, want %s at the line marked %s", finding.Kind, finding.Location.Line, tt.kind, tt.marker)
+			}
+			if tt.kind != FindingUnreachable && finding.Inputs == nil {
+				t.Errorf("finding %+v has no inputs", finding)
+			}
+		})
+	}
+}
+
+func TestFindingKindText(t *testing.T) {
+	for _, kind := range FindingKinds {
+		text, _ := kind.MarshalText()
+		var decoded FindingKind
+		if err := decoded.UnmarshalText(text); err != nil || decoded != kind {
+			t.Errorf("%s decoded as %s, %v", kind, decoded, err)
+		}
+	}
+	var kind FindingKind
+	if err := kind.UnmarshalText([]byte("crash")); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+}
@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"fmt"
+	"go/types"
+
+	"symbolic-execution-course/internal/symbolic"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// GlobalsMode - как моделируются глобальные переменные пакетов.
+// Глобальные переменные общие для всех кадров состояния и создаются
+// в куче состояния при первом обращении.
+type GlobalsMode int
+
+const (
+	// GlobalsZero - переменные начинаются с нулевых значений, init не исполняется
+	GlobalsZero GlobalsMode = iota
+	// GlobalsInit - переменные начинаются с нулевых значений, и перед точкой
+	// входа исполняется init её пакета. Инициализаторы импортированных
+	// пакетов не исполняются, их переменные остаются нулевыми.
+	GlobalsInit
+	// GlobalsSymbolic - каждая переменная - неограниченный символьный вход
+	GlobalsSymbolic
+)
+
+func (m GlobalsMode) String() string {
+	switch m {
+	case GlobalsInit:
+		return "init"
+	case GlobalsSymbolic:
+		return "symbolic"
+	}
+	return "zero"
+}
+
+// ParseGlobalsMode разбирает режим по имени: zero, init или symbolic
+func ParseGlobalsMode(name string) (GlobalsMode, error) {
+	for _, mode := range []GlobalsMode{GlobalsZero, GlobalsInit, GlobalsSymbolic} {
+		if mode.String() == name {
+			return mode, nil
+		}
+	}
+	return GlobalsZero, fmt.Errorf("unknown globals mode %q: expected zero, init or symbolic", name)
+}
+
+// globalCell возвращает ячейку глобальной переменной в куче состояния,
+// создавая её при первом обращении. Ячейка у переменной одна, поэтому запись
+// по взятому адресу видна при прямом чтении и наоборот. Значение переменной
+// простого типа хранится в поле 0 ячейки, составная переменная - сам объект.
+func (interpreter *Interpreter) globalCell(global *ssa.Global) *symbolic.SymbolicPointer {
+	if cell, ok := interpreter.Globals[global]; ok {
+		return cell
+	}
+
+	mode := GlobalsZero
+	if interpreter.Analyser != nil {
+		mode = interpreter.Analyser.Globals
+	}
+	name := globalName(global, interpreter.CallStack[0].Function.Pkg)
+
+	var cell *symbolic.SymbolicPointer
+	if basic, ok := basicGlobal(global); ok {
+		value := zeroValue(basic)
+		if mode == GlobalsSymbolic {
+			value = symbolic.NewSymbolicVariable(name, basicSymbolicType(basic))
+		}
+		cell = interpreter.Heap.AllocateFullStruct("&"+name, []symbolic.SymbolicExpression{value})
+	} else {
+		cell = interpreter.Heap.Allocate(symbolic.ObjType, name, symbolic.NewSymbolicVariable(name, symbolic.ObjType))
+	}
+
+	if interpreter.Globals == nil {
+		interpreter.Globals = make(map[*ssa.Global]*symbolic.SymbolicPointer)
+	}
+	interpreter.Globals[global] = cell
+	return cell
+}
+
+// cellValue - текущее значение переменной простого типа: последняя запись
+// в поле 0 её ячейки
+func cellValue(cell *symbolic.SymbolicPointer) symbolic.SymbolicExpression {
+	if assign, ok := cell.Expr.(*symbolic.FieldAssign); ok && assign.FieldIdx == 0 {
+		return assign.Value
+	}
+	return symbolic.NewFieldAccess(cell.Expr, 0, cell.Expr, cell.Name, symbolic.IntType)
+}
+
+// resolveGlobal - глобальная переменная как адрес: всегда одна и та же ячейка
+func (interpreter *Interpreter) resolveGlobal(global *ssa.Global) symbolic.SymbolicExpression {
+	return interpreter.globalCell(global)
+}
+
+// loadGlobal читает глобальную переменную простого типа по адресу addr
+func (interpreter *Interpreter) loadGlobal(addr ssa.Value) (symbolic.SymbolicExpression, bool) {
+	global, ok := addr.(*ssa.Global)
+	if !ok {
+		return nil, false
+	}
+	if _, ok := basicGlobal(global); !ok {
+		return nil, false
+	}
+	return cellValue(interpreter.globalCell(global)), true
+}
+
+// storeGlobal записывает глобальную переменную простого типа по адресу addr
+func (interpreter *Interpreter) storeGlobal(addr ssa.Value, value symbolic.SymbolicExpression) bool {
+	global, ok := addr.(*ssa.Global)
+	if !ok {
+		return false
+	}
+	if _, ok := basicGlobal(global); !ok {
+		return false
+	}
+	interpreter.Heap.AssignField(interpreter.globalCell(global), 0, value)
+	return true
+}
+
+func basicGlobal(global *ssa.Global) (*types.Basic, bool) {
+	basic, ok := global.Type().(*types.Pointer).Elem().Underlying().(*types.Basic)
+	return basic, ok
+}
+
+// globalName - имя переменной для условий пути: переменные пакетов,
+// отличных от пакета точки входа, квалифицируются именем пакета
+func globalName(global *ssa.Global, entry *ssa.Package) string {
+	if global.Pkg == nil || global.Pkg == entry {
+		return global.Name()
+	}
+	return global.Pkg.Pkg.Name() + "." + global.Name()
+}
+
+func basicSymbolicType(basic *types.Basic) symbolic.ExpressionType {
+	switch {
+	case basic.Info()&types.IsBoolean != 0:
+		return symbolic.BoolType
+	case basic.Info()&types.IsFloat != 0:
+		return symbolic.FloatType
+	}
+	return symbolic.IntType
+}
+
+func zeroValue(basic *types.Basic) symbolic.SymbolicExpression {
+	switch basicSymbolicType(basic) {
+	case symbolic.BoolType:
+		return symbolic.NewBoolConstant(false)
+	case symbolic.FloatType:
+		return symbolic.NewFloatConstant(0)
+	}
+	return symbolic.NewIntConstant(0)
+}
+
+// pushInit кладёт на стек вызов init пакета функции fn: после его
+// возврата исполнение продолжится с начала точки входа
+func (interpreter *Interpreter) pushInit(fn *ssa.Function) {
+	if fn.Pkg == nil {
+		return
+	}
+	init := fn.Pkg.Func("init")
+	if init == nil || len(init.Blocks) == 0 {
+		return
+	}
+
+	interpreter.CallStack = append(interpreter.CallStack, CallStackFrame{
+		Function:      init,
+		LocalMemory:   make(map[string]symbolic.SymbolicExpression),
+		CurrentBlock:  interpreter.CurrentBlock,
+		ReturnToIndex: interpreter.InstrIndex,
+	})
+	interpreter.CurrentCallDepth++
+	interpreter.CurrentBlock = init.Blocks[0]
+	interpreter.InstrIndex = 0
+}
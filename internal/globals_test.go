@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const globalsSource = `package main
+
+var counter int
+
+func setPtr(p *int) { *p = 7 }
+
+func ThroughPointer() bool {
+	setPtr(&counter)
+	return counter == 7
+}
+
+func ReadThroughPointer() int {
+	counter = 5
+	p := &counter
+	if *p == 5 {
+		return 1
+	}
+	return 0
+}
+
+func Conditional() int {
+	if counter > 3 {
+		setPtr(&counter)
+	}
+	if counter == 7 {
+		return 1
+	}
+	return 0
+}
+`
+
+func TestGlobalAliasing(t *testing.T) {
+	tests := []struct {
+		function string
+		mode     GlobalsMode
+		// returns - результаты путей, по одному на путь
+		returns []string
+	}{
+		{"ThroughPointer", GlobalsZero, []string{"true"}},
+		{"ThroughPointer", GlobalsSymbolic, []string{"true"}},
+		{"ReadThroughPointer", GlobalsZero, []string{"1"}},
+		{"Conditional", GlobalsZero, []string{"0"}},
+		{"Conditional", GlobalsSymbolic, []string{"0", "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.function+"/"+tt.mode.String(), func(t *testing.T) {
+			fn, err := BuildFunction(map[string]string{"main.go": globalsSource}, tt.function)
+			if err != nil {
+				t.Fatalf("build: %v", err)
+			}
+			results, err := ExploreFunction(context.Background(), fn, Options{Globals: tt.mode})
+			if err != nil {
+				t.Fatalf("explore: %v", err)
+			}
+
+			var returns []string
+			for _, result := range results {
+				if frame := result.GetCurrentFrame(); frame != nil && frame.ReturnValue != nil {
+					returns = append(returns, frame.ReturnValue.String())
+				}
+			}
+			sort.Strings(returns)
+			if strings.Join(returns, ",") != strings.Join(tt.returns, ",") {
+				t.Errorf("returns = %v, want %v", returns, tt.returns)
+			}
+		})
+	}
+}
+
+func TestGlobalCellIsShared(t *testing.T) {
+	fn, err := BuildFunction(map[string]string{"main.go": globalsSource}, "ThroughPointer")
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	analyser, err := newAnalyser(context.Background(), fn, Options{})
+	if err != nil {
+		t.Fatalf("newAnalyser: %v", err)
+	}
+	defer analyser.close()
+
+	state := analyser.initialStates[0].Copy()
+	global := fn.Pkg.Var("counter")
+	objects := state.Heap.ObjectId
+	first := state.resolveGlobal(global)
+	if second := state.resolveGlobal(global); first != second {
+		t.Error("expected every resolution to return the same cell")
+	}
+	if state.Heap.ObjectId != objects+1 {
+		t.Errorf("allocated %d objects, want 1", state.Heap.ObjectId-objects)
+	}
+
+	// Копия состояния получает свою ячейку по тому же адресу
+	copied := state.Copy()
+	if cell := copied.resolveGlobal(global); cell == first || cell.String() != first.String() {
+		t.Errorf("copied cell = %p %s, want a distinct cell at %s", cell, cell, first)
+	}
+}
@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// Logger - журнал анализа: шаги состояний пишутся на уровне Debug, итоги
+// анализа функции - на уровне Info, ошибки построения - на уровне Error.
+// По умолчанию всё отбрасывается, чтобы библиотеку можно было встраивать.
+var Logger = slog.New(slog.DiscardHandler)
+
+// maxLoggedCondition - длина, до которой в журнале обрезается условие пути
+const maxLoggedCondition = 200
+
+// logStep пишет в журнал шаг состояния: инструкцию, которую оно исполнит,
+// или nil, если путь завершён
+func logStep(step int, interpreter *Interpreter, instr ssa.Instruction) {
+	ctx := context.Background()
+	if !Logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	attrs := []slog.Attr{slog.Int("step", step)}
+	if interpreter.Node != nil {
+		attrs = append(attrs, slog.Int("state", interpreter.Node.ID))
+	}
+	if block := interpreter.CurrentBlock; block != nil {
+		attrs = append(attrs, slog.String("function", block.Parent().String()), slog.Int("block", block.Index))
+	}
+	attrs = append(attrs, slog.Int("depth", interpreter.CurrentCallDepth))
+
+	condition := interpreter.PathCondition.String()
+	if len(condition) > maxLoggedCondition {
+		condition = condition[:maxLoggedCondition] + "..."
+	}
+	attrs = append(attrs, slog.String("pathCondition", condition))
+
+	if instr == nil {
+		Logger.LogAttrs(ctx, slog.LevelDebug, "path finished", attrs...)
+		return
+	}
+	attrs = append(attrs, slog.String("instr", instr.String()), slog.String("kind", fmt.Sprintf("%T", instr)))
+	Logger.LogAttrs(ctx, slog.LevelDebug, "step", attrs...)
+}
+
+// logSummary пишет в журнал итоги анализа функции
+func (analyser *Analyser) logSummary(fn *ssa.Function) {
+	stats := analyser.SolverCache.Stats
+	attrs := []any{
+		"function", fn.String(),
+		"paths", len(analyser.Results),
+		"steps", analyser.stepsCounter,
+		"truncated", analyser.Truncated,
+		"solverHits", stats.TotalHits(),
+		"solverExactHits", stats.Hits,
+		"solverModelReuses", stats.ModelReuses,
+		"solverCoreHits", stats.CoreHits,
+		"solverMisses", stats.Misses,
+	}
+	if scheduler := analyser.Scheduler; len(scheduler.Evictions) > 0 {
+		attrs = append(attrs, "evicted", len(scheduler.Evictions), "evictionPolicy", scheduler.Policy.String(),
+			"lost", scheduler.Lost(), "suspended", scheduler.Suspended())
+	}
+	if len(analyser.Cuts) > 0 {
+		attrs = append(attrs, "cuts", analyser.Cuts)
+	}
+	if merger := analyser.Merger; merger != nil {
+		attrs = append(attrs, "merges", merger.Merges, "mergesRejected", merger.Rejected)
+	}
+	Logger.Info("analysis finished", attrs...)
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestLoggerIsSilentByDefault(t *testing.T) {
+	if Logger.Handler() != slog.DiscardHandler {
+		t.Errorf("default handler = %T, want slog.DiscardHandler", Logger.Handler())
+	}
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelError} {
+		if Logger.Enabled(context.Background(), level) {
+			t.Errorf("default logger enabled at %s", level)
+		}
+	}
+}
+
+func TestLoggerLevels(t *testing.T) {
+	defer func(logger *slog.Logger) { Logger = logger }(Logger)
+
+	tests := []struct {
+		level slog.Level
+		steps bool
+	}{
+		{slog.LevelDebug, true},
+		{slog.LevelInfo, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			Logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: tt.level}))
+			if _, err := AnalyseSources(context.Background(), map[string]string{"main.go": reportSource}, "Check", Options{}); err != nil {
+				t.Fatalf("analyse: %v", err)
+			}
+
+			messages := make(map[string]int)
+			var summary map[string]interface{}
+			decoder := json.NewDecoder(&buf)
+			for decoder.More() {
+				var record map[string]interface{}
+				if err := decoder.Decode(&record); err != nil {
+					t.Fatalf("decode record: %v", err)
+				}
+				msg, _ := record["msg"].(string)
+				messages[msg]++
+				if msg == "analysis finished" {
+					summary = record
+				}
+			}
+
+			if steps := messages["step"] > 0 && messages["path finished"] == 2; steps != tt.steps {
+				t.Errorf("records %v, want steps %v", messages, tt.steps)
+			}
+			if summary == nil || summary["paths"] != float64(2) || summary["function"] != "homework1/main.go.Check" {
+				t.Errorf("summary = %v, want 2 paths of Check", summary)
+			}
+		})
+	}
+}
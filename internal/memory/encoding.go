@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"fmt"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+// EncodedMemory - память, записанная для сохранения: выражения заменены
+// номерами узлов в таблице symbolic.Encoder
+type EncodedMemory struct {
+	Primitives []EncodedPrimitive `json:"primitives,omitempty"`
+
+	Objects  map[Id]map[Id]int `json:"objects,omitempty"`
+	ObjectId Id                `json:"objectId"`
+
+	Arrays  map[Id]map[Id]int `json:"arrays,omitempty"`
+	ArrayId Id                `json:"arrayId"`
+
+	Aliases   map[Id]Id `json:"aliases,omitempty"`
+	AliasesId Id        `json:"aliasesId"`
+
+	ArrLength map[Id]uint `json:"arrLength,omitempty"`
+}
+
+// EncodedPrimitive - значение по указателю; ключ карты Primitives -
+// значение указателя, поэтому он записывается отдельным узлом
+type EncodedPrimitive struct {
+	Pointer int `json:"pointer"`
+	Value   int `json:"value"`
+}
+
+// Encode записывает память, добавляя её выражения в таблицу enc
+func (mem *SymbolicMemory) Encode(enc *symbolic.Encoder) *EncodedMemory {
+	encoded := &EncodedMemory{
+		Objects:   encodeCells(enc, mem.Objects),
+		ObjectId:  mem.ObjectId,
+		Arrays:    encodeCells(enc, mem.Arrays),
+		ArrayId:   mem.ArrayId,
+		Aliases:   mem.Aliases,
+		AliasesId: mem.AliasesId,
+		ArrLength: mem.ArrLength,
+	}
+	for ptr, value := range mem.Primitives {
+		key := ptr
+		encoded.Primitives = append(encoded.Primitives, EncodedPrimitive{Pointer: enc.Encode(&key), Value: enc.Encode(value)})
+	}
+	return encoded
+}
+
+func encodeCells(enc *symbolic.Encoder, cells map[Id]map[Id]symbolic.SymbolicExpression) map[Id]map[Id]int {
+	res := make(map[Id]map[Id]int, len(cells))
+	for id, fields := range cells {
+		res[id] = make(map[Id]int, len(fields))
+		for field, value := range fields {
+			res[id][field] = enc.Encode(value)
+		}
+	}
+	return res
+}
+
+// Decode восстанавливает память по таблице выражений
+func (encoded *EncodedMemory) Decode(dec *symbolic.Decoder) (*SymbolicMemory, error) {
+	mem := NewSymbolicMemory()
+	mem.ObjectId, mem.ArrayId, mem.AliasesId = encoded.ObjectId, encoded.ArrayId, encoded.AliasesId
+
+	for _, primitive := range encoded.Primitives {
+		ptr, err := dec.DecodePointer(primitive.Pointer)
+		if err != nil {
+			return nil, err
+		}
+		if ptr == nil {
+			return nil, fmt.Errorf("decode memory: nil primitive pointer")
+		}
+		value, err := dec.Decode(primitive.Value)
+		if err != nil {
+			return nil, err
+		}
+		mem.Primitives[*ptr] = value
+	}
+
+	var err error
+	if mem.Objects, err = decodeCells(dec, encoded.Objects); err != nil {
+		return nil, err
+	}
+	if mem.Arrays, err = decodeCells(dec, encoded.Arrays); err != nil {
+		return nil, err
+	}
+	for id, addr := range encoded.Aliases {
+		mem.Aliases[id] = addr
+	}
+	for id, length := range encoded.ArrLength {
+		mem.ArrLength[id] = length
+	}
+	return mem, nil
+}
+
+func decodeCells(dec *symbolic.Decoder, cells map[Id]map[Id]int) (map[Id]map[Id]symbolic.SymbolicExpression, error) {
+	res := make(map[Id]map[Id]symbolic.SymbolicExpression, len(cells))
+	for id, fields := range cells {
+		res[id] = make(map[Id]symbolic.SymbolicExpression, len(fields))
+		for field, value := range fields {
+			expr, err := dec.Decode(value)
+			if err != nil {
+				return nil, err
+			}
+			res[id][field] = expr
+		}
+	}
+	return res, nil
+}
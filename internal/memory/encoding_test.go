@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+func TestEncodingRoundTrip(t *testing.T) {
+	mem := NewSymbolicMemory()
+	x := symbolic.NewSymbolicVariable("x", symbolic.IntType)
+	point := mem.AllocateFullStruct("Point", []symbolic.SymbolicExpression{x, symbolic.NewIntConstant(2)})
+	array := mem.AllocateArray("a", symbolic.IntType, 3)
+	mem.AssignToArray(array, 1, x)
+	mem.CreateAlias(array, 5)
+	mem.AssignPrimitive(mem.Allocate(symbolic.IntType, "", x), symbolic.NewIntConstant(1))
+	mem.AssignPrimitive(mem.Allocate(symbolic.AddrType, "", nil), point)
+
+	enc := symbolic.NewEncoder()
+	data, err := json.Marshal(mem.Encode(enc))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var encoded EncodedMemory
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	dec, err := symbolic.NewDecoder(enc.Nodes)
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	decoded, err := encoded.Decode(dec)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	// Ключи Primitives сравниваются по значению выражений, а не по их адресам
+	if len(decoded.Primitives) != len(mem.Primitives) {
+		t.Fatalf("decoded %d primitives, want %d", len(decoded.Primitives), len(mem.Primitives))
+	}
+	for ptr, value := range mem.Primitives {
+		found := false
+		for decodedPtr, decodedValue := range decoded.Primitives {
+			if reflect.DeepEqual(decodedPtr, ptr) && reflect.DeepEqual(decodedValue, value) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("primitive %v = %v not decoded", ptr, value)
+		}
+	}
+
+	decoded.Primitives, mem.Primitives = nil, nil
+	if !reflect.DeepEqual(decoded, mem) {
+		t.Errorf("decoded memory\n%#v\nwant\n%#v", decoded, mem)
+	}
+	if decoded.GetArrayLength(&symbolic.SymbolicPointer{Address: 5}) != 3 {
+		t.Error("the alias of the array lost its length")
+	}
+}
+
+func TestDecodeRejectsNilPrimitivePointer(t *testing.T) {
+	dec, err := symbolic.NewDecoder([]symbolic.Node{{Kind: "int"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := &EncodedMemory{Primitives: []EncodedPrimitive{{Pointer: -1, Value: 0}}}
+	if _, err := encoded.Decode(dec); err == nil {
+		t.Error("expected an error for a nil primitive pointer")
+	}
+}
@@ -190,6 +190,11 @@ func (sm *SymbolicMemory) Copy() *SymbolicMemory {
 		Primitives: make(map[symbolic.SymbolicPointer]symbolic.SymbolicExpression),
 		Objects:    make(map[Id]map[Id]symbolic.SymbolicExpression),
 		Arrays:     make(map[Id]map[Id]symbolic.SymbolicExpression),
+		ObjectId:   sm.ObjectId,
+		ArrayId:    sm.ArrayId,
+		Aliases:    make(map[Id]Id),
+		AliasesId:  sm.AliasesId,
+		ArrLength:  make(map[Id]uint),
 	}
 	for id, value := range sm.Primitives {
 		newMem.Primitives[id] = value
@@ -206,5 +211,11 @@ func (sm *SymbolicMemory) Copy() *SymbolicMemory {
 			newMem.Arrays[id][index] = element
 		}
 	}
+	for id, addr := range sm.Aliases {
+		newMem.Aliases[id] = addr
+	}
+	for id, length := range sm.ArrLength {
+		newMem.ArrLength[id] = length
+	}
 	return newMem
 }
@@ -0,0 +1,503 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"symbolic-execution-course/internal/memory"
+	"symbolic-execution-course/internal/symbolic"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+const defaultMaxMergeCost = 64
+
+// StateMerger объединяет состояния в точках слияния потока управления:
+// в ближайших постдоминаторах ветвлений, сразу после phi-инструкций.
+// Состояние, пришедшее в такую точку, ждёт, пока туда могут прийти другие
+// состояния из очереди; совместимые состояния сливаются в одно, различающиеся
+// значения становятся ite, а условие пути - общим префиксом и дизъюнкцией хвостов.
+//
+// Слияние делает будущие запросы к решателю тяжелее, поэтому оценивается
+// его стоимость: суммарный размер ite и дизъюнкции. Если она больше MaxCost,
+// состояния остаются раздельными.
+type StateMerger struct {
+	MaxCost int
+
+	Merges   int // Выполненные слияния
+	Rejected int // Отказы по стоимости или несовместимости
+
+	joins    map[*ssa.Function]map[*ssa.BasicBlock]int
+	parked   map[string][]*Interpreter
+	released map[mergeRelease]bool
+}
+
+type mergeRelease struct {
+	node  *ExecutionNode
+	block *ssa.BasicBlock
+}
+
+func NewStateMerger() *StateMerger {
+	return &StateMerger{
+		MaxCost:  defaultMaxMergeCost,
+		joins:    make(map[*ssa.Function]map[*ssa.BasicBlock]int),
+		parked:   make(map[string][]*Interpreter),
+		released: make(map[mergeRelease]bool),
+	}
+}
+
+// Parked возвращает число состояний, ожидающих слияния
+func (sm *StateMerger) Parked() int {
+	n := 0
+	for _, states := range sm.parked {
+		n += len(states)
+	}
+	return n
+}
+
+// arrive вызывается для состояния перед выполнением инструкции.
+// Возвращает состояние, которое нужно исполнять дальше, или nil,
+// если состояние оставлено ждать партнёров.
+func (sm *StateMerger) arrive(analyser *Analyser, interpreter *Interpreter) *Interpreter {
+	if !sm.isMergePoint(interpreter) {
+		return interpreter
+	}
+
+	release := mergeRelease{node: interpreter.Node, block: interpreter.CurrentBlock}
+	if sm.released[release] {
+		delete(sm.released, release)
+		return interpreter
+	}
+
+	key := mergeKey(interpreter)
+	waiting := sm.parked[key]
+	for i, other := range waiting {
+		merged := sm.merge(analyser, other, interpreter)
+		if merged == nil {
+			continue
+		}
+		sm.parked[key] = append(waiting[:i:i], waiting[i+1:]...)
+		interpreter = merged
+		break
+	}
+
+	if sm.mayArrive(analyser, interpreter) {
+		sm.parked[key] = append(sm.parked[key], interpreter)
+		return nil
+	}
+	return interpreter
+}
+
+// release возвращает в очередь все ожидающие состояния; вызывается,
+// когда очередь опустела и партнёров больше не будет
+func (sm *StateMerger) release(analyser *Analyser) {
+	for key, states := range sm.parked {
+		for _, state := range states {
+			sm.released[mergeRelease{node: state.Node, block: state.CurrentBlock}] = true
+			analyser.push(state)
+		}
+		delete(sm.parked, key)
+	}
+}
+
+// isMergePoint проверяет, стоит ли состояние на первой не-phi инструкции
+// блока, постдоминирующего какое-то ветвление
+func (sm *StateMerger) isMergePoint(interpreter *Interpreter) bool {
+	frame := interpreter.GetCurrentFrame()
+	if frame == nil || interpreter.CurrentBlock == nil {
+		return false
+	}
+
+	joins, ok := sm.joins[frame.Function]
+	if !ok {
+		joins = joinPoints(frame.Function)
+		sm.joins[frame.Function] = joins
+	}
+	index, ok := joins[interpreter.CurrentBlock]
+	return ok && index == interpreter.InstrIndex
+}
+
+// mayArrive проверяет, может ли какое-то состояние из очереди ещё прийти
+// в ту же точку с тем же стеком вызовов
+func (sm *StateMerger) mayArrive(analyser *Analyser, interpreter *Interpreter) bool {
+	target := interpreter.CurrentBlock
+	depth := len(interpreter.CallStack)
+
+	for _, item := range analyser.StatesQueue {
+		other := &item.value
+		if len(other.CallStack) < depth || other.CurrentBlock == nil {
+			continue
+		}
+		sameStack := true
+		for i := 0; i < depth; i++ {
+			if other.CallStack[i].Function != interpreter.CallStack[i].Function {
+				sameStack = false
+				break
+			}
+		}
+		if !sameStack {
+			continue
+		}
+		// Из вызова состояние ещё вернётся в эту функцию
+		if len(other.CallStack) > depth || reachable(other.CurrentBlock, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// merge объединяет два состояния или возвращает nil, если они несовместимы
+// или слияние слишком дорого
+func (sm *StateMerger) merge(analyser *Analyser, a, b *Interpreter) *Interpreter {
+	if !compatibleStates(a, b) {
+		sm.Rejected++
+		return nil
+	}
+
+	prefix := commonPrefix(a.PathCondition, b.PathCondition)
+	condA := conjunction(a.PathCondition.suffix(prefix))
+	condB := conjunction(b.PathCondition.suffix(prefix))
+	cost := expressionSize(condA) + expressionSize(condB)
+
+	var ite func(x, y symbolic.SymbolicExpression) (symbolic.SymbolicExpression, bool)
+	ite = func(x, y symbolic.SymbolicExpression) (symbolic.SymbolicExpression, bool) {
+		if x == y || x.String() == y.String() {
+			return x, true
+		}
+		// Кортежи результатов сливаются поэлементно
+		if tx, ok := x.(*symbolic.Tuple); ok {
+			ty, ok := y.(*symbolic.Tuple)
+			if !ok || len(tx.Elements) != len(ty.Elements) {
+				return nil, false
+			}
+			elements := make([]symbolic.SymbolicExpression, len(tx.Elements))
+			for i := range elements {
+				if elements[i], ok = ite(tx.Elements[i], ty.Elements[i]); !ok {
+					return nil, false
+				}
+			}
+			return symbolic.NewTuple(elements), true
+		}
+		if !isBaseValue(x) || !isBaseValue(y) || x.Type() != y.Type() {
+			return nil, false
+		}
+		cost += expressionSize(x) + expressionSize(y)
+		return symbolic.NewConditionalOperation(condA, []symbolic.SymbolicExpression{x}, []symbolic.SymbolicExpression{y}), true
+	}
+
+	merged := a.Copy()
+	for i := range merged.CallStack {
+		frameA, frameB := a.CallStack[i], b.CallStack[i]
+		if frameA.ReturnValue != nil && frameB.ReturnValue != nil {
+			value, ok := ite(frameA.ReturnValue, frameB.ReturnValue)
+			if !ok {
+				sm.Rejected++
+				return nil
+			}
+			merged.CallStack[i].ReturnValue = value
+		}
+		for name, valueB := range frameB.LocalMemory {
+			valueA, ok := frameA.LocalMemory[name]
+			if !ok {
+				merged.CallStack[i].LocalMemory[name] = valueB
+				continue
+			}
+			value, ok := ite(valueA, valueB)
+			if !ok {
+				sm.Rejected++
+				return nil
+			}
+			merged.CallStack[i].LocalMemory[name] = value
+		}
+	}
+
+	if !mergeHeaps(merged.Heap, a.Heap, b.Heap, ite) {
+		sm.Rejected++
+		return nil
+	}
+
+	if !mergeGlobals(merged, a.Globals, b.Globals, ite) {
+		sm.Rejected++
+		return nil
+	}
+
+	if cost > sm.MaxCost {
+		sm.Rejected++
+		return nil
+	}
+
+	merged.PathCondition = prefix
+	if !isComplement(condA, condB) {
+		merged.PathCondition = prefix.Append(symbolic.NewLogicalOperation(
+			[]symbolic.SymbolicExpression{condA, condB}, symbolic.OR))
+	}
+	merged.ExecutionSteps = max(a.ExecutionSteps, b.ExecutionSteps)
+	merged.SkippedCalls = max(a.SkippedCalls, b.SkippedCalls)
+	for k, v := range b.BlockVisitCount {
+		merged.BlockVisitCount[k] = max(merged.BlockVisitCount[k], v)
+	}
+	for k, v := range b.LoopCounters {
+		merged.LoopCounters[k] = max(merged.LoopCounters[k], v)
+	}
+	for k, v := range b.VisitedBlocks {
+		merged.VisitedBlocks[k] = merged.VisitedBlocks[k] || v
+	}
+	for k, v := range b.VisitedFunctions {
+		merged.VisitedFunctions[k] = merged.VisitedFunctions[k] || v
+	}
+
+	merged.trail = &blockTrail{block: a.CurrentBlock, parent: a.trail, merged: b.trail}
+
+	merged.Node = analyser.newNode(a.Node, a.CurrentBlock)
+	merged.Node.MergedWith = b.Node
+	merged.Analyser = analyser
+
+	sm.Merges++
+	return merged
+}
+
+// mergeHeaps записывает в dst ячейки кучи обоих состояний; ячейки,
+// существующие только в одном из них, делают состояния несовместимыми
+func mergeHeaps(dst, a, b *memory.SymbolicMemory, ite func(x, y symbolic.SymbolicExpression) (symbolic.SymbolicExpression, bool)) bool {
+	if len(a.Primitives) != len(b.Primitives) || len(a.Objects) != len(b.Objects) || len(a.Arrays) != len(b.Arrays) {
+		return false
+	}
+
+	for ptr, valueA := range a.Primitives {
+		valueB, ok := b.Primitives[ptr]
+		if !ok {
+			return false
+		}
+		value, ok := ite(valueA, valueB)
+		if !ok {
+			return false
+		}
+		dst.Primitives[ptr] = value
+	}
+
+	mergeCells := func(dst, a, b map[memory.Id]map[memory.Id]symbolic.SymbolicExpression) bool {
+		for id, cellsA := range a {
+			cellsB, ok := b[id]
+			if !ok || len(cellsA) != len(cellsB) {
+				return false
+			}
+			for idx, valueA := range cellsA {
+				valueB, ok := cellsB[idx]
+				if !ok {
+					return false
+				}
+				if valueA == nil || valueB == nil {
+					if valueA != valueB {
+						return false
+					}
+					continue
+				}
+				value, ok := ite(valueA, valueB)
+				if !ok {
+					return false
+				}
+				dst[id][idx] = value
+			}
+		}
+		return true
+	}
+	return mergeCells(dst.Objects, a.Objects, b.Objects) && mergeCells(dst.Arrays, a.Arrays, b.Arrays)
+}
+
+// mergeGlobals записывает в ячейки merged глобальные переменные обоих
+// состояний; переменная, к которой обращалось только одно из них, или
+// составная переменная с разным содержимым делают их несовместимыми
+func mergeGlobals(merged *Interpreter, a, b map[*ssa.Global]*symbolic.SymbolicPointer, ite func(x, y symbolic.SymbolicExpression) (symbolic.SymbolicExpression, bool)) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for global, cellA := range a {
+		cellB, ok := b[global]
+		if !ok || cellA.Address != cellB.Address {
+			return false
+		}
+		if cellA.Expr.String() == cellB.Expr.String() {
+			continue
+		}
+		if _, ok := basicGlobal(global); !ok {
+			return false
+		}
+		value, ok := ite(cellValue(cellA), cellValue(cellB))
+		if !ok {
+			return false
+		}
+		merged.Heap.AssignField(merged.Globals[global], 0, value)
+	}
+	return true
+}
+
+// compatibleStates проверяет, что состояния стоят в одной точке с одинаковым стеком вызовов
+func compatibleStates(a, b *Interpreter) bool {
+	if a.CurrentBlock != b.CurrentBlock || a.InstrIndex != b.InstrIndex ||
+		len(a.CallStack) != len(b.CallStack) || a.CurrentCallDepth != b.CurrentCallDepth {
+		return false
+	}
+	for i := range a.CallStack {
+		fa, fb := a.CallStack[i], b.CallStack[i]
+		if fa.Function != fb.Function || fa.CurrentBlock != fb.CurrentBlock ||
+			fa.ReturnToIndex != fb.ReturnToIndex || fa.ReturnVarName != fb.ReturnVarName {
+			return false
+		}
+	}
+	return true
+}
+
+// isBaseValue - значения, которые можно выбирать через ite; указатели и массивы
+// интерпретатор разыменовывает напрямую, поэтому они должны совпадать
+func isBaseValue(expr symbolic.SymbolicExpression) bool {
+	switch expr.(type) {
+	case *symbolic.SymbolicPointer, *symbolic.SymbolicArray, *symbolic.FieldAddr, *symbolic.IndexAddr:
+		return false
+	}
+	switch expr.Type() {
+	case symbolic.IntType, symbolic.BoolType, symbolic.FloatType:
+		return true
+	}
+	return false
+}
+
+// isComplement распознаёт пару c и !c, дизъюнкция которых тождественно истинна
+func isComplement(a, b symbolic.SymbolicExpression) bool {
+	if not, ok := b.(*symbolic.UnaryOperation); ok && not.Operator == symbolic.NOT && not.Operand.String() == a.String() {
+		return true
+	}
+	if not, ok := a.(*symbolic.UnaryOperation); ok && not.Operator == symbolic.NOT && not.Operand.String() == b.String() {
+		return true
+	}
+	return false
+}
+
+func conjunction(constraints []symbolic.SymbolicExpression) symbolic.SymbolicExpression {
+	switch len(constraints) {
+	case 0:
+		return symbolic.NewBoolConstant(true)
+	case 1:
+		return constraints[0]
+	}
+	return symbolic.NewLogicalOperation(constraints, symbolic.AND)
+}
+
+// mergeKey описывает точку программы вместе со стеком вызовов
+func mergeKey(interpreter *Interpreter) string {
+	var sb strings.Builder
+	for _, frame := range interpreter.CallStack {
+		fmt.Fprintf(&sb, "%p:%p:%d/", frame.Function, frame.CurrentBlock, frame.ReturnToIndex)
+	}
+	fmt.Fprintf(&sb, "%p:%d", interpreter.CurrentBlock, interpreter.InstrIndex)
+	return sb.String()
+}
+
+// joinPoints находит ближайшие постдоминаторы блоков с ветвлением
+// и для каждого - индекс первой инструкции после phi
+func joinPoints(fn *ssa.Function) map[*ssa.BasicBlock]int {
+	ipdom := immediatePostDominators(fn)
+	joins := make(map[*ssa.BasicBlock]int)
+
+	for _, block := range fn.Blocks {
+		if len(block.Succs) < 2 {
+			continue
+		}
+		join := ipdom[block]
+		if join == nil || len(join.Preds) < 2 {
+			continue
+		}
+		index := 0
+		for index < len(join.Instrs) {
+			if _, isPhi := join.Instrs[index].(*ssa.Phi); !isPhi {
+				break
+			}
+			index++
+		}
+		joins[join] = index
+	}
+	return joins
+}
+
+// immediatePostDominators считает постдоминаторы итеративно: множество
+// блока - он сам и пересечение множеств его преемников
+func immediatePostDominators(fn *ssa.Function) map[*ssa.BasicBlock]*ssa.BasicBlock {
+	n := len(fn.Blocks)
+	pdom := make([][]bool, n)
+	for i, block := range fn.Blocks {
+		pdom[i] = make([]bool, n)
+		if len(block.Succs) == 0 {
+			pdom[i][i] = true
+			continue
+		}
+		for j := range pdom[i] {
+			pdom[i][j] = true
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for i := n - 1; i >= 0; i-- {
+			block := fn.Blocks[i]
+			if len(block.Succs) == 0 {
+				continue
+			}
+			for j := 0; j < n; j++ {
+				value := j == i
+				if !value {
+					value = true
+					for _, succ := range block.Succs {
+						value = value && pdom[succ.Index][j]
+					}
+				}
+				if value != pdom[i][j] {
+					pdom[i][j] = value
+					changed = true
+				}
+			}
+		}
+	}
+
+	// Ближайший строгий постдоминатор - тот, у кого больше всего постдоминаторов
+	ipdom := make(map[*ssa.BasicBlock]*ssa.BasicBlock)
+	for i, block := range fn.Blocks {
+		best, bestCount := -1, -1
+		for j := 0; j < n; j++ {
+			if j == i || !pdom[i][j] {
+				continue
+			}
+			count := 0
+			for _, v := range pdom[j] {
+				if v {
+					count++
+				}
+			}
+			if count > bestCount {
+				best, bestCount = j, count
+			}
+		}
+		if best >= 0 {
+			ipdom[block] = fn.Blocks[best]
+		}
+	}
+	return ipdom
+}
+
+// reachable проверяет достижимость блока to из блока from по графу потока управления
+func reachable(from, to *ssa.BasicBlock) bool {
+	seen := map[*ssa.BasicBlock]bool{from: true}
+	queue := []*ssa.BasicBlock{from}
+	for len(queue) > 0 {
+		block := queue[0]
+		queue = queue[1:]
+		if block == to {
+			return true
+		}
+		for _, succ := range block.Succs {
+			if !seen[succ] {
+				seen[succ] = true
+				queue = append(queue, succ)
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const mergeSource = `package main
+
+func Diamond(x int) int {
+	y := 0
+	if x > 0 {
+		y = 1
+	} else {
+		y = 2
+	}
+	return y + 1
+}
+
+func Sequence(a, b int) int {
+	s := 0
+	if a > 0 {
+		s += 1
+	}
+	if b > 0 {
+		s += 2
+	}
+	return s
+}
+
+func Early(x int) int {
+	if x > 0 {
+		return 1
+	}
+	return 0
+}
+`
+
+func TestStateMerging(t *testing.T) {
+	tests := []struct {
+		function string
+		maxCost  int
+		paths    int
+		merges   int
+		rejected bool
+	}{
+		{"Diamond", 0, 1, 1, false},
+		{"Sequence", 0, 1, 2, false},
+		// Пути расходятся до точки слияния: сливать нечего
+		{"Early", 0, 2, 0, false},
+		// Слишком дорогое слияние оставляет состояния раздельными
+		{"Diamond", 1, 2, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			merger := NewStateMerger()
+			if tt.maxCost > 0 {
+				merger.MaxCost = tt.maxCost
+			}
+			report, err := AnalyseSources(context.Background(), map[string]string{"main.go": mergeSource}, tt.function, Options{Merger: merger})
+			if err != nil {
+				t.Fatalf("analyse: %v", err)
+			}
+			if report.Stats.Paths != tt.paths || report.Stats.Merges != tt.merges || (report.Stats.MergesRejected > 0) != tt.rejected {
+				t.Errorf("stats = %+v, want %d paths after %d merges, rejected %v", report.Stats, tt.paths, tt.merges, tt.rejected)
+			}
+			if report.Stats.Truncated {
+				t.Error("merging must not lose paths")
+			}
+			// Слитый результат различает ветви через ite
+			if tt.merges > 0 && !strings.Contains(report.Paths[0].ReturnValue, "?") {
+				t.Errorf("return value = %s, want a conditional", report.Paths[0].ReturnValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"symbolic-execution-course/internal/solver"
+	"symbolic-execution-course/internal/translator"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// Значения ограничений по умолчанию
+const (
+	defaultMaxSteps     = 2000
+	defaultLoopBound    = 10
+	defaultMaxUnrolls   = 100
+	defaultMaxCallDepth = 50
+	defaultMaxPathSteps = 1000
+
+	// Ограничения на условие пути: число конъюнктов и глубина самого глубокого из них.
+	// Длины повторяют прежние пороги на число "&&" в строке условия (50 и 20
+	// для обратной дуги), глубина заменяет порог на число скобок. Числа путей
+	// в final_tests/loops.go закреплены в TestPathLimitsOnLoops.
+	defaultMaxPathLength = 50
+	defaultMaxPathDepth  = 32
+	// Более жёсткие ограничения для путей, проходящих по обратной дуге цикла
+	defaultMaxLoopPathLength = 20
+	defaultMaxLoopPathDepth  = 16
+)
+
+// Options - настройки и бюджеты анализа функции. Нулевое значение поля
+// означает значение по умолчанию, так что Options{} - обход в глубину
+// на 2000 шагов.
+type Options struct {
+	// Selector - стратегия выбора путей; nil - обход в глубину
+	Selector PathSelector
+	// Scheduler - политика вытеснения состояний; nil - NewScheduler()
+	Scheduler *Scheduler
+	// Merger - слияние состояний; nil - без слияния
+	Merger *StateMerger
+	// Globals - режим моделирования глобальных переменных
+	Globals GlobalsMode
+	// Solver - внешний решатель, запускаемый процессом: z3, cvc5 или yices;
+	// пусто - Z3 через привязку
+	Solver string
+
+	// MaxSteps - бюджет шагов анализа
+	MaxSteps int
+	// Timeout - бюджет времени анализа; 0 - без ограничения. Действует
+	// вместе со сроком контекста, переданного в точку входа.
+	Timeout time.Duration
+	// SolverTimeout - время на один запрос к решателю; 0 - без ограничения.
+	// Запрос, не уложившийся в него, считается выполнимым.
+	SolverTimeout time.Duration
+	// MemoryLimit - размер кучи в байтах, выше которого состояния вытесняются;
+	// 0 - без ограничения
+	MemoryLimit uint64
+	// MaxStates - сколько состояний может ждать в очереди; 0 - значение
+	// планировщика, отрицательное - без ограничения
+	MaxStates int
+
+	// LoopBound - сколько раз путь может войти в один блок по переходу
+	LoopBound int
+	// MaxUnrolls - сколько переходов по циклам всего может сделать путь
+	MaxUnrolls int
+	// MaxCallDepth - глубина вызовов, начиная с которой вызовы не исполняются
+	MaxCallDepth int
+	// MaxPathSteps - сколько инструкций может исполнить один путь
+	MaxPathSteps int
+	// MaxPathLength, MaxPathDepth - число конъюнктов условия пути
+	// и глубина самого глубокого из них
+	MaxPathLength int
+	MaxPathDepth  int
+	// MaxLoopPathLength, MaxLoopPathDepth - то же для путей, уходящих
+	// на следующую итерацию цикла
+	MaxLoopPathLength int
+	MaxLoopPathDepth  int
+
+	// Checkpoint - файл контрольной точки: анализ периодически записывает
+	// в него своё состояние, а при остановке - последнее; пусто - не записывать
+	Checkpoint string
+	// CheckpointInterval - как часто записывать контрольную точку; 0 - раз в минуту
+	CheckpointInterval time.Duration
+	// Resume - контрольная точка, с которой продолжается анализ той же
+	// функции той же программы. Бюджет шагов считается вместе с шагами,
+	// сделанными до контрольной точки.
+	Resume *Checkpoint
+}
+
+func (options Options) withDefaults() Options {
+	if options.Selector == nil {
+		options.Selector = &DfsPathSelector{}
+	}
+	if options.Scheduler == nil {
+		options.Scheduler = NewScheduler()
+	}
+	switch {
+	case options.MaxStates > 0:
+		options.Scheduler.MaxStates = options.MaxStates
+	case options.MaxStates < 0:
+		options.Scheduler.MaxStates = 0
+	}
+	if options.MemoryLimit > 0 {
+		options.Scheduler.MemoryLimit = options.MemoryLimit
+	}
+	if options.CheckpointInterval <= 0 {
+		options.CheckpointInterval = defaultCheckpointInterval
+	}
+
+	setDefault(&options.MaxSteps, defaultMaxSteps)
+	setDefault(&options.LoopBound, defaultLoopBound)
+	setDefault(&options.MaxUnrolls, defaultMaxUnrolls)
+	setDefault(&options.MaxCallDepth, defaultMaxCallDepth)
+	setDefault(&options.MaxPathSteps, defaultMaxPathSteps)
+	setDefault(&options.MaxPathLength, defaultMaxPathLength)
+	setDefault(&options.MaxPathDepth, defaultMaxPathDepth)
+	setDefault(&options.MaxLoopPathLength, defaultMaxLoopPathLength)
+	setDefault(&options.MaxLoopPathDepth, defaultMaxLoopPathDepth)
+	return options
+}
+
+func setDefault(value *int, def int) {
+	if *value <= 0 {
+		*value = def
+	}
+}
+
+// Limit - ограничение анализа, оборвавшее путь
+type Limit int
+
+const (
+	LimitNone Limit = iota
+	// LimitSteps - исчерпан бюджет шагов анализа
+	LimitSteps
+	// LimitTimeout - исчерпан бюджет времени
+	LimitTimeout
+	// LimitPathSteps - путь исполнил слишком много инструкций
+	LimitPathSteps
+	// LimitLoopBound - путь слишком много раз вошёл в один блок цикла
+	LimitLoopBound
+	// LimitUnrolls - путь сделал слишком много переходов по циклам
+	LimitUnrolls
+	// LimitPathLength - условие пути слишком длинное или глубокое
+	LimitPathLength
+	// LimitCallDepth - путь пропустил вызов из-за глубины вызовов или рекурсии,
+	// его результат - непрозрачное значение
+	LimitCallDepth
+	// LimitStates - состояние вытеснено из переполненной очереди и потеряно
+	LimitStates
+	// LimitMemory - состояние вытеснено по памяти и потеряно
+	LimitMemory
+	// LimitCanceled - контекст анализа отменён
+	LimitCanceled
+)
+
+// Limits - все ограничения в порядке объявления
+var Limits = []Limit{LimitSteps, LimitTimeout, LimitPathSteps, LimitLoopBound, LimitUnrolls, LimitPathLength, LimitCallDepth, LimitStates, LimitMemory, LimitCanceled}
+
+func (l Limit) String() string {
+	switch l {
+	case LimitSteps:
+		return "steps"
+	case LimitTimeout:
+		return "timeout"
+	case LimitPathSteps:
+		return "path-steps"
+	case LimitLoopBound:
+		return "loop-bound"
+	case LimitUnrolls:
+		return "unrolls"
+	case LimitPathLength:
+		return "path-length"
+	case LimitCallDepth:
+		return "call-depth"
+	case LimitStates:
+		return "states"
+	case LimitMemory:
+		return "memory"
+	case LimitCanceled:
+		return "canceled"
+	}
+	return "none"
+}
+
+func (l Limit) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *Limit) UnmarshalText(text []byte) error {
+	for _, limit := range append([]Limit{LimitNone}, Limits...) {
+		if limit.String() == string(text) {
+			*l = limit
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown limit %q", text)
+}
+
+// truncate завершает путь, оборванный ограничением limit
+func (interpreter *Interpreter) truncate(limit Limit) {
+	interpreter.CurrentBlock = nil
+	interpreter.Terminal = TerminalTruncated
+	interpreter.Limit = limit
+}
+
+// cut отмечает, что ограничение limit оборвало n путей
+func (analyser *Analyser) cut(limit Limit, n int) {
+	if n == 0 {
+		return
+	}
+	analyser.Truncated = true
+	if analyser.Cuts == nil {
+		analyser.Cuts = make(map[Limit]int)
+	}
+	analyser.Cuts[limit] += n
+}
+
+// options - настройки анализатора состояния; без анализатора - по умолчанию
+func (interpreter *Interpreter) options() *Options {
+	if interpreter.Analyser != nil {
+		return &interpreter.Analyser.options
+	}
+	return &defaultOptions
+}
+
+var defaultOptions = Options{}.withDefaults()
+
+// ExploreFunction исследует пути функции с заданными настройками и
+// возвращает завершённые состояния. При отмене ctx или истечении его срока
+// анализ останавливается и возвращает пути, найденные к этому моменту;
+// оставшиеся состояния считаются оборванными. Ошибка возвращается, если
+// не удалось запустить решатель.
+func ExploreFunction(ctx context.Context, fn *ssa.Function, options Options) ([]*Interpreter, error) {
+	analyser, err := run(ctx, fn, options)
+	if err != nil {
+		return nil, err
+	}
+	analyser.close()
+	return analyser.Results, nil
+}
+
+// AnalyseFunctionWithOptions - как ExploreFunction, но возвращает отчёт
+// с моделями путей, найденными проблемами и статистикой
+func AnalyseFunctionWithOptions(ctx context.Context, fn *ssa.Function, options Options) (*Report, error) {
+	analyser, err := run(ctx, fn, options)
+	if err != nil {
+		return nil, err
+	}
+	defer analyser.close()
+	return analyser.report(fn), nil
+}
+
+// AnalyseSources - как AnalyseFunctionWithOptions для функции из исходников
+// пакета: ключ - имя файла, значение - его исходный код
+func AnalyseSources(ctx context.Context, sources map[string]string, functionName string, options Options) (*Report, error) {
+	fn, err := BuildFunction(sources, functionName)
+	if err != nil {
+		return nil, err
+	}
+	return AnalyseFunctionWithOptions(ctx, fn, options)
+}
+
+// run - общий цикл всех точек входа: создаёт анализатор, исследует
+// пути функции и пишет итоги в журнал
+func run(ctx context.Context, fn *ssa.Function, options Options) (*Analyser, error) {
+	analyser, err := newAnalyser(ctx, fn, options)
+	if err != nil {
+		return nil, err
+	}
+	analyser.explore()
+	analyser.logSummary(fn)
+	return analyser, nil
+}
+
+// newSolver создаёт транслятор и решатель: Z3 через привязку или внешний
+// решатель по имени. Транслятор Z3 нужен и при внешнем решателе.
+func newSolver(name string, timeout time.Duration) (*translator.Z3Translator, solver.Solver, error) {
+	z3Translator := translator.NewZ3Translator()
+	if name == "" {
+		z3Solver := solver.NewZ3SolverWithTranslator(z3Translator)
+		if timeout > 0 {
+			z3Solver.SetTimeout(timeout)
+		}
+		return z3Translator, solver.NewIncremental(z3Solver), nil
+	}
+
+	path, args, err := solver.ProcessCommand(name)
+	if err == nil && timeout > 0 {
+		var arg string
+		arg, err = solver.ProcessTimeoutArg(name, timeout)
+		args = append(args, arg)
+	}
+	var process *solver.ProcessSolver
+	if err == nil {
+		process, err = solver.NewProcessSolverWithLogic(solver.ProcessLogic(name), path, args...)
+	}
+	if err != nil {
+		z3Translator.Close()
+		return nil, nil, err
+	}
+	return z3Translator, process, nil
+}
+
+// close останавливает процесс внешнего решателя. Решатель Z3 не закрывается:
+// результаты анализа могут ещё обращаться к его контексту.
+func (analyser *Analyser) close() {
+	if process, ok := analyser.Solver.(*solver.ProcessSolver); ok {
+		process.Close()
+	}
+}
+
+// expired сообщает, что контекст анализа отменён или его срок истёк
+func (analyser *Analyser) expired() bool {
+	return analyser.ctx.Err() != nil
+}
+
+// contextLimit - ограничение, которым остановлен анализ с завершённым контекстом
+func contextLimit(ctx context.Context) Limit {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return LimitTimeout
+	}
+	return LimitCanceled
+}
@@ -1,6 +1,13 @@
 package internal
 
-import "math/rand"
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
 
 type PathSelector interface {
 	CalculatePriority(interpreter Interpreter) int
@@ -24,8 +31,382 @@ func (bfs *BfsPathSelector) CalculatePriority(interpreter Interpreter) int {
 	return bfs.counter
 }
 
-type RandomPathSelector struct{}
+// RandomPathSelector выбирает состояние из очереди равновероятно.
+// Нулевое значение использует зерно 0; для другого зерна - NewRandomPathSelector.
+type RandomPathSelector struct {
+	rng *rand.Rand
+}
+
+func NewRandomPathSelector(seed int64) *RandomPathSelector {
+	return &RandomPathSelector{rng: rand.New(rand.NewSource(seed))}
+}
 
 func (random *RandomPathSelector) CalculatePriority(interpreter Interpreter) int {
-	return rand.Int()
+	if random.rng == nil {
+		random.rng = rand.New(rand.NewSource(0))
+	}
+	return random.rng.Int()
+}
+
+// StateChooser - селектор, который сам выбирает состояние из очереди,
+// а не полагается на приоритеты. Choose возвращает индекс в очереди.
+type StateChooser interface {
+	Choose(queue PriorityQueue) int
+}
+
+// RandomPathTreeSelector - random-path из KLEE: спускается от корня дерева
+// исполнения, на каждом ветвлении подбрасывая монетку среди поддеревьев
+// с живыми состояниями. Состояние за k ветвлений от корня выбирается
+// с вероятностью порядка 2^-k, поэтому развилки внутри циклов
+// не перетягивают на себя выбор, как у RandomPathSelector.
+type RandomPathTreeSelector struct {
+	rng *rand.Rand
+}
+
+func NewRandomPathTreeSelector(seed int64) *RandomPathTreeSelector {
+	return &RandomPathTreeSelector{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (rp *RandomPathTreeSelector) CalculatePriority(interpreter Interpreter) int {
+	return 0
+}
+
+func (rp *RandomPathTreeSelector) Choose(queue PriorityQueue) int {
+	if rp.rng == nil {
+		rp.rng = rand.New(rand.NewSource(0))
+	}
+
+	leaves := make(map[*ExecutionNode]int, len(queue))
+	live := make(map[*ExecutionNode]bool)
+	for i, item := range queue {
+		if item.value.Node == nil {
+			// Состояние вне дерева, выбирать по дереву не из чего
+			return rp.rng.Intn(len(queue))
+		}
+		leaves[item.value.Node] = i
+		for n := item.value.Node; n != nil && !live[n]; n = n.Parent {
+			live[n] = true
+		}
+	}
+
+	node := queue[0].value.Node.Root()
+	for {
+		if i, ok := leaves[node]; ok {
+			return i
+		}
+
+		var children []*ExecutionNode
+		for _, child := range node.Children {
+			if live[child] {
+				children = append(children, child)
+			}
+		}
+		if len(children) == 0 {
+			return rp.rng.Intn(len(queue))
+		}
+		node = children[rp.rng.Intn(len(children))]
+	}
+}
+
+// InterleavedSelector чередует несколько селекторов: по кругу или, если заданы
+// веса, случайно пропорционально весам. Каждый селектор видит очередь
+// со своими приоритетами.
+type InterleavedSelector struct {
+	Selectors []PathSelector
+	Weights   []int
+
+	rng        *rand.Rand
+	turn       int
+	priorities map[*ExecutionNode][]int
+}
+
+// NewRoundRobinSelector чередует селекторы по кругу
+func NewRoundRobinSelector(selectors ...PathSelector) *InterleavedSelector {
+	return &InterleavedSelector{
+		Selectors:  selectors,
+		priorities: make(map[*ExecutionNode][]int),
+	}
+}
+
+// NewWeightedSelector выбирает селектор случайно с вероятностью, пропорциональной весу
+func NewWeightedSelector(seed int64, selectors []PathSelector, weights []int) *InterleavedSelector {
+	return &InterleavedSelector{
+		Selectors:  selectors,
+		Weights:    weights,
+		rng:        rand.New(rand.NewSource(seed)),
+		priorities: make(map[*ExecutionNode][]int),
+	}
+}
+
+func (is *InterleavedSelector) CalculatePriority(interpreter Interpreter) int {
+	priorities := make([]int, len(is.Selectors))
+	for i, selector := range is.Selectors {
+		priorities[i] = selector.CalculatePriority(interpreter)
+	}
+	if interpreter.Node != nil {
+		is.priorities[interpreter.Node] = priorities
+	}
+	return priorities[0]
+}
+
+// Reprioritize пересчитывает только приоритеты селекторов, зависящих от
+// покрытия. Счётчики DFS и BFS не сдвигаются, поэтому на их ходах
+// состояния остаются в прежнем порядке.
+func (is *InterleavedSelector) Reprioritize(interpreter Interpreter) int {
+	priorities, ok := is.priorities[interpreter.Node]
+	if !ok {
+		return is.CalculatePriority(interpreter)
+	}
+	for i, selector := range is.Selectors {
+		switch s := selector.(type) {
+		case Reprioritizer:
+			priorities[i] = s.Reprioritize(interpreter)
+		case CoverageObserver:
+			priorities[i] = selector.CalculatePriority(interpreter)
+		}
+	}
+	return priorities[0]
+}
+
+func (is *InterleavedSelector) Forget(interpreter *Interpreter) {
+	delete(is.priorities, interpreter.Node)
+	for _, selector := range is.Selectors {
+		if forgetter, ok := selector.(StateForgetter); ok {
+			forgetter.Forget(interpreter)
+		}
+	}
+}
+
+func (is *InterleavedSelector) Choose(queue PriorityQueue) int {
+	k := is.next()
+	if chooser, ok := is.Selectors[k].(StateChooser); ok {
+		return chooser.Choose(queue)
+	}
+
+	best, bestPriority := 0, 0
+	for i, item := range queue {
+		priority := item.priority
+		if p, ok := is.priorities[item.value.Node]; ok {
+			priority = p[k]
+		}
+		if i == 0 || priority > bestPriority {
+			best, bestPriority = i, priority
+		}
+	}
+	return best
+}
+
+func (is *InterleavedSelector) ObserveStep(interpreter *Interpreter) bool {
+	changed := false
+	for _, selector := range is.Selectors {
+		if observer, ok := selector.(CoverageObserver); ok && observer.ObserveStep(interpreter) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// next выбирает номер селектора для очередного шага
+func (is *InterleavedSelector) next() int {
+	if len(is.Weights) != len(is.Selectors) {
+		k := is.turn % len(is.Selectors)
+		is.turn++
+		return k
+	}
+
+	if is.rng == nil {
+		is.rng = rand.New(rand.NewSource(0))
+	}
+	total := 0
+	for _, w := range is.Weights {
+		total += max(w, 0)
+	}
+	if total == 0 {
+		return 0
+	}
+	r := is.rng.Intn(total)
+	for k, w := range is.Weights {
+		r -= max(w, 0)
+		if r < 0 {
+			return k
+		}
+	}
+	return len(is.Weights) - 1
+}
+
+// CoverageObserver - селектор, которому нужно видеть каждый шаг анализа.
+// Анализатор вызывает ObserveStep перед выполнением очередной инструкции
+// состояния; если метод вернул true, приоритеты в очереди пересчитываются.
+type CoverageObserver interface {
+	ObserveStep(interpreter *Interpreter) bool
+}
+
+// Reprioritizer - селектор, который при пересчёте приоритетов после шага
+// обновляет только их часть. Без него анализатор заново вызывает CalculatePriority.
+type Reprioritizer interface {
+	Reprioritize(interpreter Interpreter) int
+}
+
+// StateForgetter - селектор, хранящий данные о состояниях в очереди.
+// Анализатор вызывает Forget, когда состояние покидает очередь: выбрано,
+// вытеснено планировщиком или отброшено.
+type StateForgetter interface {
+	Forget(interpreter *Interpreter)
+}
+
+type coverageEdge struct {
+	from, to *ssa.BasicBlock
+}
+
+// Селекторы по расстоянию считают приоритет как -расстояние*stepsPriorityWeight
+// плюс число выполненных шагов, чтобы при равном расстоянии вести себя как DFS
+const (
+	unreachableDistance = 1 << 10
+	stepsPriorityWeight = 1 << 16
+)
+
+// CoverageSelector отдаёт предпочтение состояниям, ближайшим к ещё не
+// покрытым блокам и дугам графа потока управления (covering-new в KLEE).
+// Покрытие общее для всех состояний анализа. При равном расстоянии
+// выбирается более глубокое состояние, как в DFS.
+type CoverageSelector struct {
+	blocks map[*ssa.BasicBlock]bool
+	edges  map[coverageEdge]bool
+}
+
+func NewCoverageSelector() *CoverageSelector {
+	return &CoverageSelector{
+		blocks: make(map[*ssa.BasicBlock]bool),
+		edges:  make(map[coverageEdge]bool),
+	}
+}
+
+func (cs *CoverageSelector) CalculatePriority(interpreter Interpreter) int {
+	steps := min(interpreter.ExecutionSteps, stepsPriorityWeight-1)
+	return -cs.distanceToUncovered(&interpreter)*stepsPriorityWeight + steps
+}
+
+func (cs *CoverageSelector) ObserveStep(interpreter *Interpreter) bool {
+	block := interpreter.CurrentBlock
+	if block == nil {
+		return false
+	}
+
+	changed := false
+	if !cs.blocks[block] {
+		cs.blocks[block] = true
+		changed = true
+	}
+	if interpreter.InstrIndex == 0 && interpreter.PrevBlock != nil {
+		edge := coverageEdge{from: interpreter.PrevBlock, to: block}
+		if !cs.edges[edge] {
+			cs.edges[edge] = true
+			changed = true
+		}
+	}
+	return changed
+}
+
+// CoveredBlocks возвращает число покрытых базовых блоков
+func (cs *CoverageSelector) CoveredBlocks() int {
+	return len(cs.blocks)
+}
+
+// CoveredEdges возвращает число покрытых дуг между блоками
+func (cs *CoverageSelector) CoveredEdges() int {
+	return len(cs.edges)
+}
+
+// IsCovered сообщает, исполнялся ли блок хотя бы одним состоянием
+func (cs *CoverageSelector) IsCovered(block *ssa.BasicBlock) bool {
+	return cs.blocks[block]
+}
+
+// distanceToUncovered - число дуг от текущего блока состояния до ближайшего
+// непокрытого блока или непокрытой дуги внутри текущей функции
+func (cs *CoverageSelector) distanceToUncovered(interpreter *Interpreter) int {
+	// Завершённые состояния ничего не стоят, их выгоднее снять с очереди сразу
+	if interpreter.IsFinished() {
+		return 0
+	}
+
+	start := interpreter.CurrentBlock
+	if !cs.blocks[start] {
+		return 0
+	}
+	if interpreter.InstrIndex == 0 && interpreter.PrevBlock != nil &&
+		!cs.edges[coverageEdge{from: interpreter.PrevBlock, to: start}] {
+		return 0
+	}
+
+	distance := map[*ssa.BasicBlock]int{start: 0}
+	queue := []*ssa.BasicBlock{start}
+	for len(queue) > 0 {
+		block := queue[0]
+		queue = queue[1:]
+		for _, succ := range block.Succs {
+			if !cs.blocks[succ] || !cs.edges[coverageEdge{from: block, to: succ}] {
+				return distance[block] + 1
+			}
+			if _, seen := distance[succ]; !seen {
+				distance[succ] = distance[block] + 1
+				queue = append(queue, succ)
+			}
+		}
+	}
+	return unreachableDistance
+}
+
+// ParseSelector создаёт селектор по имени: dfs, bfs, random, random-path
+// или coverage. Несколько имён через "+" чередуются по кругу, а если хотя
+// бы у одного указан вес (имя:вес) - случайно пропорционально весам.
+func ParseSelector(spec string, seed int64) (PathSelector, error) {
+	parts := strings.Split(spec, "+")
+	if len(parts) == 1 {
+		return parseBasicSelector(spec, seed)
+	}
+
+	var (
+		selectors []PathSelector
+		weights   []int
+		weighted  bool
+	)
+	for i, part := range parts {
+		name, weight := part, 1
+		if n, w, ok := strings.Cut(part, ":"); ok {
+			var err error
+			if weight, err = strconv.Atoi(w); err != nil || weight < 0 {
+				return nil, fmt.Errorf("invalid weight in path selector %q", part)
+			}
+			name, weighted = n, true
+		}
+		// Каждый случайный селектор получает свою последовательность, производную от seed
+		selector, err := parseBasicSelector(name, seed+int64(i))
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+		weights = append(weights, weight)
+	}
+
+	if weighted {
+		return NewWeightedSelector(seed, selectors, weights), nil
+	}
+	return NewRoundRobinSelector(selectors...), nil
+}
+
+func parseBasicSelector(name string, seed int64) (PathSelector, error) {
+	switch name {
+	case "dfs":
+		return &DfsPathSelector{}, nil
+	case "bfs":
+		return &BfsPathSelector{}, nil
+	case "random":
+		return NewRandomPathSelector(seed), nil
+	case "random-path":
+		return NewRandomPathTreeSelector(seed), nil
+	case "coverage":
+		return NewCoverageSelector(), nil
+	}
+	return nil, fmt.Errorf("unknown path selector %q", name)
 }
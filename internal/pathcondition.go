@@ -0,0 +1,189 @@
+package internal
+
+import (
+	"symbolic-execution-course/internal/symbolic"
+)
+
+// PathCondition - неизменяемый список ограничений пути.
+// Каждый узел хранит одно ограничение и ссылку на родителя, поэтому
+// состояния после ветвления разделяют общий префикс, а не копируют его.
+// Пустое условие пути (true) - узел без ограничения; nil также означает true.
+type PathCondition struct {
+	parent     *PathCondition
+	constraint symbolic.SymbolicExpression
+	length     int
+	depth      int
+}
+
+// NewPathCondition создаёт пустое условие пути
+func NewPathCondition() *PathCondition {
+	return &PathCondition{}
+}
+
+// Append возвращает условие пути, дополненное ограничением.
+// Исходное условие не меняется. Ограничения, упрощающиеся до true, не добавляются.
+func (pc *PathCondition) Append(constraint symbolic.SymbolicExpression) *PathCondition {
+	constraint = simplifyPathCondition(constraint)
+	if boolConst, ok := constraint.(*symbolic.BoolConstant); ok && boolConst.Value {
+		if pc == nil {
+			return NewPathCondition()
+		}
+		return pc
+	}
+
+	return &PathCondition{
+		parent:     pc,
+		constraint: constraint,
+		length:     pc.Len() + 1,
+		depth:      max(pc.Depth(), expressionDepth(constraint)),
+	}
+}
+
+// Len возвращает число ограничений в условии пути
+func (pc *PathCondition) Len() int {
+	if pc == nil {
+		return 0
+	}
+	return pc.length
+}
+
+// Depth возвращает глубину самого глубокого ограничения
+func (pc *PathCondition) Depth() int {
+	if pc == nil {
+		return 0
+	}
+	return pc.depth
+}
+
+// exceeds проверяет, превышает ли условие пути ограничения на число
+// ограничений или на глубину самого глубокого из них
+func (pc *PathCondition) exceeds(maxLength, maxDepth int) bool {
+	return pc.Len() > maxLength || pc.Depth() > maxDepth
+}
+
+// Last возвращает последнее добавленное ограничение или nil
+func (pc *PathCondition) Last() symbolic.SymbolicExpression {
+	if pc == nil {
+		return nil
+	}
+	return pc.constraint
+}
+
+// Constraints возвращает ограничения в порядке добавления.
+// Одни и те же ограничения в разных ветвях - одни и те же объекты,
+// что позволяет решателю переиспользовать общий префикс.
+func (pc *PathCondition) Constraints() []symbolic.SymbolicExpression {
+	res := make([]symbolic.SymbolicExpression, pc.Len())
+	for node := pc; node.Len() > 0; node = node.parent {
+		res[node.length-1] = node.constraint
+	}
+	return res
+}
+
+// Expression собирает условие пути в одно выражение
+func (pc *PathCondition) Expression() symbolic.SymbolicExpression {
+	switch pc.Len() {
+	case 0:
+		return symbolic.NewBoolConstant(true)
+	case 1:
+		return pc.constraint
+	}
+	return simplifyPathCondition(symbolic.NewLogicalOperation(pc.Constraints(), symbolic.AND))
+}
+
+func (pc *PathCondition) String() string {
+	return pc.Expression().String()
+}
+
+// expressionDepth вычисляет глубину дерева выражения
+func expressionDepth(expr symbolic.SymbolicExpression) int {
+	maxDepth := func(exprs ...symbolic.SymbolicExpression) int {
+		res := 0
+		for _, e := range exprs {
+			res = max(res, expressionDepth(e))
+		}
+		return res
+	}
+
+	switch e := expr.(type) {
+	case nil:
+		return 0
+	case *symbolic.BinaryOperation:
+		return 1 + maxDepth(e.Left, e.Right)
+	case *symbolic.LogicalOperation:
+		return 1 + maxDepth(e.Operands...)
+	case *symbolic.UnaryOperation:
+		return 1 + maxDepth(e.Operand)
+	case *symbolic.ConditionalOperation:
+		return 1 + max(maxDepth(e.Condition), maxDepth(e.TrueBlock...), maxDepth(e.FalseBlock...))
+	case *symbolic.ArrayAccess:
+		return 1 + maxDepth(e.Index)
+	case *symbolic.FieldAccess:
+		return 1 + maxDepth(e.Obj, e.Key)
+	case *symbolic.FieldAssign:
+		return 1 + maxDepth(e.Obj, e.Value)
+	case *symbolic.FunctionCall:
+		return 1 + maxDepth(e.Args...)
+	case *symbolic.Tuple:
+		return 1 + maxDepth(e.Elements...)
+	}
+	return 1
+}
+
+// expressionSize считает число узлов в дереве выражения
+func expressionSize(expr symbolic.SymbolicExpression) int {
+	sum := func(exprs ...symbolic.SymbolicExpression) int {
+		res := 0
+		for _, e := range exprs {
+			res += expressionSize(e)
+		}
+		return res
+	}
+
+	switch e := expr.(type) {
+	case nil:
+		return 0
+	case *symbolic.BinaryOperation:
+		return 1 + sum(e.Left, e.Right)
+	case *symbolic.LogicalOperation:
+		return 1 + sum(e.Operands...)
+	case *symbolic.UnaryOperation:
+		return 1 + sum(e.Operand)
+	case *symbolic.ConditionalOperation:
+		return 1 + sum(e.Condition) + sum(e.TrueBlock...) + sum(e.FalseBlock...)
+	case *symbolic.ArrayAccess:
+		return 1 + sum(e.Index)
+	case *symbolic.FieldAccess:
+		return 1 + sum(e.Obj, e.Key)
+	case *symbolic.FieldAssign:
+		return 1 + sum(e.Obj, e.Value)
+	case *symbolic.FunctionCall:
+		return 1 + sum(e.Args...)
+	case *symbolic.Tuple:
+		return 1 + sum(e.Elements...)
+	}
+	return 1
+}
+
+// commonPrefix возвращает общий префикс двух условий пути
+func commonPrefix(a, b *PathCondition) *PathCondition {
+	for a.Len() > b.Len() {
+		a = a.parent
+	}
+	for b.Len() > a.Len() {
+		b = b.parent
+	}
+	for a != b && a.Len() > 0 {
+		a, b = a.parent, b.parent
+	}
+	if a.Len() == 0 {
+		return NewPathCondition()
+	}
+	return a
+}
+
+// suffix возвращает ограничения, добавленные после префикса
+func (pc *PathCondition) suffix(prefix *PathCondition) []symbolic.SymbolicExpression {
+	constraints := pc.Constraints()
+	return constraints[prefix.Len():]
+}
@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+func TestPathCondition(t *testing.T) {
+	x := symbolic.NewSymbolicVariable("x", symbolic.IntType)
+	positive := symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(0), symbolic.GT)
+	// ((x + 1) * 2) < 10 - глубина 4, листья тоже считаются
+	deep := symbolic.NewBinaryOperation(
+		symbolic.NewBinaryOperation(symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(1), symbolic.ADD), symbolic.NewIntConstant(2), symbolic.MUL),
+		symbolic.NewIntConstant(10),
+		symbolic.LT,
+	)
+
+	tests := []struct {
+		name        string
+		constraints []symbolic.SymbolicExpression
+		length      int
+		depth       int
+		expression  string
+	}{
+		{"empty", nil, 0, 0, "true"},
+		{"true is skipped", []symbolic.SymbolicExpression{symbolic.NewBoolConstant(true)}, 0, 0, "true"},
+		{"one constraint", []symbolic.SymbolicExpression{positive}, 1, 2, positive.String()},
+		{"depth of the deepest constraint", []symbolic.SymbolicExpression{positive, deep, positive}, 3, 4, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc := NewPathCondition()
+			for _, c := range tt.constraints {
+				pc = pc.Append(c)
+			}
+			if pc.Len() != tt.length || pc.Depth() != tt.depth {
+				t.Errorf("len %d, depth %d; want %d, %d", pc.Len(), pc.Depth(), tt.length, tt.depth)
+			}
+			if len(pc.Constraints()) != tt.length {
+				t.Errorf("constraints = %v, want %d", pc.Constraints(), tt.length)
+			}
+			if tt.expression != "" && pc.String() != tt.expression {
+				t.Errorf("expression = %s, want %s", pc, tt.expression)
+			}
+		})
+	}
+}
+
+func TestPathConditionSharesPrefix(t *testing.T) {
+	x := symbolic.NewSymbolicVariable("x", symbolic.IntType)
+	cond := symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(0), symbolic.GT)
+
+	prefix := NewPathCondition().Append(cond)
+	left := prefix.Append(symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(5), symbolic.LT))
+	right := prefix.Append(symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(5), symbolic.GE))
+
+	if prefix.Len() != 1 {
+		t.Errorf("Append changed the original condition: len %d", prefix.Len())
+	}
+	if left.Constraints()[0] != right.Constraints()[0] {
+		t.Error("branches do not share the constraint of their prefix")
+	}
+	if commonPrefix(left, right) != prefix {
+		t.Error("common prefix of the branches is not their parent")
+	}
+	if suffix := left.suffix(prefix); len(suffix) != 1 || suffix[0] != left.Last() {
+		t.Errorf("suffix = %v, want the last constraint of the branch", suffix)
+	}
+	if commonPrefix(left, NewPathCondition().Append(cond)).Len() != 0 {
+		t.Error("conditions built separately share no nodes")
+	}
+}
+
+func TestPathLimitsOnLoops(t *testing.T) {
+	source, err := os.ReadFile("../final_tests/loops.go")
+	if err != nil {
+		t.Fatalf("read loops.go: %v", err)
+	}
+
+	// Решатель отсекает невыполнимые ветви, поэтому путей меньше, чем
+	// находила проверка одних синтаксических противоречий (83 в LoopInsideLoop)
+	tests := []struct {
+		function string
+		paths    int
+	}{
+		{"LoopWithConcreteBound", 1},
+		{"LoopWithSymbolicBound", 12},
+		{"LoopWithSymbolicBoundAndSymbolicBranching", 22},
+		{"LoopWithSymbolicBoundAndComplexControlFlow", 16},
+		{"WhileCycle", 11},
+		{"LoopInsideLoop", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			report, err := AnalyseSources(context.Background(), map[string]string{"main.go": string(source)}, tt.function, Options{})
+			if err != nil {
+				t.Fatalf("analyse: %v", err)
+			}
+			if len(report.Paths) != tt.paths {
+				t.Errorf("found %d paths, want %d", len(report.Paths), tt.paths)
+			}
+		})
+	}
+}
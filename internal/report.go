@@ -0,0 +1,226 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"symbolic-execution-course/internal/solver"
+	"symbolic-execution-course/internal/symbolic"
+	"symbolic-execution-course/internal/translator"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// TerminalKind - чем закончился путь
+type TerminalKind int
+
+const (
+	// TerminalReturn - возврат из точки входа
+	TerminalReturn TerminalKind = iota
+	// TerminalPanic - паника, включая неудачное приведение типа
+	TerminalPanic
+	// TerminalTruncated - путь оборван ограничением анализа: числом шагов,
+	// развёрток цикла или длиной условия пути
+	TerminalTruncated
+	// TerminalUnsupported - путь дошёл до конструкции, которую движок
+	// не умеет исполнять, см. UnsupportedError
+	TerminalUnsupported
+)
+
+func (k TerminalKind) String() string {
+	switch k {
+	case TerminalPanic:
+		return "panic"
+	case TerminalTruncated:
+		return "truncated"
+	case TerminalUnsupported:
+		return "unsupported"
+	}
+	return "return"
+}
+
+func (k TerminalKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *TerminalKind) UnmarshalText(text []byte) error {
+	for _, kind := range []TerminalKind{TerminalReturn, TerminalPanic, TerminalTruncated, TerminalUnsupported} {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown terminal kind %q", text)
+}
+
+// blockTrail - блоки, пройденные состоянием, в виде неизменяемого списка:
+// копии состояния разделяют общий префикс, а состояние после слияния
+// ссылается и на путь второго состояния
+type blockTrail struct {
+	block  *ssa.BasicBlock
+	parent *blockTrail
+	merged *blockTrail
+}
+
+// enterBlock отмечает, что состояние исполняет текущий блок
+func (interpreter *Interpreter) enterBlock() {
+	if interpreter.CurrentBlock == nil || (interpreter.trail != nil && interpreter.trail.block == interpreter.CurrentBlock) {
+		return
+	}
+	interpreter.trail = &blockTrail{block: interpreter.CurrentBlock, parent: interpreter.trail}
+}
+
+// CoveredBlocks возвращает блоки, пройденные путём, по функциям и индексам
+func (interpreter *Interpreter) CoveredBlocks() []*ssa.BasicBlock {
+	seen := make(map[*blockTrail]bool)
+	blocks := make(map[*ssa.BasicBlock]bool)
+	var walk func(trail *blockTrail)
+	walk = func(trail *blockTrail) {
+		for ; trail != nil && !seen[trail]; trail = trail.parent {
+			seen[trail] = true
+			blocks[trail.block] = true
+			walk(trail.merged)
+		}
+	}
+	walk(interpreter.trail)
+	return sortedBlocks(blocks)
+}
+
+// sortedBlocks упорядочивает блоки по функциям и индексам
+func sortedBlocks(blocks map[*ssa.BasicBlock]bool) []*ssa.BasicBlock {
+	res := make([]*ssa.BasicBlock, 0, len(blocks))
+	for block := range blocks {
+		res = append(res, block)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if fi, fj := res[i].Parent().String(), res[j].Parent().String(); fi != fj {
+			return fi < fj
+		}
+		return res[i].Index < res[j].Index
+	})
+	return res
+}
+
+// Report - машиночитаемый результат анализа одной функции
+type Report struct {
+	Function string       `json:"function"`
+	Paths    []PathReport `json:"paths"`
+	Stats    ReportStats  `json:"stats"`
+	Findings []Finding    `json:"findings,omitempty"`
+
+	// Results - завершённые состояния, по одному на путь
+	Results []*Interpreter `json:"-"`
+}
+
+// PathReport - один найденный путь
+type PathReport struct {
+	PathCondition string `json:"pathCondition"`
+	// SMTLib - условие пути термом SMT-LIB2; пусто, если его нельзя транслировать
+	SMTLib      string       `json:"smtlib,omitempty"`
+	ReturnValue string       `json:"returnValue,omitempty"`
+	Terminal    TerminalKind `json:"terminal"`
+	// Limit - ограничение, оборвавшее путь, если путь оборван
+	Limit Limit `json:"limit,omitempty"`
+	// Reason - неподдерживаемая конструкция и её позиция, если путь на ней остановился
+	Reason string `json:"reason,omitempty"`
+	// Model - значения входов, ведущие по пути; nil, если решатель не нашёл модель
+	Model         map[string]interface{} `json:"model"`
+	CoveredBlocks []BlockReport          `json:"coveredBlocks"`
+	Steps         int                    `json:"steps"`
+}
+
+// BlockReport - базовый блок функции
+type BlockReport struct {
+	Function string `json:"function"`
+	Block    int    `json:"block"`
+}
+
+// ReportStats - статистика анализа функции
+type ReportStats struct {
+	Steps          int  `json:"steps"`
+	Paths          int  `json:"paths"`
+	Truncated      bool `json:"truncated"`
+	SolverHits     int  `json:"solverHits"`
+	SolverMisses   int  `json:"solverMisses"`
+	Evicted        int  `json:"evicted"`
+	Lost           int  `json:"lost"`
+	Merges         int  `json:"merges"`
+	MergesRejected int  `json:"mergesRejected"`
+	// Cuts - сколько путей оборвано каждым ограничением
+	Cuts map[Limit]int `json:"cuts,omitempty"`
+}
+
+func (analyser *Analyser) report(fn *ssa.Function) *Report {
+	report := &Report{
+		Function: fn.String(),
+		Paths:    make([]PathReport, 0, len(analyser.Results)),
+		Results:  analyser.Results,
+	}
+
+	for _, result := range analyser.Results {
+		path := PathReport{
+			PathCondition: result.PathCondition.String(),
+			Terminal:      result.Terminal,
+			Limit:         result.Limit,
+			Reason:        result.Reason,
+			Steps:         result.ExecutionSteps,
+		}
+		if term, err := translator.NewSMTLibTranslator().TranslateExpression(result.PathCondition.Expression()); err == nil {
+			path.SMTLib = term.(string)
+		}
+		if frame := result.GetCurrentFrame(); frame != nil && frame.ReturnValue != nil {
+			path.ReturnValue = frame.ReturnValue.String()
+		}
+		if inputs := analyser.inputsFor(result.PathCondition); inputs != nil {
+			path.Model = modelValues(inputs)
+		}
+		for _, block := range result.CoveredBlocks() {
+			path.CoveredBlocks = append(path.CoveredBlocks, BlockReport{Function: block.Parent().String(), Block: block.Index})
+		}
+		report.Paths = append(report.Paths, path)
+	}
+
+	report.Findings = analyser.findings(fn)
+
+	stats := analyser.SolverCache.Stats
+	report.Stats = ReportStats{
+		Steps:        analyser.stepsCounter,
+		Paths:        len(analyser.Results),
+		Truncated:    analyser.Truncated,
+		SolverHits:   stats.TotalHits(),
+		SolverMisses: stats.Misses,
+		Evicted:      len(analyser.Scheduler.Evictions),
+		Lost:         analyser.Scheduler.Lost(),
+		Cuts:         analyser.Cuts,
+	}
+	if analyser.Merger != nil {
+		report.Stats.Merges = analyser.Merger.Merges
+		report.Stats.MergesRejected = analyser.Merger.Rejected
+	}
+	return report
+}
+
+// modelValues переводит модель в значения JSON: числа и булевы значения
+// остаются собой, прочее записывается строкой
+func modelValues(model solver.Model) map[string]interface{} {
+	res := make(map[string]interface{}, len(model))
+	for name, value := range model {
+		switch v := value.(type) {
+		case *symbolic.IntConstant:
+			res[name] = v.Value
+		case *symbolic.BoolConstant:
+			res[name] = v.Value
+		case *symbolic.FloatConstant:
+			// NaN и бесконечности в JSON не представимы
+			if f := float64(v.Value); !math.IsNaN(f) && !math.IsInf(f, 0) {
+				res[name] = v.Value
+			} else {
+				res[name] = value.String()
+			}
+		default:
+			res[name] = value.String()
+		}
+	}
+	return res
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const reportSource = `package main
+
+func Check(x int) int {
+	if x > 5 {
+		panic("big")
+	}
+	return x
+}
+`
+
+func TestReportJSON(t *testing.T) {
+	report, err := AnalyseSources(context.Background(), map[string]string{"main.go": reportSource}, "Check", Options{})
+	if err != nil {
+		t.Fatalf("analyse: %v", err)
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal %s: %v", data, err)
+	}
+
+	if decoded.Function != report.Function || decoded.Stats.Paths != 2 || len(decoded.Paths) != 2 {
+		t.Fatalf("decoded %s", data)
+	}
+	// Пути различаются термом условия; модель ведёт по своему пути
+	tests := map[string]struct {
+		terminal TerminalKind
+		blocks   []int
+		taken    func(x float64) bool
+	}{
+		"(> x 5)":       {TerminalPanic, []int{0, 1}, func(x float64) bool { return x > 5 }},
+		"(not (> x 5))": {TerminalReturn, []int{0, 2}, func(x float64) bool { return x <= 5 }},
+	}
+	for _, path := range decoded.Paths {
+		want, ok := tests[path.SMTLib]
+		if !ok {
+			t.Errorf("unexpected path %q", path.SMTLib)
+			continue
+		}
+		delete(tests, path.SMTLib)
+		if path.Terminal != want.terminal {
+			t.Errorf("path %s ended with %s, want %s", path.SMTLib, path.Terminal, want.terminal)
+		}
+		// Числа модели декодируются как float64
+		if x, ok := path.Model["x"].(float64); !ok || !want.taken(x) {
+			t.Errorf("path %s has model %v", path.SMTLib, path.Model)
+		}
+		var blocks []int
+		for _, block := range path.CoveredBlocks {
+			if block.Function != report.Function {
+				t.Errorf("block %+v outside %s", block, report.Function)
+			}
+			blocks = append(blocks, block.Block)
+		}
+		if !reflect.DeepEqual(blocks, want.blocks) {
+			t.Errorf("path %s covered blocks %v, want %v", path.SMTLib, blocks, want.blocks)
+		}
+	}
+	for smtlib := range tests {
+		t.Errorf("path %s not reported", smtlib)
+	}
+}
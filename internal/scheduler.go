@@ -0,0 +1,303 @@
+package internal
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// EvictionPolicy - что делать с состоянием, вытесненным из очереди
+type EvictionPolicy int
+
+const (
+	// EvictDrop отбрасывает состояние; поддерево теряется, анализ помечается как неполный
+	EvictDrop EvictionPolicy = iota
+	// EvictReplay держит в памяти только точку ветвления состояния
+	// и позже переисполняет путь до неё от начального состояния
+	EvictReplay
+	// EvictSpill как EvictReplay, но записывает решения на развилках в файл
+	EvictSpill
+)
+
+func (p EvictionPolicy) String() string {
+	switch p {
+	case EvictReplay:
+		return "replay"
+	case EvictSpill:
+		return "spill"
+	}
+	return "drop"
+}
+
+const (
+	defaultMaxStates = 100
+
+	// Память проверяется не на каждом шаге: runtime.ReadMemStats останавливает мир
+	memoryCheckInterval = 64
+	maxReplaySteps      = 100000
+)
+
+// Eviction - запись о вытесненном состоянии
+type Eviction struct {
+	NodeID   int
+	Priority int
+	Reason   string
+	Limit    Limit
+	Policy   EvictionPolicy
+	Restored bool
+	// Err - почему состояние не удалось восстановить
+	Err error
+}
+
+// suspendedState - вытесненное состояние, которое ещё можно восстановить
+type suspendedState struct {
+	eviction  *Eviction
+	decisions []int
+	spillFile string
+}
+
+// Scheduler ограничивает очередь состояний по числу и по памяти.
+// При переполнении вытесняет состояния с наименьшим приоритетом селектора
+// и записывает каждое вытеснение. Восстанавливаются вытесненные состояния,
+// когда очередь опустела.
+type Scheduler struct {
+	MaxStates   int
+	MemoryLimit uint64 // байты кучи, 0 - без ограничения
+	Policy      EvictionPolicy
+	SpillDir    string
+	// Workers - число исполнителей, параллельно делающих шаги состояний
+	// из общей очереди; 0 и 1 - последовательный анализ
+	Workers int
+
+	Evictions []*Eviction
+
+	suspended []*suspendedState
+	checks    int
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{MaxStates: defaultMaxStates}
+}
+
+// Suspended возвращает число вытесненных состояний, ожидающих восстановления
+func (s *Scheduler) Suspended() int {
+	return len(s.suspended)
+}
+
+// Lost возвращает число состояний, потерянных без возможности восстановления
+func (s *Scheduler) Lost() int {
+	lost := 0
+	for _, e := range s.Evictions {
+		if e.Policy == EvictDrop || e.Err != nil {
+			lost++
+		}
+	}
+	return lost
+}
+
+// admit проверяет ограничения после добавления состояний в очередь
+func (s *Scheduler) admit(analyser *Analyser) {
+	for s.MaxStates > 0 && analyser.StatesQueue.Len() > s.MaxStates {
+		s.evict(analyser, LimitStates)
+	}
+
+	if s.MemoryLimit == 0 {
+		return
+	}
+	s.checks++
+	if s.checks%memoryCheckInterval != 0 {
+		return
+	}
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if stats.HeapAlloc <= s.MemoryLimit {
+		return
+	}
+	// Вытесняем четверть очереди, а не по одному: память освободится
+	// только после сборки мусора, и следующая проверка увидит её не сразу
+	for n := (analyser.StatesQueue.Len() + 3) / 4; n > 0; n-- {
+		s.evict(analyser, LimitMemory)
+	}
+}
+
+// evict вытесняет из очереди состояние с наименьшим приоритетом
+func (s *Scheduler) evict(analyser *Analyser, limit Limit) {
+	queue := analyser.StatesQueue
+	victim := 0
+	for i, item := range queue {
+		if item.priority < queue[victim].priority {
+			victim = i
+		}
+	}
+	item := heap.Remove(&analyser.StatesQueue, victim).(*Item)
+	analyser.forget(item)
+
+	eviction := &Eviction{Priority: item.priority, Reason: evictionReason(limit), Limit: limit, Policy: s.Policy}
+	s.Evictions = append(s.Evictions, eviction)
+
+	node := item.value.Node
+	if node == nil || s.Policy == EvictDrop || node.IsMerged() {
+		eviction.Policy = EvictDrop
+		analyser.cut(limit, 1)
+		return
+	}
+	eviction.NodeID = node.ID
+
+	suspended := &suspendedState{eviction: eviction, decisions: decisionsTo(node)}
+	if s.Policy == EvictSpill {
+		if err := s.spill(suspended); err != nil {
+			eviction.Err = err
+			analyser.cut(limit, 1)
+			return
+		}
+	}
+	s.suspended = append(s.suspended, suspended)
+}
+
+// restore восстанавливает последнее вытесненное состояние и кладёт его в очередь
+func (s *Scheduler) restore(analyser *Analyser) {
+	for len(s.suspended) > 0 && analyser.StatesQueue.Len() == 0 {
+		suspended := s.suspended[len(s.suspended)-1]
+		s.suspended = s.suspended[:len(s.suspended)-1]
+
+		state, err := s.load(analyser, suspended)
+		if err != nil {
+			suspended.eviction.Err = err
+			analyser.cut(suspended.eviction.Limit, 1)
+			continue
+		}
+
+		suspended.eviction.Restored = true
+		analyser.push(state)
+	}
+}
+
+func (s *Scheduler) load(analyser *Analyser, suspended *suspendedState) (*Interpreter, error) {
+	if suspended.spillFile != "" {
+		data, err := os.ReadFile(suspended.spillFile)
+		if err != nil {
+			return nil, err
+		}
+		os.Remove(suspended.spillFile)
+		if err := json.Unmarshal(data, &suspended.decisions); err != nil {
+			return nil, err
+		}
+	}
+	return analyser.replay(suspended.decisions)
+}
+
+func (s *Scheduler) spill(suspended *suspendedState) error {
+	dir := s.SpillDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	data, err := json.Marshal(suspended.decisions)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(dir, fmt.Sprintf("state-%d-*.json", suspended.eviction.NodeID))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	suspended.spillFile = file.Name()
+	suspended.decisions = nil
+	return nil
+}
+
+// hasStates сообщает, есть ли что исследовать, при необходимости
+// восстанавливая вытесненные состояния
+func (analyser *Analyser) hasStates() bool {
+	if analyser.StatesQueue.Len() == 0 && analyser.Merger != nil {
+		analyser.Merger.release(analyser)
+	}
+	if analyser.StatesQueue.Len() == 0 && analyser.Scheduler != nil {
+		analyser.Scheduler.restore(analyser)
+	}
+	return analyser.StatesQueue.Len() > 0
+}
+
+// decisionsTo возвращает номера дочерних узлов на пути от корня до узла
+func decisionsTo(node *ExecutionNode) []int {
+	var decisions []int
+	for ; node.Parent != nil; node = node.Parent {
+		for i, child := range node.Parent.Children {
+			if child == node {
+				decisions = append(decisions, i)
+				break
+			}
+		}
+	}
+	for i, j := 0, len(decisions)-1; i < j; i, j = i+1, j-1 {
+		decisions[i], decisions[j] = decisions[j], decisions[i]
+	}
+	return decisions
+}
+
+// replay переисполняет путь от начального состояния, на каждой развилке
+// выбирая записанную ветку, и возвращает состояние в точке последнего ветвления
+func (analyser *Analyser) replay(decisions []int) (*Interpreter, error) {
+	if len(analyser.initialStates) == 0 {
+		return nil, fmt.Errorf("replay: no initial state")
+	}
+
+	state := analyser.initialStates[0].Copy()
+	state.Node = analyser.Root
+	if len(analyser.initialStates) > 1 {
+		if len(decisions) == 0 || decisions[0] >= len(analyser.initialStates) {
+			return nil, fmt.Errorf("replay: bad initial decision")
+		}
+		state = analyser.initialStates[decisions[0]].Copy()
+		state.Node = analyser.Root.Children[decisions[0]]
+		decisions = decisions[1:]
+	}
+	state.Analyser = analyser
+
+	for steps := 0; len(decisions) > 0; steps++ {
+		if steps >= maxReplaySteps {
+			return nil, fmt.Errorf("replay: too many steps")
+		}
+
+		instr := state.GetNextInstruction()
+		if instr == nil {
+			return nil, fmt.Errorf("replay: path ended %d forks early", len(decisions))
+		}
+
+		state.enterBlock()
+		next := state.interpretStep(instr)
+		switch {
+		case len(next) == 0:
+			return nil, fmt.Errorf("replay: path ended %d forks early", len(decisions))
+		case len(next) == 1:
+			next[0].Node = state.Node
+			state = next[0]
+		default:
+			d := decisions[0]
+			if d >= len(next) || d >= len(state.Node.Children) {
+				return nil, fmt.Errorf("replay: diverged at node %d", state.Node.ID)
+			}
+			next[d].Node = state.Node.Children[d]
+			state = next[d]
+			decisions = decisions[1:]
+		}
+		state.Analyser = analyser
+	}
+
+	return state, nil
+}
+
+func evictionReason(limit Limit) string {
+	if limit == LimitMemory {
+		return "memory limit"
+	}
+	return "queue limit"
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+// forksSource - 16 путей: очередь BFS растёт до восьми состояний
+const forksSource = `package main
+
+func Forks(a, b, c, d int) int {
+	s := 0
+	if a > 0 {
+		s++
+	}
+	if b > 0 {
+		s++
+	}
+	if c > 0 {
+		s++
+	}
+	if d > 0 {
+		s++
+	}
+	return s
+}
+`
+
+func TestSchedulerPolicies(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxStates int
+		policy    EvictionPolicy
+		// complete - найдены все 16 путей и ни одно состояние не потеряно
+		complete bool
+	}{
+		{"unbounded", 0, EvictDrop, true},
+		{"drop", 2, EvictDrop, false},
+		{"replay", 2, EvictReplay, true},
+		{"spill", 2, EvictSpill, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := &Scheduler{MaxStates: tt.maxStates, Policy: tt.policy, SpillDir: t.TempDir()}
+			maxStates := tt.maxStates
+			if maxStates == 0 {
+				maxStates = -1
+			}
+			report, err := AnalyseSources(context.Background(), map[string]string{"main.go": forksSource}, "Forks",
+				Options{Selector: &BfsPathSelector{}, Scheduler: scheduler, MaxStates: maxStates})
+			if err != nil {
+				t.Fatalf("analyse: %v", err)
+			}
+
+			stats := report.Stats
+			if complete := stats.Paths == 16 && !stats.Truncated && stats.Lost == 0; complete != tt.complete {
+				t.Errorf("stats = %+v, want complete %v", stats, tt.complete)
+			}
+			if (stats.Evicted > 0) != (tt.maxStates > 0) {
+				t.Errorf("evicted %d states with limit %d", stats.Evicted, tt.maxStates)
+			}
+			if stats.Cuts[LimitStates] != stats.Lost {
+				t.Errorf("cuts = %v, want %d states lost", stats.Cuts, stats.Lost)
+			}
+			for _, eviction := range scheduler.Evictions {
+				if eviction.Restored != (tt.policy != EvictDrop) || eviction.Err != nil {
+					t.Errorf("eviction %+v, want restored %v", eviction, tt.policy != EvictDrop)
+				}
+			}
+			if files, _ := os.ReadDir(scheduler.SpillDir); len(files) != 0 {
+				t.Errorf("%d spill files left", len(files))
+			}
+		})
+	}
+}
+
+func TestDecisionsReplay(t *testing.T) {
+	fn, err := BuildFunction(map[string]string{"main.go": forksSource}, "Forks")
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	analyser, err := newAnalyser(context.Background(), fn, Options{})
+	if err != nil {
+		t.Fatalf("newAnalyser: %v", err)
+	}
+	defer analyser.close()
+	analyser.explore()
+
+	// Каждый путь переисполняется до последней развилки по её решениям
+	for _, result := range analyser.Results {
+		node := result.Node
+		state, err := analyser.replay(decisionsTo(node))
+		if err != nil {
+			t.Fatalf("replay to node %d: %v", node.ID, err)
+		}
+		if state.Node != node {
+			t.Errorf("replay reached node %d, want %d", state.Node.ID, node.ID)
+		}
+	}
+}
+
+func TestInterleavedSelectorForgetsEvictedStates(t *testing.T) {
+	fn, err := BuildFunction(map[string]string{"main.go": forksSource}, "Forks")
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	selector, err := ParseSelector("bfs+coverage", 0)
+	if err != nil {
+		t.Fatalf("ParseSelector: %v", err)
+	}
+	scheduler := &Scheduler{Policy: EvictDrop}
+	if _, err := ExploreFunction(context.Background(), fn, Options{Selector: selector, Scheduler: scheduler, MaxStates: 2}); err != nil {
+		t.Fatalf("explore: %v", err)
+	}
+	if len(scheduler.Evictions) == 0 {
+		t.Fatal("no states were evicted")
+	}
+	if left := len(selector.(*InterleavedSelector).priorities); left != 0 {
+		t.Errorf("%d priorities left for evicted and finished states", left)
+	}
+}
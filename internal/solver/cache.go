@@ -0,0 +1,341 @@
+package solver
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+// CoreSolver - решатель, умеющий объяснять невыполнимость через unsat core
+type CoreSolver interface {
+	Solver
+
+	// CheckWithCore проверяет конъюнкцию ограничений. При Sat возвращает модель,
+	// при Unsat - индексы ограничений, входящих в ядро невыполнимости.
+	CheckWithCore(constraints []symbolic.SymbolicExpression) (Result, Model, []int, error)
+}
+
+// CacheStats - статистика работы кэша запросов
+type CacheStats struct {
+	Hits        int // Ответы из кэша точных запросов
+	ModelReuses int // Ответы Sat по ранее найденной модели
+	CoreHits    int // Ответы Unsat по ранее найденному ядру
+	Misses      int // Запросы, дошедшие до решателя
+}
+
+// TotalHits возвращает количество запросов, обошедшихся без решателя
+func (s CacheStats) TotalHits() int {
+	return s.Hits + s.ModelReuses + s.CoreHits
+}
+
+const (
+	maxReusedModels = 64
+	maxUnsatCores   = 256
+	maxCacheEntries = 4096
+)
+
+type cacheEntry struct {
+	result Result
+	model  Model
+}
+
+// Cache стоит перед решателем и отвечает на повторяющиеся запросы.
+// Помимо точного совпадения канонической формы, как в KLEE, он пробует
+// модели прошлых sat ответов и отвечает unsat на надмножества известных ядер.
+type Cache struct {
+	Solver Solver
+	Stats  CacheStats
+
+	entries map[string]cacheEntry
+	// order - кольцевой буфер ключей entries. Когда он заполнен, новый ключ
+	// занимает место самого старого, на которое указывает next
+	order  []string
+	next   int
+	models []Model
+	cores  [][]string
+}
+
+// NewCache создаёт кэш перед заданным решателем
+func NewCache(s Solver) *Cache {
+	return &Cache{
+		Solver:  s,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Check проверяет выполнимость конъюнкции ограничений
+func (c *Cache) Check(constraints []symbolic.SymbolicExpression) (Result, Model, error) {
+	conjuncts, keys, sortedKeys := canonicalize(constraints)
+	key := strings.Join(sortedKeys, "\n")
+
+	if entry, ok := c.entries[key]; ok {
+		c.Stats.Hits++
+		return entry.result, entry.model, nil
+	}
+
+	if c.hasUnsatSubset(sortedKeys) {
+		c.Stats.CoreHits++
+		c.remember(key, cacheEntry{result: Unsat})
+		return Unsat, nil, nil
+	}
+
+	for i := len(c.models) - 1; i >= 0; i-- {
+		if Satisfies(c.models[i], conjuncts) {
+			c.Stats.ModelReuses++
+			c.remember(key, cacheEntry{result: Sat, model: c.models[i]})
+			return Sat, c.models[i], nil
+		}
+	}
+
+	c.Stats.Misses++
+
+	var (
+		res   Result
+		model Model
+		core  []int
+		err   error
+	)
+	if coreSolver, ok := c.Solver.(CoreSolver); ok {
+		res, model, core, err = coreSolver.CheckWithCore(conjuncts)
+	} else {
+		res, model, err = CheckConstraints(c.Solver, conjuncts)
+	}
+	if err != nil {
+		return res, model, err
+	}
+
+	switch res {
+	case Sat:
+		if model != nil {
+			c.rememberModel(model)
+		}
+	case Unsat:
+		coreKeys := sortedKeys
+		if core != nil {
+			coreKeys = make([]string, 0, len(core))
+			for _, i := range core {
+				coreKeys = append(coreKeys, keys[i])
+			}
+			sort.Strings(coreKeys)
+		}
+		c.rememberCore(coreKeys)
+	}
+
+	if res != Unknown {
+		c.remember(key, cacheEntry{result: res, model: model})
+	}
+	return res, model, nil
+}
+
+func (c *Cache) remember(key string, entry cacheEntry) {
+	if len(c.order) < maxCacheEntries {
+		c.order = append(c.order, key)
+	} else {
+		delete(c.entries, c.order[c.next])
+		c.order[c.next] = key
+		c.next = (c.next + 1) % maxCacheEntries
+	}
+	c.entries[key] = entry
+}
+
+// keys возвращает ключи записей от старых к новым
+func (c *Cache) keys() []string {
+	return append(append([]string(nil), c.order[c.next:]...), c.order[:c.next]...)
+}
+
+func (c *Cache) rememberModel(model Model) {
+	if len(c.models) >= maxReusedModels {
+		c.models = c.models[1:]
+	}
+	c.models = append(c.models, model)
+}
+
+func (c *Cache) rememberCore(core []string) {
+	if len(c.cores) >= maxUnsatCores {
+		c.cores = c.cores[1:]
+	}
+	c.cores = append(c.cores, core)
+}
+
+// hasUnsatSubset проверяет, содержит ли набор ключей какое-то известное ядро
+func (c *Cache) hasUnsatSubset(keys []string) bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+
+	for _, core := range c.cores {
+		if len(core) > len(keys) {
+			continue
+		}
+		subset := true
+		for _, k := range core {
+			if !set[k] {
+				subset = false
+				break
+			}
+		}
+		if subset {
+			return true
+		}
+	}
+	return false
+}
+
+// Conjuncts раскладывает цепочки AND в плоский список конъюнктов
+// и отбрасывает константы true
+func Conjuncts(constraints ...symbolic.SymbolicExpression) []symbolic.SymbolicExpression {
+	var res []symbolic.SymbolicExpression
+
+	var walk func(expr symbolic.SymbolicExpression)
+	walk = func(expr symbolic.SymbolicExpression) {
+		switch e := expr.(type) {
+		case nil:
+		case *symbolic.LogicalOperation:
+			if e.Operator == symbolic.AND {
+				for _, op := range e.Operands {
+					walk(op)
+				}
+				return
+			}
+			res = append(res, expr)
+		case *symbolic.BoolConstant:
+			if !e.Value {
+				res = append(res, expr)
+			}
+		default:
+			res = append(res, expr)
+		}
+	}
+
+	for _, c := range constraints {
+		walk(c)
+	}
+	return res
+}
+
+// canonicalize строит каноническую форму набора ограничений: конъюнкты без
+// повторов в порядке первого появления (так инкрементальный решатель
+// сохраняет общий префикс), их ключи и упорядоченный список ключей
+func canonicalize(constraints []symbolic.SymbolicExpression) ([]symbolic.SymbolicExpression, []string, []string) {
+	seen := make(map[string]bool)
+	var (
+		conjuncts []symbolic.SymbolicExpression
+		keys      []string
+	)
+	for _, c := range Conjuncts(constraints...) {
+		key := c.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		conjuncts = append(conjuncts, c)
+		keys = append(keys, key)
+	}
+
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	return conjuncts, keys, sorted
+}
+
+// CacheState - содержимое кэша для сохранения. Выражения моделей заменены
+// номерами узлов в таблице symbolic.Encoder; записи ссылаются на модели
+// по номеру в Models, -1 - без модели. Записи идут в порядке добавления.
+type CacheState struct {
+	Models  []map[string]int `json:"models,omitempty"`
+	Entries []CacheRecord    `json:"entries,omitempty"`
+	// Reused - номера моделей, которые кэш пробует на новых запросах
+	Reused []int      `json:"reused,omitempty"`
+	Cores  [][]string `json:"cores,omitempty"`
+	Stats  CacheStats `json:"stats"`
+}
+
+// CacheRecord - ответ на запрос с канонической формой Key
+type CacheRecord struct {
+	Key    string `json:"key"`
+	Result Result `json:"result"`
+	Model  int    `json:"model"`
+}
+
+// Save записывает содержимое кэша, добавляя выражения моделей в таблицу enc
+func (c *Cache) Save(enc *symbolic.Encoder) *CacheState {
+	state := &CacheState{Cores: c.cores, Stats: c.Stats}
+
+	// Одна модель может быть и в записях, и в списке переиспользуемых;
+	// карты нельзя сравнивать, поэтому модели различаются по адресу
+	models := make(map[uintptr]int)
+	modelID := func(model Model) int {
+		if model == nil {
+			return -1
+		}
+		ptr := reflect.ValueOf(model).Pointer()
+		if id, ok := models[ptr]; ok {
+			return id
+		}
+		encoded := make(map[string]int, len(model))
+		for name, value := range model {
+			encoded[name] = enc.Encode(value)
+		}
+		models[ptr] = len(state.Models)
+		state.Models = append(state.Models, encoded)
+		return models[ptr]
+	}
+
+	for _, model := range c.models {
+		state.Reused = append(state.Reused, modelID(model))
+	}
+	for _, key := range c.keys() {
+		entry := c.entries[key]
+		state.Entries = append(state.Entries, CacheRecord{Key: key, Result: entry.result, Model: modelID(entry.model)})
+	}
+	return state
+}
+
+// Load заменяет содержимое кэша сохранённым
+func (c *Cache) Load(state *CacheState, dec *symbolic.Decoder) error {
+	models := make([]Model, len(state.Models))
+	for i, encoded := range state.Models {
+		model := make(Model, len(encoded))
+		for name, id := range encoded {
+			value, err := dec.Decode(id)
+			if err != nil {
+				return err
+			}
+			model[name] = value
+		}
+		models[i] = model
+	}
+	model := func(id int) (Model, error) {
+		if id == -1 {
+			return nil, nil
+		}
+		if id < 0 || id >= len(models) {
+			return nil, fmt.Errorf("load cache: no model %d", id)
+		}
+		return models[id], nil
+	}
+
+	c.entries = make(map[string]cacheEntry, len(state.Entries))
+	c.order, c.next = nil, 0
+	for _, record := range state.Entries {
+		m, err := model(record.Model)
+		if err != nil {
+			return err
+		}
+		c.remember(record.Key, cacheEntry{result: record.Result, model: m})
+	}
+	c.models = nil
+	for _, id := range state.Reused {
+		m, err := model(id)
+		if err != nil {
+			return err
+		}
+		c.models = append(c.models, m)
+	}
+	c.cores = state.Cores
+	c.Stats = state.Stats
+	return nil
+}
@@ -0,0 +1,177 @@
+package solver
+
+import (
+	"fmt"
+	"testing"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+// countingSolver отвечает sat, если ограничения выполняет заданная модель,
+// и считает запросы, дошедшие до решателя
+type countingSolver struct {
+	model    Model
+	asserted []symbolic.SymbolicExpression
+	scopes   []int
+	checks   int
+}
+
+func (s *countingSolver) Assert(constraint symbolic.SymbolicExpression) error {
+	s.asserted = append(s.asserted, constraint)
+	return nil
+}
+
+func (s *countingSolver) Push() { s.scopes = append(s.scopes, len(s.asserted)) }
+
+func (s *countingSolver) Pop() {
+	s.asserted = s.asserted[:s.scopes[len(s.scopes)-1]]
+	s.scopes = s.scopes[:len(s.scopes)-1]
+}
+
+func (s *countingSolver) Check() (Result, error) {
+	s.checks++
+	if Satisfies(s.model, s.asserted) {
+		return Sat, nil
+	}
+	return Unsat, nil
+}
+
+func (s *countingSolver) Model() (Model, error) { return s.model, nil }
+func (s *countingSolver) Reset()                { s.asserted, s.scopes = nil, nil }
+func (s *countingSolver) Close() error          { return nil }
+
+func TestCache(t *testing.T) {
+	x, y := intVar("x"), intVar("y")
+	positive := compare(x, 0, symbolic.GT)
+	negative := compare(x, 0, symbolic.LT)
+
+	tests := []struct {
+		name    string
+		queries [][]symbolic.SymbolicExpression
+		want    Result
+		stats   CacheStats
+	}{
+		{
+			name:    "exact hit",
+			queries: [][]symbolic.SymbolicExpression{{positive}, {positive}},
+			want:    Sat,
+			stats:   CacheStats{Hits: 1, Misses: 1},
+		},
+		{
+			name: "hit regardless of order and repeats",
+			queries: [][]symbolic.SymbolicExpression{
+				{positive, compare(y, 1, symbolic.EQ)},
+				{compare(y, 1, symbolic.EQ), positive, positive},
+			},
+			want:  Sat,
+			stats: CacheStats{Hits: 1, Misses: 1},
+		},
+		{
+			name:    "model reuse",
+			queries: [][]symbolic.SymbolicExpression{{positive}, {compare(x, 3, symbolic.GT)}},
+			want:    Sat,
+			stats:   CacheStats{ModelReuses: 1, Misses: 1},
+		},
+		{
+			name: "unsat core",
+			queries: [][]symbolic.SymbolicExpression{
+				{positive, negative},
+				{compare(y, 1, symbolic.EQ), negative, positive},
+			},
+			want:  Unsat,
+			stats: CacheStats{CoreHits: 1, Misses: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &countingSolver{model: Model{"x": symbolic.NewIntConstant(5), "y": symbolic.NewIntConstant(1)}}
+			cache := NewCache(s)
+			var res Result
+			for _, query := range tt.queries {
+				var err error
+				if res, _, err = cache.Check(query); err != nil {
+					t.Fatalf("Check: %v", err)
+				}
+			}
+			if res != tt.want {
+				t.Errorf("last answer = %s, want %s", res, tt.want)
+			}
+			if cache.Stats != tt.stats {
+				t.Errorf("stats = %+v, want %+v", cache.Stats, tt.stats)
+			}
+			if s.checks != tt.stats.Misses {
+				t.Errorf("solver checked %d queries, want %d", s.checks, tt.stats.Misses)
+			}
+		})
+	}
+}
+
+func TestCacheEntriesAreBounded(t *testing.T) {
+	x := intVar("x")
+	// Модели нет, поэтому каждый новый запрос доходит до решателя
+	cache := NewCache(&countingSolver{})
+	query := func(i int) []symbolic.SymbolicExpression {
+		return []symbolic.SymbolicExpression{compare(x, int64(i), symbolic.EQ)}
+	}
+	for i := 0; i < maxCacheEntries; i++ {
+		cache.Check(query(i))
+	}
+	// После заполнения буфер ключей обходит круг, не перевыделяясь
+	buffer := cap(cache.order)
+	last := 2 * maxCacheEntries
+	for i := maxCacheEntries; i <= last; i++ {
+		cache.Check(query(i))
+	}
+	if len(cache.entries) != maxCacheEntries || len(cache.order) != maxCacheEntries || cap(cache.order) != buffer {
+		t.Fatalf("cache keeps %d entries in a buffer of %d, want %d in %d", len(cache.entries), cap(cache.order), maxCacheEntries, buffer)
+	}
+	keys := cache.keys()
+	if first := query(last - maxCacheEntries + 1)[0].String(); keys[0] != first || keys[len(keys)-1] != query(last)[0].String() {
+		t.Errorf("keys run from %s to %s, want from %s", keys[0], keys[len(keys)-1], first)
+	}
+
+	// Самая старая запись и её ядро вытеснены, поэтому запрос снова доходит
+	// до решателя, последняя осталась в кэше
+	cache.Stats = CacheStats{}
+	cache.Check(query(last))
+	cache.Check(query(0))
+	if want := (CacheStats{Hits: 1, Misses: 1}); cache.Stats != want {
+		t.Errorf("stats = %+v, want %+v", cache.Stats, want)
+	}
+}
+
+func TestCacheSaveLoad(t *testing.T) {
+	x := intVar("x")
+	s := &countingSolver{model: Model{"x": symbolic.NewIntConstant(5)}}
+	cache := NewCache(s)
+	for _, query := range [][]symbolic.SymbolicExpression{
+		{compare(x, 0, symbolic.GT)},
+		{compare(x, 0, symbolic.LT)},
+		{compare(x, 1, symbolic.GT)},
+	} {
+		cache.Check(query)
+	}
+
+	enc := symbolic.NewEncoder()
+	state := cache.Save(enc)
+	dec, err := symbolic.NewDecoder(enc.Nodes)
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	loaded := NewCache(s)
+	if err := loaded.Load(state, dec); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if fmt.Sprint(loaded.keys()) != fmt.Sprint(cache.keys()) {
+		t.Errorf("keys = %q, want %q", loaded.keys(), cache.keys())
+	}
+	if loaded.Stats != cache.Stats || len(loaded.models) != len(cache.models) || len(loaded.cores) != len(cache.cores) {
+		t.Errorf("loaded %+v with %d models and %d cores, want %+v with %d and %d",
+			loaded.Stats, len(loaded.models), len(loaded.cores), cache.Stats, len(cache.models), len(cache.cores))
+	}
+	res, model, _ := loaded.Check([]symbolic.SymbolicExpression{compare(x, 0, symbolic.GT)})
+	if res != Sat || model.String() != "x = 5" || loaded.Stats.Hits != cache.Stats.Hits+1 {
+		t.Errorf("Check after Load = %s with %s, stats %+v; want a cached sat with x = 5", res, model, loaded.Stats)
+	}
+}
@@ -0,0 +1,249 @@
+package solver
+
+import (
+	"symbolic-execution-course/internal/symbolic"
+)
+
+// Evaluate вычисляет выражение на конкретных значениях переменных из модели.
+// Возвращает константу и true, либо nil и false, если выражение нельзя
+// вычислить (нет значения переменной, массивы, поля, деление на ноль...).
+// Семантика целочисленных div/mod совпадает с SMT-LIB2, а не с Go.
+func Evaluate(expr symbolic.SymbolicExpression, model Model) (symbolic.SymbolicExpression, bool) {
+	if expr == nil {
+		return nil, false
+	}
+	res, _ := expr.Accept(&evaluator{model: model}).(symbolic.SymbolicExpression)
+	return res, res != nil
+}
+
+// Satisfies проверяет, что модель делает истинными все ограничения
+func Satisfies(model Model, constraints []symbolic.SymbolicExpression) bool {
+	for _, c := range constraints {
+		value, ok := Evaluate(c, model)
+		if !ok {
+			return false
+		}
+		b, ok := value.(*symbolic.BoolConstant)
+		if !ok || !b.Value {
+			return false
+		}
+	}
+	return true
+}
+
+type evaluator struct {
+	model Model
+}
+
+func (ev *evaluator) eval(expr symbolic.SymbolicExpression) symbolic.SymbolicExpression {
+	if expr == nil {
+		return nil
+	}
+	res, _ := expr.Accept(ev).(symbolic.SymbolicExpression)
+	return res
+}
+
+func (ev *evaluator) VisitVariable(expr *symbolic.SymbolicVariable) interface{} {
+	if value, ok := ev.model[expr.Name]; ok {
+		return value
+	}
+	return nil
+}
+
+func (ev *evaluator) VisitIntConstant(expr *symbolic.IntConstant) interface{} { return expr }
+
+func (ev *evaluator) VisitFloatConstant(expr *symbolic.FloatConstant) interface{} { return expr }
+
+func (ev *evaluator) VisitBoolConstant(expr *symbolic.BoolConstant) interface{} { return expr }
+
+func (ev *evaluator) VisitBinaryOperation(expr *symbolic.BinaryOperation) interface{} {
+	left := ev.eval(expr.Left)
+	right := ev.eval(expr.Right)
+	if left == nil || right == nil {
+		return nil
+	}
+
+	switch l := left.(type) {
+	case *symbolic.IntConstant:
+		r, ok := right.(*symbolic.IntConstant)
+		if !ok {
+			return nil
+		}
+		return evalInt(l.Value, r.Value, expr.Operator)
+	case *symbolic.FloatConstant:
+		r, ok := right.(*symbolic.FloatConstant)
+		if !ok {
+			return nil
+		}
+		return evalFloat(l.Value, r.Value, expr.Operator)
+	case *symbolic.BoolConstant:
+		r, ok := right.(*symbolic.BoolConstant)
+		if !ok {
+			return nil
+		}
+		switch expr.Operator {
+		case symbolic.EQ:
+			return symbolic.NewBoolConstant(l.Value == r.Value)
+		case symbolic.NE:
+			return symbolic.NewBoolConstant(l.Value != r.Value)
+		}
+	}
+	return nil
+}
+
+func evalInt(l, r int64, op symbolic.BinaryOperator) interface{} {
+	switch op {
+	case symbolic.ADD:
+		return symbolic.NewIntConstant(l + r)
+	case symbolic.SUB:
+		return symbolic.NewIntConstant(l - r)
+	case symbolic.MUL:
+		return symbolic.NewIntConstant(l * r)
+	case symbolic.DIV, symbolic.MOD:
+		if r == 0 {
+			// В SMT-LIB2 результат не определён, значит модель ничего не гарантирует
+			return nil
+		}
+		// Как и в Go, частное округляется к нулю, остаток имеет знак делимого
+		if op == symbolic.DIV {
+			return symbolic.NewIntConstant(l / r)
+		}
+		return symbolic.NewIntConstant(l % r)
+	case symbolic.EQ:
+		return symbolic.NewBoolConstant(l == r)
+	case symbolic.NE:
+		return symbolic.NewBoolConstant(l != r)
+	case symbolic.LT:
+		return symbolic.NewBoolConstant(l < r)
+	case symbolic.LE:
+		return symbolic.NewBoolConstant(l <= r)
+	case symbolic.GT:
+		return symbolic.NewBoolConstant(l > r)
+	case symbolic.GE:
+		return symbolic.NewBoolConstant(l >= r)
+	}
+	return nil
+}
+
+func evalFloat(l, r float32, op symbolic.BinaryOperator) interface{} {
+	switch op {
+	case symbolic.ADD:
+		return symbolic.NewFloatConstant(l + r)
+	case symbolic.SUB:
+		return symbolic.NewFloatConstant(l - r)
+	case symbolic.MUL:
+		return symbolic.NewFloatConstant(l * r)
+	case symbolic.DIV:
+		return symbolic.NewFloatConstant(l / r)
+	case symbolic.EQ:
+		return symbolic.NewBoolConstant(l == r)
+	case symbolic.NE:
+		return symbolic.NewBoolConstant(l != r)
+	case symbolic.LT:
+		return symbolic.NewBoolConstant(l < r)
+	case symbolic.LE:
+		return symbolic.NewBoolConstant(l <= r)
+	case symbolic.GT:
+		return symbolic.NewBoolConstant(l > r)
+	case symbolic.GE:
+		return symbolic.NewBoolConstant(l >= r)
+	}
+	return nil
+}
+
+func (ev *evaluator) VisitLogicalOperation(expr *symbolic.LogicalOperation) interface{} {
+	values := make([]bool, 0, len(expr.Operands))
+	for _, op := range expr.Operands {
+		b, ok := ev.eval(op).(*symbolic.BoolConstant)
+		if !ok {
+			return nil
+		}
+		values = append(values, b.Value)
+	}
+
+	switch expr.Operator {
+	case symbolic.AND:
+		for _, v := range values {
+			if !v {
+				return symbolic.NewBoolConstant(false)
+			}
+		}
+		return symbolic.NewBoolConstant(true)
+	case symbolic.OR:
+		for _, v := range values {
+			if v {
+				return symbolic.NewBoolConstant(true)
+			}
+		}
+		return symbolic.NewBoolConstant(false)
+	case symbolic.IMPLIES:
+		if len(values) != 2 {
+			return nil
+		}
+		return symbolic.NewBoolConstant(!values[0] || values[1])
+	}
+	return nil
+}
+
+func (ev *evaluator) VisitUnaryOperation(expr *symbolic.UnaryOperation) interface{} {
+	switch operand := ev.eval(expr.Operand).(type) {
+	case *symbolic.BoolConstant:
+		if expr.Operator == symbolic.NOT {
+			return symbolic.NewBoolConstant(!operand.Value)
+		}
+	case *symbolic.IntConstant:
+		switch expr.Operator {
+		case symbolic.MINUS:
+			return symbolic.NewIntConstant(-operand.Value)
+		case symbolic.INCREMENT:
+			return symbolic.NewIntConstant(operand.Value + 1)
+		case symbolic.DECREMENT:
+			return symbolic.NewIntConstant(operand.Value - 1)
+		}
+	case *symbolic.FloatConstant:
+		switch expr.Operator {
+		case symbolic.MINUS:
+			return symbolic.NewFloatConstant(-operand.Value)
+		case symbolic.INCREMENT:
+			return symbolic.NewFloatConstant(operand.Value + 1)
+		case symbolic.DECREMENT:
+			return symbolic.NewFloatConstant(operand.Value - 1)
+		}
+	}
+	return nil
+}
+
+func (ev *evaluator) VisitConditional(expr *symbolic.ConditionalOperation) interface{} {
+	if len(expr.TrueBlock) == 0 || len(expr.FalseBlock) == 0 {
+		return nil
+	}
+	cond, ok := ev.eval(expr.Condition).(*symbolic.BoolConstant)
+	if !ok {
+		return nil
+	}
+	if cond.Value {
+		return ev.eval(expr.TrueBlock[len(expr.TrueBlock)-1])
+	}
+	return ev.eval(expr.FalseBlock[len(expr.FalseBlock)-1])
+}
+
+func (ev *evaluator) VisitArray(expr *symbolic.SymbolicArray) interface{}       { return nil }
+func (ev *evaluator) VisitArrayAccess(expr *symbolic.ArrayAccess) interface{}   { return nil }
+func (ev *evaluator) VisitFieldAccess(expr *symbolic.FieldAccess) interface{}   { return nil }
+func (ev *evaluator) VisitFieldAssign(expr *symbolic.FieldAssign) interface{}   { return nil }
+func (ev *evaluator) VisitFunction(expr *symbolic.Function) interface{}         { return nil }
+func (ev *evaluator) VisitFunctionCall(expr *symbolic.FunctionCall) interface{} { return nil }
+func (ev *evaluator) VisitTuple(expr *symbolic.Tuple) interface{}               { return nil }
+
+// Адреса вычисляются так же, как их кодируют трансляторы
+func (ev *evaluator) VisitPointer(expr *symbolic.SymbolicPointer) interface{} {
+	return symbolic.NewIntConstant(int64(expr.Address))
+}
+
+func (ev *evaluator) VisitIndexAddr(expr *symbolic.IndexAddr) interface{} {
+	return symbolic.NewIntConstant(int64(int(expr.Ptr.Address)*1000 + expr.Index))
+}
+
+func (ev *evaluator) VisitFieldAddr(expr *symbolic.FieldAddr) interface{} {
+	return symbolic.NewIntConstant(int64(int(expr.Ptr.Address)*1000 + expr.FieldIndex))
+}
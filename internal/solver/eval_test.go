@@ -0,0 +1,39 @@
+package solver
+
+import (
+	"testing"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+func TestEvaluate(t *testing.T) {
+	model := Model{"x": symbolic.NewIntConstant(-7), "f": symbolic.NewFloatConstant(1.5)}
+	x, f := intVar("x"), symbolic.NewSymbolicVariable("f", symbolic.FloatType)
+
+	tests := []struct {
+		name string
+		expr symbolic.SymbolicExpression
+		want string
+	}{
+		// Деление и остаток, как в Go, а не евклидовы
+		{"div", symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(2), symbolic.DIV), "-3"},
+		{"mod", symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(2), symbolic.MOD), "-1"},
+		{"div by negative", symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(-2), symbolic.DIV), "3"},
+		{"mod by negative", symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(-2), symbolic.MOD), "-1"},
+		{"float increment", symbolic.NewUnaryOperation(f, symbolic.INCREMENT), "2.500000"},
+		{"float decrement", symbolic.NewUnaryOperation(f, symbolic.DECREMENT), "0.500000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := Evaluate(tt.expr, model)
+			if !ok || value.String() != tt.want {
+				t.Errorf("Evaluate(%s) = %v, %v; want %s", tt.expr, value, ok, tt.want)
+			}
+		})
+	}
+
+	// Деление на ноль в SMT-LIB2 не определено, модель ничего не гарантирует
+	if value, ok := Evaluate(symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(0), symbolic.DIV), model); ok {
+		t.Errorf("Evaluate(x / 0) = %s, want no value", value)
+	}
+}
@@ -0,0 +1,106 @@
+package solver
+
+import (
+	"fmt"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+// TrackingSolver - решатель, умеющий помечать ограничения трекерами
+// и сообщать, какие из них вошли в ядро невыполнимости
+type TrackingSolver interface {
+	Solver
+
+	AssertAndTrack(constraint symbolic.SymbolicExpression, name string) error
+	UnsatCore() []string
+}
+
+type incrementalLevel struct {
+	constraint symbolic.SymbolicExpression
+	tracker    string
+}
+
+// Incremental держит в стеке решателя ограничения предыдущего запроса
+// и при следующем запросе снимает только несовпадающий хвост.
+// Ограничения сравниваются как объекты, поэтому общий префикс условий
+// пути соседних состояний (см. PathCondition) утверждается один раз.
+type Incremental struct {
+	TrackingSolver
+
+	levels  []incrementalLevel
+	counter int
+}
+
+// NewIncremental создаёт инкрементальную обёртку над решателем
+func NewIncremental(s TrackingSolver) *Incremental {
+	return &Incremental{TrackingSolver: s}
+}
+
+// Interrupt прерывает запрос, если его умеет прерывать обёрнутый решатель
+func (inc *Incremental) Interrupt() {
+	if interrupter, ok := inc.TrackingSolver.(Interrupter); ok {
+		interrupter.Interrupt()
+	}
+}
+
+// CheckWithCore проверяет конъюнкцию ограничений, переиспользуя
+// уже утверждённый префикс
+func (inc *Incremental) CheckWithCore(constraints []symbolic.SymbolicExpression) (Result, Model, []int, error) {
+	common := 0
+	for common < len(inc.levels) && common < len(constraints) && inc.levels[common].constraint == constraints[common] {
+		common++
+	}
+
+	for len(inc.levels) > common {
+		inc.TrackingSolver.Pop()
+		inc.levels = inc.levels[:len(inc.levels)-1]
+	}
+
+	for _, c := range constraints[common:] {
+		inc.counter++
+		name := fmt.Sprintf("$track_%d", inc.counter)
+
+		inc.TrackingSolver.Push()
+		if err := inc.TrackingSolver.AssertAndTrack(c, name); err != nil {
+			inc.TrackingSolver.Pop()
+			return Unknown, nil, nil, err
+		}
+		inc.levels = append(inc.levels, incrementalLevel{constraint: c, tracker: name})
+	}
+
+	trackers := make(map[string]int, len(constraints))
+	for i, level := range inc.levels {
+		trackers[level.tracker] = i
+	}
+
+	return checkTracked(inc.TrackingSolver, trackers)
+}
+
+// Push и Assert используются для обычных, неинкрементальных запросов,
+// поэтому сначала снимают удерживаемые ограничения
+func (inc *Incremental) Push() {
+	inc.release()
+	inc.TrackingSolver.Push()
+}
+
+func (inc *Incremental) Assert(constraint symbolic.SymbolicExpression) error {
+	inc.release()
+	return inc.TrackingSolver.Assert(constraint)
+}
+
+func (inc *Incremental) release() {
+	for range inc.levels {
+		inc.TrackingSolver.Pop()
+	}
+	inc.levels = nil
+}
+
+// Depth возвращает число ограничений, удерживаемых в стеке решателя
+func (inc *Incremental) Depth() int {
+	return len(inc.levels)
+}
+
+func (inc *Incremental) Reset() {
+	inc.TrackingSolver.Reset()
+	inc.levels = nil
+}
@@ -0,0 +1,67 @@
+package solver
+
+import (
+	"fmt"
+	"testing"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+func TestIncremental(t *testing.T) {
+	x, y := intVar("x"), intVar("y")
+	xPositive := compare(x, 0, symbolic.GT)
+	yPositive := compare(y, 0, symbolic.GT)
+	xSmall := compare(x, 5, symbolic.LT)
+	xNegative := compare(x, 0, symbolic.LT)
+
+	inc := NewIncremental(NewZ3Solver())
+	// Запросы идут подряд на одном решателе, как соседние состояния
+	tests := []struct {
+		name        string
+		constraints []symbolic.SymbolicExpression
+		want        Result
+		core        []int
+		// kept - сколько ограничений осталось в стеке от предыдущего запроса
+		kept int
+	}{
+		{"first", []symbolic.SymbolicExpression{xPositive, yPositive}, Sat, nil, 0},
+		{"extends the prefix", []symbolic.SymbolicExpression{xPositive, yPositive, xSmall}, Sat, nil, 2},
+		{"replaces the tail", []symbolic.SymbolicExpression{xPositive, yPositive, xNegative}, Unsat, []int{0, 2}, 2},
+		{"shorter", []symbolic.SymbolicExpression{xPositive}, Sat, nil, 1},
+		{"different first", []symbolic.SymbolicExpression{xNegative, xSmall}, Sat, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept := 0
+			for kept < inc.Depth() && kept < len(tt.constraints) && inc.levels[kept].constraint == tt.constraints[kept] {
+				kept++
+			}
+			if kept != tt.kept {
+				t.Errorf("kept %d constraints, want %d", kept, tt.kept)
+			}
+
+			res, model, core, err := inc.CheckWithCore(tt.constraints)
+			if err != nil {
+				t.Fatalf("CheckWithCore: %v", err)
+			}
+			if res != tt.want {
+				t.Fatalf("result = %s, want %s", res, tt.want)
+			}
+			if inc.Depth() != len(tt.constraints) {
+				t.Errorf("depth = %d, want %d", inc.Depth(), len(tt.constraints))
+			}
+			if res == Sat && !Satisfies(model, tt.constraints) {
+				t.Errorf("model %s does not satisfy %s", model, joinExprs(tt.constraints))
+			}
+			if res == Unsat && fmt.Sprint(core) != fmt.Sprint(tt.core) {
+				t.Errorf("core = %v, want %v", core, tt.core)
+			}
+		})
+	}
+
+	// Обычный запрос снимает удерживаемые ограничения
+	res, _, err := CheckConstraints(inc, []symbolic.SymbolicExpression{xPositive})
+	if err != nil || res != Sat || inc.Depth() != 0 {
+		t.Errorf("CheckConstraints = %s, %v with depth %d; want sat with depth 0", res, err, inc.Depth())
+	}
+}
@@ -0,0 +1,185 @@
+package solver
+
+import (
+	"fmt"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+// IndependentSlice оставляет из ограничений только группы, делящие
+// свободные символы с условием focus, и добавляет к ним само focus.
+//
+// Группы, не касающиеся focus, на его выполнимость не влияют, если сами
+// ограничения выполнимы (а условие пути выполнимо по построению).
+// Ограничения без свободных символов сохраняются всегда.
+func IndependentSlice(constraints []symbolic.SymbolicExpression, focus symbolic.SymbolicExpression) []symbolic.SymbolicExpression {
+	focusConjuncts := Conjuncts(focus)
+	all := append(Conjuncts(constraints...), focusConjuncts...)
+	isFocus := make(map[symbolic.SymbolicExpression]bool, len(focusConjuncts))
+	for _, c := range focusConjuncts {
+		isFocus[c] = true
+	}
+
+	var res []symbolic.SymbolicExpression
+	for _, group := range IndependentGroups(all) {
+		touches := false
+		for _, c := range group {
+			if isFocus[c] {
+				touches = true
+				break
+			}
+		}
+
+		for _, c := range group {
+			if isFocus[c] {
+				continue
+			}
+			if touches || len(FreeSymbols(c)) == 0 {
+				res = append(res, c)
+			}
+		}
+	}
+	return append(res, focusConjuncts...)
+}
+
+// IndependentGroups разбивает ограничения на группы с непересекающимися
+// множествами свободных символов. Порядок групп и ограничений внутри них
+// соответствует первому появлению.
+func IndependentGroups(constraints []symbolic.SymbolicExpression) [][]symbolic.SymbolicExpression {
+	conjuncts := Conjuncts(constraints...)
+
+	parent := make([]int, len(conjuncts))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	owner := make(map[string]int)
+	for i, c := range conjuncts {
+		for name := range FreeSymbols(c) {
+			if j, ok := owner[name]; ok {
+				ri, rj := find(i), find(j)
+				if ri < rj {
+					parent[rj] = ri
+				} else if rj < ri {
+					parent[ri] = rj
+				}
+			} else {
+				owner[name] = i
+			}
+		}
+	}
+
+	index := make(map[int]int)
+	var groups [][]symbolic.SymbolicExpression
+	for i, c := range conjuncts {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], c)
+	}
+	return groups
+}
+
+// FreeSymbols собирает имена свободных символов выражения: переменных,
+// массивов, массивов полей структур и неинтерпретируемых функций
+func FreeSymbols(expr symbolic.SymbolicExpression) map[string]bool {
+	collector := &symbolCollector{symbols: make(map[string]bool)}
+	if expr != nil {
+		expr.Accept(collector)
+	}
+	return collector.symbols
+}
+
+type symbolCollector struct {
+	symbols map[string]bool
+}
+
+func (sc *symbolCollector) visit(exprs ...symbolic.SymbolicExpression) interface{} {
+	for _, e := range exprs {
+		if e != nil {
+			e.Accept(sc)
+		}
+	}
+	return nil
+}
+
+func (sc *symbolCollector) VisitVariable(expr *symbolic.SymbolicVariable) interface{} {
+	sc.symbols[expr.Name] = true
+	return nil
+}
+
+func (sc *symbolCollector) VisitIntConstant(expr *symbolic.IntConstant) interface{}     { return nil }
+func (sc *symbolCollector) VisitFloatConstant(expr *symbolic.FloatConstant) interface{} { return nil }
+func (sc *symbolCollector) VisitBoolConstant(expr *symbolic.BoolConstant) interface{}   { return nil }
+func (sc *symbolCollector) VisitPointer(expr *symbolic.SymbolicPointer) interface{}     { return nil }
+func (sc *symbolCollector) VisitIndexAddr(expr *symbolic.IndexAddr) interface{}         { return nil }
+func (sc *symbolCollector) VisitFieldAddr(expr *symbolic.FieldAddr) interface{}         { return nil }
+
+func (sc *symbolCollector) VisitBinaryOperation(expr *symbolic.BinaryOperation) interface{} {
+	return sc.visit(expr.Left, expr.Right)
+}
+
+func (sc *symbolCollector) VisitLogicalOperation(expr *symbolic.LogicalOperation) interface{} {
+	return sc.visit(expr.Operands...)
+}
+
+func (sc *symbolCollector) VisitUnaryOperation(expr *symbolic.UnaryOperation) interface{} {
+	return sc.visit(expr.Operand)
+}
+
+func (sc *symbolCollector) VisitArray(expr *symbolic.SymbolicArray) interface{} {
+	sc.symbols[expr.Name] = true
+	return nil
+}
+
+func (sc *symbolCollector) VisitArrayAccess(expr *symbolic.ArrayAccess) interface{} {
+	sc.symbols[expr.Array.Name] = true
+	return sc.visit(expr.Index)
+}
+
+func (sc *symbolCollector) VisitConditional(expr *symbolic.ConditionalOperation) interface{} {
+	sc.visit(expr.Condition)
+	sc.visit(expr.TrueBlock...)
+	return sc.visit(expr.FalseBlock...)
+}
+
+// Поля структур транслируются в общий массив на каждое поле,
+// поэтому все обращения к одному полю считаются зависимыми
+func (sc *symbolCollector) VisitFieldAccess(expr *symbolic.FieldAccess) interface{} {
+	sc.symbols[fieldSymbol(expr.StructName, expr.FieldIdx)] = true
+	return sc.visit(expr.Obj, expr.Key)
+}
+
+func (sc *symbolCollector) VisitFieldAssign(expr *symbolic.FieldAssign) interface{} {
+	sc.symbols[fieldSymbol(expr.StructName, expr.FieldIdx)] = true
+	return sc.visit(expr.Obj, expr.Value)
+}
+
+func (sc *symbolCollector) VisitFunction(expr *symbolic.Function) interface{} {
+	sc.symbols["func "+expr.Name] = true
+	return nil
+}
+
+func (sc *symbolCollector) VisitFunctionCall(expr *symbolic.FunctionCall) interface{} {
+	sc.symbols["func "+expr.FunctionDecl.Name] = true
+	return sc.visit(expr.Args...)
+}
+
+func (sc *symbolCollector) VisitTuple(expr *symbolic.Tuple) interface{} {
+	return sc.visit(expr.Elements...)
+}
+
+func fieldSymbol(structName string, fieldIdx int) string {
+	return fmt.Sprintf("field %s.%d", structName, fieldIdx)
+}
@@ -0,0 +1,132 @@
+package solver
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+// joinExprs записывает список выражений одной строкой для сравнения
+func joinExprs(exprs []symbolic.SymbolicExpression) string {
+	parts := make([]string, len(exprs))
+	for i, expr := range exprs {
+		parts[i] = expr.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+func TestIndependentGroups(t *testing.T) {
+	x, y, z := intVar("x"), intVar("y"), intVar("z")
+	xPositive := compare(x, 0, symbolic.GT)
+	yPositive := compare(y, 0, symbolic.GT)
+	zPositive := compare(z, 0, symbolic.GT)
+	xLessY := symbolic.NewBinaryOperation(x, y, symbolic.LT)
+	falseConst := symbolic.NewBoolConstant(false)
+
+	tests := []struct {
+		name        string
+		constraints []symbolic.SymbolicExpression
+		groups      [][]symbolic.SymbolicExpression
+	}{
+		{
+			name:        "disjoint",
+			constraints: []symbolic.SymbolicExpression{xPositive, yPositive},
+			groups:      [][]symbolic.SymbolicExpression{{xPositive}, {yPositive}},
+		},
+		{
+			name:        "shared symbol",
+			constraints: []symbolic.SymbolicExpression{xPositive, zPositive, xLessY},
+			groups:      [][]symbolic.SymbolicExpression{{xPositive, xLessY}, {zPositive}},
+		},
+		{
+			name:        "joined by a later constraint",
+			constraints: []symbolic.SymbolicExpression{yPositive, xPositive, xLessY},
+			groups:      [][]symbolic.SymbolicExpression{{yPositive, xPositive, xLessY}},
+		},
+		{
+			name: "conjunction is flattened",
+			constraints: []symbolic.SymbolicExpression{
+				symbolic.NewLogicalOperation([]symbolic.SymbolicExpression{xPositive, yPositive}, symbolic.AND),
+			},
+			groups: [][]symbolic.SymbolicExpression{{xPositive}, {yPositive}},
+		},
+		{
+			name:        "constant on its own",
+			constraints: []symbolic.SymbolicExpression{falseConst, xPositive},
+			groups:      [][]symbolic.SymbolicExpression{{falseConst}, {xPositive}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := IndependentGroups(tt.constraints)
+			if len(groups) != len(tt.groups) {
+				t.Fatalf("got %d groups, want %d", len(groups), len(tt.groups))
+			}
+			for i := range groups {
+				if got, want := joinExprs(groups[i]), joinExprs(tt.groups[i]); got != want {
+					t.Errorf("group %d = %s, want %s", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestIndependentSlice(t *testing.T) {
+	x, y, z := intVar("x"), intVar("y"), intVar("z")
+	xPositive := compare(x, 0, symbolic.GT)
+	yPositive := compare(y, 0, symbolic.GT)
+	yLessZ := symbolic.NewBinaryOperation(y, z, symbolic.LT)
+	zLessX := symbolic.NewBinaryOperation(z, x, symbolic.LT)
+	falseConst := symbolic.NewBoolConstant(false)
+	focus := compare(x, 5, symbolic.LT)
+
+	tests := []struct {
+		name        string
+		constraints []symbolic.SymbolicExpression
+		want        []symbolic.SymbolicExpression
+	}{
+		{"unrelated dropped", []symbolic.SymbolicExpression{xPositive, yPositive}, []symbolic.SymbolicExpression{xPositive, focus}},
+		{"related through another symbol", []symbolic.SymbolicExpression{yPositive, yLessZ, zLessX}, []symbolic.SymbolicExpression{yPositive, yLessZ, zLessX, focus}},
+		{"constants kept", []symbolic.SymbolicExpression{falseConst, yPositive}, []symbolic.SymbolicExpression{falseConst, focus}},
+		{"empty path condition", nil, []symbolic.SymbolicExpression{focus}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := joinExprs(IndependentSlice(tt.constraints, focus)), joinExprs(tt.want); got != want {
+				t.Errorf("slice = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestFreeSymbols(t *testing.T) {
+	x := intVar("x")
+	array := symbolic.NewSymbolicArray("a", symbolic.IntType, 4)
+	function := symbolic.NewFunction("f", []symbolic.ExpressionType{symbolic.IntType}, symbolic.IntType)
+
+	tests := []struct {
+		name string
+		expr symbolic.SymbolicExpression
+		want []string
+	}{
+		{"constant", symbolic.NewIntConstant(1), nil},
+		{"variable", compare(x, 0, symbolic.GT), []string{"x"}},
+		{"array access", symbolic.NewArrayAccess(*array, x), []string{"a", "x"}},
+		{"field access", symbolic.NewFieldAccess(symbolic.NewIntConstant(0), 1, x, "Point", symbolic.IntType), []string{"field Point.1", "x"}},
+		{"function call", symbolic.NewFunctionCall(*function, []symbolic.SymbolicExpression{x}), []string{"func f", "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for symbol := range FreeSymbols(tt.expr) {
+				got = append(got, symbol)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("symbols = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
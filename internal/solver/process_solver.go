@@ -0,0 +1,509 @@
+package solver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"symbolic-execution-course/internal/symbolic"
+	"symbolic-execution-course/internal/translator"
+)
+
+// ProcessSolver реализует Solver через внешний решатель (z3, cvc5, yices),
+// общаясь с ним текстом SMT-LIB2 через stdin/stdout. Не требует cgo.
+type ProcessSolver struct {
+	Translator *translator.SMTLibTranslator
+	Logic      string
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	scopes []int // Количество объявлений на момент каждого Push
+	// err - первая ошибка Push, Pop или Reset, которые не возвращают ошибок;
+	// её возвращают следующие Assert, Check и Model
+	err error
+}
+
+// ProcessCommand возвращает командную строку для известного решателя,
+// запускающую его в инкрементальном режиме SMT-LIB2 на stdin
+func ProcessCommand(name string) (string, []string, error) {
+	switch name {
+	case "z3":
+		return "z3", []string{"-in", "-smt2"}, nil
+	case "cvc5":
+		return "cvc5", []string{"--lang=smt2", "--incremental", "--produce-models"}, nil
+	case "yices":
+		return "yices-smt2", []string{"--incremental"}, nil
+	default:
+		return "", nil, fmt.Errorf("unknown solver %q", name)
+	}
+}
+
+// ProcessTimeoutArg возвращает аргумент командной строки известного
+// решателя, ограничивающий время одного запроса
+func ProcessTimeoutArg(name string, timeout time.Duration) (string, error) {
+	switch name {
+	case "z3":
+		return fmt.Sprintf("-t:%d", timeout.Milliseconds()), nil
+	case "cvc5":
+		return fmt.Sprintf("--tlimit-per=%d", timeout.Milliseconds()), nil
+	default:
+		return "", fmt.Errorf("solver %q does not support query timeouts", name)
+	}
+}
+
+// ProcessLogic возвращает логику SMT-LIB2 для известного решателя.
+// yices не принимает ALL и не поддерживает плавающую точку, поэтому ему
+// задаётся QF_AUFLIA: массивы, неинтерпретируемые функции и линейная
+// целочисленная арифметика. Запросы вне неё решатель отклоняет ошибкой.
+func ProcessLogic(name string) string {
+	if name == "yices" {
+		return "QF_AUFLIA"
+	}
+	return "ALL"
+}
+
+// NewProcessSolverByName запускает известный решатель по имени (z3, cvc5, yices)
+func NewProcessSolverByName(name string) (*ProcessSolver, error) {
+	path, args, err := ProcessCommand(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewProcessSolverWithLogic(ProcessLogic(name), path, args...)
+}
+
+// NewProcessSolver запускает решатель как дочерний процесс с логикой ALL
+func NewProcessSolver(path string, args ...string) (*ProcessSolver, error) {
+	return NewProcessSolverWithLogic("ALL", path, args...)
+}
+
+// NewProcessSolverWithLogic запускает решатель как дочерний процесс с заданной логикой
+func NewProcessSolverWithLogic(logic string, path string, args ...string) (*ProcessSolver, error) {
+	cmd := exec.Command(path, args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start solver %s: %w", path, err)
+	}
+
+	s := &ProcessSolver{
+		Translator: translator.NewSMTLibTranslator(),
+		Logic:      logic,
+		cmd:        cmd,
+		stdin:      stdin,
+		stdout:     bufio.NewReader(stdout),
+	}
+
+	if err := s.setup(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// setup включает подтверждения команд и модели. После (set-option
+// :print-success true) решатель отвечает success на каждую команду.
+func (s *ProcessSolver) setup() error {
+	return s.command("(set-option :print-success true)", "(set-option :produce-models true)", "(set-logic "+s.Logic+")")
+}
+
+// send пишет команду решателю, не дожидаясь ответа
+func (s *ProcessSolver) send(command string) error {
+	if _, err := io.WriteString(s.stdin, command+"\n"); err != nil {
+		return fmt.Errorf("failed to write to solver: %w", err)
+	}
+	return nil
+}
+
+// command исполняет команды по одной и проверяет, что на каждую решатель
+// ответил success
+func (s *ProcessSolver) command(commands ...string) error {
+	for _, command := range commands {
+		if err := s.send(command); err != nil {
+			return err
+		}
+		answer, err := s.readAnswer()
+		if err != nil {
+			return err
+		}
+		if answer != "success" {
+			return fmt.Errorf("unexpected solver answer to %s: %s", command, answer)
+		}
+	}
+	return nil
+}
+
+// query пишет команду и возвращает её ответ
+func (s *ProcessSolver) query(command string) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	if err := s.send(command); err != nil {
+		return "", err
+	}
+	return s.readAnswer()
+}
+
+// readAnswer читает ответ на одну команду, превращая (error "...") в ошибку
+func (s *ProcessSolver) readAnswer() (string, error) {
+	answer, err := s.readSExpr()
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(answer, "(error") {
+		message := answer
+		if parsed, err := parseSExpr(answer); err == nil {
+			if list, ok := parsed.([]interface{}); ok && len(list) == 2 {
+				if text, ok := list[1].(string); ok {
+					message = strings.Trim(text, `"`)
+				}
+			}
+		}
+		return "", fmt.Errorf("solver error: %s", message)
+	}
+	return answer, nil
+}
+
+// fail запоминает первую ошибку команды, которая не может её вернуть
+func (s *ProcessSolver) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *ProcessSolver) Assert(constraint symbolic.SymbolicExpression) error {
+	if s.err != nil {
+		return s.err
+	}
+	term, err := s.Translator.TranslateExpression(constraint)
+	if err != nil {
+		return err
+	}
+
+	commands := s.Translator.TakeNewDeclarations()
+	commands = append(commands, "(assert "+term.(string)+")")
+	return s.command(commands...)
+}
+
+func (s *ProcessSolver) Push() {
+	s.scopes = append(s.scopes, s.Translator.DeclarationsCount())
+	if err := s.command("(push 1)"); err != nil {
+		s.fail(err)
+	}
+}
+
+// Pop откатывает и объявления: после (pop) решатель их забывает,
+// поэтому транслятор должен выдать их заново при следующем использовании
+func (s *ProcessSolver) Pop() {
+	if len(s.scopes) == 0 {
+		return
+	}
+	count := s.scopes[len(s.scopes)-1]
+	s.scopes = s.scopes[:len(s.scopes)-1]
+	s.Translator.ForgetDeclarations(count)
+	if err := s.command("(pop 1)"); err != nil {
+		s.fail(err)
+	}
+}
+
+func (s *ProcessSolver) Check() (Result, error) {
+	answer, err := s.query("(check-sat)")
+	if err != nil {
+		return Unknown, err
+	}
+
+	switch answer {
+	case "sat":
+		return Sat, nil
+	case "unsat":
+		return Unsat, nil
+	case "unknown":
+		return Unknown, nil
+	default:
+		return Unknown, fmt.Errorf("unexpected solver answer: %s", answer)
+	}
+}
+
+// Model запрашивает значения всех объявленных констант через (get-value)
+func (s *ProcessSolver) Model() (Model, error) {
+	consts := s.Translator.Constants()
+	res := make(Model)
+	if len(consts) == 0 {
+		return res, nil
+	}
+
+	symbols := make([]string, 0, len(consts))
+	for symbol := range consts {
+		symbols = append(symbols, symbol)
+	}
+
+	answer, err := s.query("(get-value (" + strings.Join(symbols, " ") + "))")
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := parseSExpr(answer)
+	if err != nil {
+		return nil, err
+	}
+	pairs, ok := parsed.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected get-value answer: %s", answer)
+	}
+
+	for _, pair := range pairs {
+		kv, ok := pair.([]interface{})
+		if !ok || len(kv) != 2 {
+			continue
+		}
+		symbol, ok := kv[0].(string)
+		if !ok {
+			continue
+		}
+		variable, ok := consts[symbol]
+		if !ok {
+			continue
+		}
+		if value := parseValue(kv[1], variable.ExprType); value != nil {
+			res[variable.Name] = value
+		}
+	}
+
+	return res, nil
+}
+
+// Reset сбрасывает и запомненную ошибку, если решатель снова отвечает.
+// (reset) возвращает настройки по умолчанию, поэтому они задаются заново.
+func (s *ProcessSolver) Reset() {
+	s.scopes = nil
+	s.Translator.Reset()
+	s.err = nil
+	if err := s.command("(reset)"); err != nil {
+		s.fail(err)
+		return
+	}
+	if err := s.setup(); err != nil {
+		s.fail(err)
+	}
+}
+
+func (s *ProcessSolver) Close() error {
+	s.send("(exit)")
+	s.stdin.Close()
+	return s.cmd.Wait()
+}
+
+// readSExpr читает из stdout решателя один атом или одно сбалансированное s-выражение
+func (s *ProcessSolver) readSExpr() (string, error) {
+	var sb strings.Builder
+	depth := 0
+	inQuote := byte(0)
+
+	for {
+		c, err := s.stdout.ReadByte()
+		if err != nil {
+			return "", fmt.Errorf("failed to read from solver: %w", err)
+		}
+
+		switch {
+		case inQuote != 0:
+			if c == inQuote {
+				inQuote = 0
+			}
+		case c == '"' || c == '|':
+			inQuote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == '\n' || c == '\r' || c == ' ' || c == '\t':
+			if depth == 0 {
+				if sb.Len() == 0 {
+					continue
+				}
+				return sb.String(), nil
+			}
+		}
+
+		sb.WriteByte(c)
+		if depth == 0 && c == ')' {
+			return sb.String(), nil
+		}
+	}
+}
+
+// parseSExpr разбирает s-выражение в дерево из string и []interface{}
+func parseSExpr(text string) (interface{}, error) {
+	tokens := tokenizeSExpr(text)
+	pos := 0
+
+	var parse func() (interface{}, error)
+	parse = func() (interface{}, error) {
+		if pos >= len(tokens) {
+			return nil, fmt.Errorf("unexpected end of s-expression: %s", text)
+		}
+		token := tokens[pos]
+		pos++
+
+		if token != "(" {
+			return token, nil
+		}
+
+		list := []interface{}{}
+		for {
+			if pos >= len(tokens) {
+				return nil, fmt.Errorf("unbalanced s-expression: %s", text)
+			}
+			if tokens[pos] == ")" {
+				pos++
+				return list, nil
+			}
+			item, err := parse()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+	}
+
+	return parse()
+}
+
+func tokenizeSExpr(text string) []string {
+	var tokens []string
+	var current strings.Builder
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '|' || c == '"':
+			end := strings.IndexByte(text[i+1:], c)
+			if end < 0 {
+				end = len(text) - i - 1
+			}
+			current.WriteString(text[i : i+end+2])
+			i += end + 1
+		case c == '(' || c == ')':
+			flush()
+			tokens = append(tokens, string(c))
+		case c == ' ' || c == '\n' || c == '\t' || c == '\r':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// parseValue переводит значение из ответа (get-value) в символьную константу
+func parseValue(value interface{}, ty symbolic.ExpressionType) symbolic.SymbolicExpression {
+	switch ty {
+	case symbolic.IntType:
+		if i, ok := parseIntValue(value); ok {
+			return symbolic.NewIntConstant(i)
+		}
+	case symbolic.BoolType:
+		if atom, ok := value.(string); ok && (atom == "true" || atom == "false") {
+			return symbolic.NewBoolConstant(atom == "true")
+		}
+	case symbolic.FloatType:
+		if f, ok := parseFloatValue(value); ok {
+			return symbolic.NewFloatConstant(f)
+		}
+	}
+	return nil
+}
+
+func parseIntValue(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		return i, err == nil
+	case []interface{}:
+		// (- 5)
+		if len(v) == 2 && v[0] == "-" {
+			if i, ok := parseIntValue(v[1]); ok {
+				return -i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func parseFloatValue(value interface{}) (float32, bool) {
+	list, ok := value.([]interface{})
+	if !ok || len(list) < 2 {
+		return 0, false
+	}
+
+	// (fp #b0 #b10000010 #b01000000000000000000000); z3 печатает
+	// части, длина которых делится на 4, в шестнадцатеричном виде: #x82
+	if list[0] == "fp" && len(list) == 4 {
+		var bits string
+		for _, part := range list[1:] {
+			atom, ok := part.(string)
+			if !ok {
+				return 0, false
+			}
+			switch {
+			case strings.HasPrefix(atom, "#b"):
+				bits += atom[2:]
+			case strings.HasPrefix(atom, "#x"):
+				for _, digit := range atom[2:] {
+					nibble, err := strconv.ParseUint(string(digit), 16, 4)
+					if err != nil {
+						return 0, false
+					}
+					bits += fmt.Sprintf("%04b", nibble)
+				}
+			default:
+				return 0, false
+			}
+		}
+		raw, err := strconv.ParseUint(bits, 2, 32)
+		if err != nil {
+			return 0, false
+		}
+		return math.Float32frombits(uint32(raw)), true
+	}
+
+	// (_ +zero 8 24), (_ -oo 8 24), (_ NaN 8 24)
+	if list[0] == "_" {
+		switch list[1] {
+		case "+zero":
+			return 0, true
+		case "-zero":
+			return float32(math.Copysign(0, -1)), true
+		case "+oo":
+			return float32(math.Inf(1)), true
+		case "-oo":
+			return float32(math.Inf(-1)), true
+		case "NaN":
+			return float32(math.NaN()), true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,174 @@
+package solver
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+// TestHelperProcess - поддельный решатель SMT-LIB2 для тестов ProcessSolver.
+// Подтверждает команды только после (set-option :print-success true),
+// отвечает ошибкой на команды со словом из FAKE_SOLVER_FAIL и sat на (check-sat).
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	fail := os.Getenv("FAKE_SOLVER_FAIL")
+	printSuccess := false
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "(exit)":
+			os.Exit(0)
+		case fail != "" && strings.Contains(line, fail):
+			fmt.Printf("(error \"line 1: %s\")\n", fail)
+		case line == "(check-sat)":
+			fmt.Println("sat")
+		case strings.HasPrefix(line, "(get-value ("):
+			symbols := strings.Fields(strings.TrimSuffix(strings.TrimPrefix(line, "(get-value ("), "))"))
+			pairs := make([]string, len(symbols))
+			for i, symbol := range symbols {
+				pairs[i] = fmt.Sprintf("(%s (- 4))", symbol)
+			}
+			fmt.Printf("(%s)\n", strings.Join(pairs, "\n "))
+		default:
+			if line == "(set-option :print-success true)" {
+				printSuccess = true
+			}
+			if printSuccess {
+				fmt.Println("success")
+			}
+			if line == "(reset)" {
+				printSuccess = false
+			}
+		}
+	}
+	os.Exit(0)
+}
+
+func newFakeSolver(t *testing.T, fail string) *ProcessSolver {
+	t.Helper()
+	t.Setenv("GO_WANT_HELPER_PROCESS", "1")
+	t.Setenv("FAKE_SOLVER_FAIL", fail)
+	s, err := NewProcessSolver(os.Args[0], "-test.run=TestHelperProcess")
+	if err != nil {
+		t.Fatalf("NewProcessSolver: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestProcessSolverReplies(t *testing.T) {
+	x := symbolic.NewSymbolicVariable("x", symbolic.IntType)
+	negative := symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(0), symbolic.LT)
+
+	tests := []struct {
+		name string
+		// fail - слово, на команды с которым решатель отвечает ошибкой
+		fail      string
+		assertErr bool
+		checkErr  bool
+	}{
+		{"ok", "", false, false},
+		{"assert", "assert", true, false},
+		{"declaration", "declare-const", true, false},
+		// Ошибка Push возвращается следующими запросами
+		{"push", "push", true, true},
+		{"check", "check-sat", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeSolver(t, tt.fail)
+			s.Push()
+			if err := s.Assert(negative); (err != nil) != tt.assertErr {
+				t.Errorf("Assert error = %v, want error %v", err, tt.assertErr)
+			}
+			res, err := s.Check()
+			if (err != nil) != tt.checkErr {
+				t.Errorf("Check error = %v, want error %v", err, tt.checkErr)
+			}
+			if err == nil && res != Sat {
+				t.Errorf("Check = %s, want sat", res)
+			}
+		})
+	}
+}
+
+func TestProcessSolverPopError(t *testing.T) {
+	s := newFakeSolver(t, "pop")
+	s.Push()
+	s.Pop()
+	if _, err := s.Check(); err == nil || !strings.Contains(err.Error(), "pop") {
+		t.Errorf("Check error = %v, want the error of the failed pop", err)
+	}
+
+	// Reset заново настраивает решатель и забывает ошибку
+	s.Reset()
+	if res, err := s.Check(); err != nil || res != Sat {
+		t.Errorf("Check after Reset = %s, %v; want sat", res, err)
+	}
+}
+
+func TestProcessSolverModel(t *testing.T) {
+	s := newFakeSolver(t, "")
+	x := symbolic.NewSymbolicVariable("x", symbolic.IntType)
+	res, model, err := CheckConstraints(s, []symbolic.SymbolicExpression{
+		symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(0), symbolic.LT),
+	})
+	if err != nil || res != Sat {
+		t.Fatalf("CheckConstraints = %s, %v; want sat", res, err)
+	}
+	if value, ok := model["x"].(*symbolic.IntConstant); !ok || value.Value != -4 {
+		t.Errorf("model = %s, want x = -4", model)
+	}
+}
+
+func TestParseFloatValue(t *testing.T) {
+	// Значения, которые z3 печатает в модели для (_ FloatingPoint 8 24)
+	tests := []struct {
+		text string
+		want float32
+	}{
+		{"(fp #b0 #x82 #b01000000000000000000000)", 10},
+		{"(fp #b1 #x7c #b01000000000000000000000)", -0.15625},
+		{"(fp #b0 #xc3 #b00101111100100111001110)", 3.5e20},
+		{"(fp #b0 #b10000010 #b01000000000000000000000)", 10},
+		{"(_ -oo 8 24)", float32(math.Inf(-1))},
+	}
+	for _, tt := range tests {
+		value, err := parseSExpr(tt.text)
+		if err != nil {
+			t.Fatalf("parseSExpr(%s): %v", tt.text, err)
+		}
+		if got, ok := parseFloatValue(value); !ok || got != tt.want {
+			t.Errorf("parseFloatValue(%s) = %v, %v; want %v", tt.text, got, ok, tt.want)
+		}
+	}
+	if _, ok := parseFloatValue([]interface{}{"fp", "#b0", "#xg2", "#b0"}); ok {
+		t.Error("parseFloatValue accepted a malformed hexadecimal exponent")
+	}
+}
+
+func TestProcessSolverLogic(t *testing.T) {
+	// Поддельный решатель, как yices, не принимает логику ALL
+	t.Setenv("GO_WANT_HELPER_PROCESS", "1")
+	t.Setenv("FAKE_SOLVER_FAIL", "(set-logic ALL)")
+	if s, err := NewProcessSolver(os.Args[0], "-test.run=TestHelperProcess"); err == nil {
+		s.Close()
+		t.Error("NewProcessSolver accepted a solver that rejects (set-logic ALL)")
+	}
+	s, err := NewProcessSolverWithLogic(ProcessLogic("yices"), os.Args[0], "-test.run=TestHelperProcess")
+	if err != nil {
+		t.Fatalf("NewProcessSolverWithLogic: %v", err)
+	}
+	defer s.Close()
+	if res, err := s.Check(); err != nil || res != Sat {
+		t.Errorf("Check = %s, %v; want sat", res, err)
+	}
+}
@@ -0,0 +1,102 @@
+// Package solver описывает интерфейс SMT решателя, которым пользуется движок,
+// и его реализации: через привязку к Z3 и через внешний процесс SMT-LIB2
+package solver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+// Result - ответ решателя на запрос о выполнимости
+type Result int
+
+const (
+	Unknown Result = iota
+	Sat
+	Unsat
+)
+
+// String возвращает ответ в нотации SMT-LIB2
+func (r Result) String() string {
+	switch r {
+	case Sat:
+		return "sat"
+	case Unsat:
+		return "unsat"
+	default:
+		return "unknown"
+	}
+}
+
+// Model - конкретные значения свободных переменных из последнего sat ответа.
+// Значения представлены константами из пакета symbolic.
+type Model map[string]symbolic.SymbolicExpression
+
+// String возвращает модель в виде "x = 1, y = true" с сортировкой по имени
+func (m Model) String() string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s = %s", name, m[name].String()))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Solver - инкрементальный SMT решатель над символьными выражениями
+type Solver interface {
+	// Assert добавляет ограничение в текущий уровень стека
+	Assert(constraint symbolic.SymbolicExpression) error
+
+	// Push сохраняет текущий набор ограничений
+	Push()
+
+	// Pop откатывает ограничения до парного Push
+	Pop()
+
+	// Check проверяет выполнимость текущих ограничений
+	Check() (Result, error)
+
+	// Model возвращает модель после ответа Sat
+	Model() (Model, error)
+
+	// Reset удаляет все ограничения
+	Reset()
+
+	// Close освобождает ресурсы решателя
+	Close() error
+}
+
+// Interrupter - решатель, запрос к которому можно прервать из другой
+// горутины. Прерванный запрос возвращает Unknown.
+type Interrupter interface {
+	Interrupt()
+}
+
+// CheckConstraints проверяет конъюнкцию ограничений на отдельном уровне стека.
+// При ответе Sat дополнительно возвращает модель.
+func CheckConstraints(s Solver, constraints []symbolic.SymbolicExpression) (Result, Model, error) {
+	s.Push()
+	defer s.Pop()
+
+	for _, c := range constraints {
+		if err := s.Assert(c); err != nil {
+			return Unknown, nil, err
+		}
+	}
+
+	res, err := s.Check()
+	if err != nil || res != Sat {
+		return res, nil, err
+	}
+
+	model, err := s.Model()
+	return res, model, err
+}
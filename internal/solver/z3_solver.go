@@ -0,0 +1,207 @@
+package solver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"symbolic-execution-course/internal/symbolic"
+	"symbolic-execution-course/internal/translator"
+
+	"github.com/ebukreev/go-z3/z3"
+)
+
+// Z3Solver реализует Solver поверх привязки github.com/ebukreev/go-z3
+type Z3Solver struct {
+	Translator *translator.Z3Translator
+	solver     *z3.Solver
+}
+
+// NewZ3Solver создаёт решатель со своим Z3 контекстом
+func NewZ3Solver() *Z3Solver {
+	return NewZ3SolverWithTranslator(translator.NewZ3Translator())
+}
+
+// NewZ3SolverWithTranslator создаёт решатель в контексте уже существующего транслятора
+func NewZ3SolverWithTranslator(zt *translator.Z3Translator) *Z3Solver {
+	return &Z3Solver{
+		Translator: zt,
+		solver:     z3.NewSolver(zt.Ctx),
+	}
+}
+
+// SetTimeout ограничивает время одного запроса: не уложившийся запрос
+// возвращает unknown
+func (s *Z3Solver) SetTimeout(timeout time.Duration) {
+	s.Translator.Ctx.Config().SetUint("timeout", uint(timeout.Milliseconds()))
+}
+
+// Interrupt прерывает выполняемый сейчас запрос; безопасен
+// для вызова из другой горутины
+func (s *Z3Solver) Interrupt() {
+	s.Translator.Ctx.Interrupt()
+}
+
+// Assert транслирует ограничение в Z3 и добавляет его в решатель.
+// Ошибки трансляции (в том числе паники Z3) возвращаются как error.
+func (s *Z3Solver) Assert(constraint symbolic.SymbolicExpression) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = translator.NewTranslationError(fmt.Sprintf("z3: %v", r), constraint)
+		}
+	}()
+
+	value, err := s.Translator.TranslateExpression(constraint)
+	if err != nil {
+		return err
+	}
+
+	b, ok := value.(z3.Bool)
+	if !ok {
+		return translator.NewTranslationError(fmt.Sprintf("z3: constraint is %T, not a Bool", value), constraint)
+	}
+
+	s.solver.Assert(b)
+	return nil
+}
+
+// AssertAndTrack добавляет ограничение, помеченное литералом-трекером name.
+// Имена трекеров, вошедших в ядро невыполнимости, возвращает UnsatCore.
+func (s *Z3Solver) AssertAndTrack(constraint symbolic.SymbolicExpression, name string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = translator.NewTranslationError(fmt.Sprintf("z3: %v", r), constraint)
+		}
+	}()
+
+	value, err := s.Translator.TranslateExpression(constraint)
+	if err != nil {
+		return err
+	}
+
+	b, ok := value.(z3.Bool)
+	if !ok {
+		return translator.NewTranslationError(fmt.Sprintf("z3: constraint is %T, not a Bool", value), constraint)
+	}
+
+	s.solver.AssertAndTrack(b, s.Translator.Ctx.BoolConst(name))
+	return nil
+}
+
+// UnsatCore возвращает имена трекеров из ядра невыполнимости последней проверки
+func (s *Z3Solver) UnsatCore() []string {
+	var names []string
+	for _, tracker := range s.solver.GetUnsatCore() {
+		names = append(names, strings.Trim(tracker.String(), "|"))
+	}
+	return names
+}
+
+// CheckWithCore помечает каждое ограничение отдельным литералом-трекером,
+// чтобы при unsat восстановить, какие ограничения вошли в ядро
+func (s *Z3Solver) CheckWithCore(constraints []symbolic.SymbolicExpression) (Result, Model, []int, error) {
+	s.Push()
+	defer s.Pop()
+
+	trackers := make(map[string]int, len(constraints))
+	for i, c := range constraints {
+		name := fmt.Sprintf("$core_%d", i)
+		if err := s.AssertAndTrack(c, name); err != nil {
+			return Unknown, nil, nil, err
+		}
+		trackers[name] = i
+	}
+
+	return checkTracked(s, trackers)
+}
+
+// checkTracked проверяет выполнимость и переводит ядро в индексы ограничений
+func checkTracked(s TrackingSolver, trackers map[string]int) (Result, Model, []int, error) {
+	res, err := s.Check()
+	switch {
+	case err != nil:
+		return res, nil, nil, err
+	case res == Sat:
+		model, err := s.Model()
+		return res, model, nil, err
+	case res == Unsat:
+		var core []int
+		for _, name := range s.UnsatCore() {
+			if i, ok := trackers[name]; ok {
+				core = append(core, i)
+			}
+		}
+		sort.Ints(core)
+		return res, nil, core, nil
+	}
+	return res, nil, nil, nil
+}
+
+func (s *Z3Solver) Push() {
+	s.solver.Push()
+}
+
+func (s *Z3Solver) Pop() {
+	s.solver.Pop()
+}
+
+func (s *Z3Solver) Check() (res Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = Unknown, fmt.Errorf("z3: %v", r)
+		}
+	}()
+
+	sat, err := s.solver.Check()
+	if err != nil {
+		return Unknown, err
+	}
+	if sat {
+		return Sat, nil
+	}
+	return Unsat, nil
+}
+
+// Model вычисляет в модели Z3 все переменные, известные транслятору
+func (s *Z3Solver) Model() (Model, error) {
+	model := s.solver.Model()
+	if model == nil {
+		return nil, fmt.Errorf("z3: no model available")
+	}
+
+	res := make(Model)
+	for name, value := range s.Translator.Vars() {
+		evaluated := model.Eval(value, true)
+		if evaluated == nil {
+			continue
+		}
+
+		switch v := evaluated.(type) {
+		case z3.Int:
+			if i, isLiteral, ok := v.AsInt64(); isLiteral && ok {
+				res[name] = symbolic.NewIntConstant(i)
+			}
+		case z3.Bool:
+			if b, isLiteral := v.AsBool(); isLiteral {
+				res[name] = symbolic.NewBoolConstant(b)
+			}
+		case z3.Float:
+			if f, isLiteral := v.AsBigFloat(); isLiteral {
+				f32, _ := f.Float32()
+				res[name] = symbolic.NewFloatConstant(f32)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (s *Z3Solver) Reset() {
+	s.solver.Reset()
+}
+
+func (s *Z3Solver) Close() error {
+	s.Translator.Close()
+	return nil
+}
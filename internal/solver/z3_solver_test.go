@@ -0,0 +1,41 @@
+package solver
+
+import (
+	"testing"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+func intVar(name string) *symbolic.SymbolicVariable {
+	return symbolic.NewSymbolicVariable(name, symbolic.IntType)
+}
+
+func compare(left symbolic.SymbolicExpression, value int64, op symbolic.BinaryOperator) symbolic.SymbolicExpression {
+	return symbolic.NewBinaryOperation(left, symbolic.NewIntConstant(value), op)
+}
+
+func TestZ3GoDivision(t *testing.T) {
+	x := intVar("x")
+	// Частное округляется к нулю, остаток имеет знак делимого, как в Go
+	for _, tt := range []struct{ a, b int64 }{{7, 2}, {-7, 2}, {7, -2}, {-7, -2}, {-6, 3}} {
+		for _, op := range []symbolic.BinaryOperator{symbolic.DIV, symbolic.MOD} {
+			want := tt.a / tt.b
+			if op == symbolic.MOD {
+				want = tt.a % tt.b
+			}
+			result := symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(tt.b), op)
+			for _, c := range []struct {
+				op   symbolic.BinaryOperator
+				want Result
+			}{{symbolic.EQ, Sat}, {symbolic.NE, Unsat}} {
+				res, _, err := CheckConstraints(NewZ3Solver(), []symbolic.SymbolicExpression{
+					compare(x, tt.a, symbolic.EQ),
+					compare(result, want, c.op),
+				})
+				if err != nil || res != c.want {
+					t.Errorf("%d %s %d %s %d: %s, %v; want %s", tt.a, op, tt.b, c.op, want, res, err, c.want)
+				}
+			}
+		}
+	}
+}
@@ -2,18 +2,27 @@
 package ssabuilder
 
 import (
+	"context"
 	"fmt"
 	"go/ast"
 	"go/importer"
 	"go/parser"
+	"go/scanner"
 	"go/token"
 	"go/types"
-	"os"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
 
 	"golang.org/x/tools/go/ssa"
 	"golang.org/x/tools/go/ssa/ssautil"
 )
 
+// Logger - журнал построения SSA: ход построения и дамп SSA пишутся
+// на уровне Debug. По умолчанию всё отбрасывается.
+var Logger = slog.New(slog.DiscardHandler)
+
 // Builder отвечает за построение SSA из исходного кода Go
 type Builder struct {
 	fset *token.FileSet
@@ -31,30 +40,55 @@ func NewBuilder() *Builder {
 // ParseAndBuildSSA парсит исходный код Go и создаёт SSA представление
 // Возвращает SSA программу и функцию по имени
 func (b *Builder) ParseAndBuildSSA(source string, funcName string) (*ssa.Function, error) {
+	return b.ParseFilesAndBuildSSA(map[string]string{"main.go": source}, funcName)
+}
+
+// ParseFilesAndBuildSSA - как ParseAndBuildSSA, но для пакета из нескольких
+// файлов: ключ - имя файла, значение - его исходный код
+func (b *Builder) ParseFilesAndBuildSSA(sources map[string]string, funcName string) (*ssa.Function, error) {
 	fset := token.NewFileSet()
 
-	// 1. Парсинг исходного кода с помощью go/parser
-	fmt.Println("#=== 1. Парсинг исходного кода с помощью go/parser ===#")
-	file, err := parser.ParseFile(fset, "main.go", source, parser.ParseComments)
-	if err != nil {
-		fmt.Println("Ошибка при парсинге исходного кода:", err)
-		panic("parser error")
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
 	}
-	files := []*ast.File{file}
+	sort.Strings(names)
 
-	for _, node := range file.Decls {
-		if node, ok := node.(*ast.FuncDecl); ok {
-			fmt.Printf("  -- Найдена функция: %s\n", node.Name.Name)
+	// 1. Парсинг исходного кода с помощью go/parser
+	Logger.Debug("parsing sources", "files", names)
+	var files []*ast.File
+	var errs []string
+	for _, name := range names {
+		file, err := parser.ParseFile(fset, name, sources[name], parser.ParseComments|parser.AllErrors)
+		if err != nil {
+			Logger.Error("failed to parse source", "file", name, "err", err)
+			errs = append(errs, diagnostics(err)...)
+			continue
+		}
+		files = append(files, file)
+
+		for _, node := range file.Decls {
+			if node, ok := node.(*ast.FuncDecl); ok {
+				Logger.Debug("found function", "file", name, "function", node.Name.Name)
+			}
 		}
 	}
-	fmt.Println("#=== Парсинг завершен ===#")
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to parse sources:\n  %s", strings.Join(errs, "\n  "))
+	}
 
 	// 2. Создание SSA программы
-	fmt.Println("#=== 2. Создание SSA программы ===#")
 
 	// Create package of source
 	pkg := types.NewPackage("homework1/main.go", "main")
-	tconfig := &types.Config{Importer: importer.Default()}
+	// Проверка типов не останавливается на первой ошибке, чтобы сообщить все
+	tconfig := &types.Config{
+		Importer: importer.Default(),
+		Error: func(err error) {
+			errs = append(errs, err.Error())
+		},
+	}
 
 	// Build SSA
 	ssa_form, _, err := ssautil.BuildPackage(
@@ -62,26 +96,58 @@ func (b *Builder) ParseAndBuildSSA(source string, funcName string) (*ssa.Functio
 		fset,
 		pkg,
 		files,
-		ssa.SanityCheckFunctions,
+		ssa.SanityCheckFunctions|ssa.InstantiateGenerics,
 	)
+	if len(errs) > 0 {
+		Logger.Error("failed to type check package", "errors", len(errs))
+		return nil, fmt.Errorf("type errors in package:\n  %s", strings.Join(errs, "\n  "))
+	}
 	if err != nil {
-		panic("type error in package")
+		return nil, err
 	}
 
 	// Create SSA
 	ssa_form.Build()
 
-	// Print results
-	ssa_form.WriteTo(os.Stdout)
+	logSSA("package built", ssa_form)
 
 	// 3. Поиск нужной функции по имени
-	if fn_decl := ssa_form.Func(funcName); fn_decl != nil {
-		// Print out the package-level functions.
-		ssa_form.Func("init").WriteTo(os.Stdout)
-		ssa_form.Func(funcName).WriteTo(os.Stdout)
-		fmt.Println("#=== Создание SSA завершено ===#")
-		return fn_decl, nil
+	fn_decl, err := Lookup(ssa_form.Prog, ssa_form, funcName)
+	if err != nil {
+		return nil, err
 	}
+	logSSA("function found", ssa_form.Func("init"), fn_decl)
+	return fn_decl, nil
+}
 
-	return nil, fmt.Errorf("Function %s not found", funcName)
+// diagnostics разбивает ошибку разбора на сообщения с позициями
+func diagnostics(err error) []string {
+	list, ok := err.(scanner.ErrorList)
+	if !ok {
+		return []string{err.Error()}
+	}
+	res := make([]string, 0, len(list))
+	for _, e := range list {
+		res = append(res, e.Error())
+	}
+	return res
+}
+
+// ssaDump - пакет или функция SSA, которые умеют печатать себя
+type ssaDump interface {
+	String() string
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// logSSA пишет в журнал текст SSA; текст строится, только если уровень Debug включён
+func logSSA(msg string, members ...ssaDump) {
+	ctx := context.Background()
+	if !Logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	for _, member := range members {
+		var sb strings.Builder
+		member.WriteTo(&sb)
+		Logger.DebugContext(ctx, msg, "member", member.String(), "ssa", sb.String())
+	}
 }
@@ -0,0 +1,44 @@
+package ssabuilder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildErrorsArePositioned(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources map[string]string
+		// want - сообщения, которые должна содержать ошибка
+		want []string
+	}{
+		{
+			"undefined symbols",
+			map[string]string{
+				"main.go": "package main\n\nfunc F(x int) int {\n\treturn missing(x)\n}\n",
+				"util.go": "package main\n\nfunc G() int {\n\treturn unknownVar\n}\n",
+			},
+			[]string{"main.go:4:9: undefined: missing", "util.go:4:9: undefined: unknownVar"},
+		},
+		{
+			"syntax errors",
+			map[string]string{
+				"main.go": "package main\n\nfunc F(x int) int {\n\treturn x +\n}\n",
+			},
+			[]string{"main.go:5:1:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := NewBuilder().ParseFilesAndBuildSSA(tt.sources, "F")
+			if err == nil {
+				t.Fatalf("built %s, want an error", fn)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
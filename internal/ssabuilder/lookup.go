@@ -0,0 +1,288 @@
+package ssabuilder
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// Lookup ищет в пакете pkg точку входа анализа по имени:
+//   - Func - функция пакета;
+//   - Type.Method, (*Type).Method, (Type).Method - метод именованного типа;
+//   - Func[int, string] - экземпляр обобщённой функции;
+//   - Func$1, Type.Method$1$2 - анонимная функция по имени SSA;
+//   - file.go:12 или file.go:12:7 - функция, объявленная в этой позиции.
+func Lookup(prog *ssa.Program, pkg *ssa.Package, name string) (*ssa.Function, error) {
+	if file, line, column, ok := parsePosition(name); ok {
+		return lookupPosition(prog, []*ssa.Package{pkg}, file, line, column)
+	}
+
+	base, typeArgs, generic, err := splitTypeArgs(name)
+	if err != nil {
+		return nil, err
+	}
+
+	base, anon, _ := strings.Cut(base, "$")
+
+	var fn *ssa.Function
+	if recv, method, ok := cutMethod(base); ok {
+		fn, err = lookupMethod(prog, pkg, recv, method)
+		if err != nil {
+			return nil, err
+		}
+	} else if fn = pkg.Func(base); fn == nil {
+		return nil, fmt.Errorf("function %s not found in package %s", base, pkg.Pkg.Path())
+	}
+
+	if anon != "" {
+		for _, index := range strings.Split(anon, "$") {
+			fn, err = anonFunc(fn, index)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if generic {
+		return instantiate(prog, fn, typeArgs)
+	}
+	if fn.TypeParams().Len() > 0 {
+		return nil, fmt.Errorf("function %s is generic: specify type arguments, e.g. %s[int]", fn.Name(), base)
+	}
+	return fn, nil
+}
+
+// parsePosition разбирает позицию вида file.go:line[:column]
+func parsePosition(name string) (file string, line, column int, ok bool) {
+	i := strings.Index(name, ".go:")
+	if i < 0 {
+		return "", 0, 0, false
+	}
+	file = name[:i+len(".go")]
+	parts := strings.Split(name[i+len(".go:"):], ":")
+	if len(parts) > 2 {
+		return "", 0, 0, false
+	}
+	line, err := strconv.Atoi(parts[0])
+	if err != nil || line <= 0 {
+		return "", 0, 0, false
+	}
+	if len(parts) == 2 {
+		if column, err = strconv.Atoi(parts[1]); err != nil || column <= 0 {
+			return "", 0, 0, false
+		}
+	}
+	return file, line, column, true
+}
+
+// lookupPosition ищет функцию, объявленную в заданной строке файла
+func lookupPosition(prog *ssa.Program, pkgs []*ssa.Package, file string, line, column int) (*ssa.Function, error) {
+	inPackages := make(map[*ssa.Package]bool)
+	for _, pkg := range pkgs {
+		inPackages[pkg] = true
+	}
+
+	var found []*ssa.Function
+	for fn := range ssautil.AllFunctions(prog) {
+		if fn.Synthetic != "" || !inPackages[fn.Package()] || !fn.Pos().IsValid() {
+			continue
+		}
+		pos := prog.Fset.Position(fn.Pos())
+		if pos.Line != line || filepath.Base(pos.Filename) != filepath.Base(file) {
+			continue
+		}
+		if column != 0 && pos.Column != column {
+			continue
+		}
+		found = append(found, fn)
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no function declared at %s:%d", file, line)
+	case 1:
+		return found[0], nil
+	}
+	names := make([]string, len(found))
+	for i, fn := range found {
+		names[i] = fmt.Sprintf("%s at column %d", fn.Name(), prog.Fset.Position(fn.Pos()).Column)
+	}
+	return nil, fmt.Errorf("several functions declared at %s:%d, add a column: %s", file, line, strings.Join(names, ", "))
+}
+
+// splitTypeArgs отделяет аргументы типов: "Func[int, []string]" -> "Func", ["int", "[]string"]
+func splitTypeArgs(name string) (base string, typeArgs []string, generic bool, err error) {
+	i := strings.Index(name, "[")
+	if i < 0 {
+		return name, nil, false, nil
+	}
+	if !strings.HasSuffix(name, "]") {
+		return "", nil, false, fmt.Errorf("invalid type arguments in %q", name)
+	}
+
+	depth, start := 0, i+1
+	for j := i + 1; j < len(name)-1; j++ {
+		switch name[j] {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				typeArgs = append(typeArgs, strings.TrimSpace(name[start:j]))
+				start = j + 1
+			}
+		}
+	}
+	typeArgs = append(typeArgs, strings.TrimSpace(name[start:len(name)-1]))
+	for _, arg := range typeArgs {
+		if arg == "" {
+			return "", nil, false, fmt.Errorf("invalid type arguments in %q", name)
+		}
+	}
+	return name[:i], typeArgs, true, nil
+}
+
+// cutMethod разбирает Type.Method, (*Type).Method и (Type).Method
+func cutMethod(name string) (recv, method string, ok bool) {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return "", "", false
+	}
+	recv, method = name[:i], name[i+1:]
+	recv = strings.TrimSuffix(strings.TrimPrefix(recv, "("), ")")
+	recv = strings.TrimPrefix(recv, "*")
+	return recv, method, true
+}
+
+// lookupMethod ищет метод, объявленный у типа, с получателем-значением
+// или получателем-указателем. Набор методов *T для этого не годится:
+// метод с получателем-значением в нём - синтетическая обёртка (*T).M.
+func lookupMethod(prog *ssa.Program, pkg *ssa.Package, recv, method string) (*ssa.Function, error) {
+	typ, ok := pkg.Members[recv].(*ssa.Type)
+	if !ok {
+		return nil, fmt.Errorf("type %s not found in package %s", recv, pkg.Pkg.Path())
+	}
+	named, ok := typ.Type().(*types.Named)
+	if !ok || named.TypeParams().Len() > 0 {
+		return nil, fmt.Errorf("methods of %s cannot be analysed: not a non-generic named type", recv)
+	}
+
+	for i := 0; i < named.NumMethods(); i++ {
+		if m := named.Method(i); m.Name() == method {
+			fn := prog.FuncValue(m)
+			if fn == nil || len(fn.Blocks) == 0 {
+				return nil, fmt.Errorf("method %s.%s has no body", recv, method)
+			}
+			return fn, nil
+		}
+	}
+	return nil, fmt.Errorf("type %s has no method %s", recv, method)
+}
+
+// anonFunc возвращает анонимную функцию fn$index
+func anonFunc(fn *ssa.Function, index string) (*ssa.Function, error) {
+	name := fn.Name() + "$" + index
+	for _, anon := range fn.AnonFuncs {
+		if anon.Name() == name {
+			return anon, nil
+		}
+	}
+	return nil, fmt.Errorf("anonymous function %s not found", name)
+}
+
+// instantiate возвращает экземпляр обобщённой функции. SSA не строит экземпляры
+// по запросу, поэтому собирается вспомогательный пакет, ссылающийся на fn[typeArgs],
+// и экземпляр берётся из его инициализатора.
+func instantiate(prog *ssa.Program, fn *ssa.Function, typeArgs []string) (*ssa.Function, error) {
+	if fn.TypeParams().Len() == 0 || fn.Signature.Recv() != nil {
+		return nil, fmt.Errorf("%s is not a generic function", fn.Name())
+	}
+	if fn.TypeParams().Len() != len(typeArgs) {
+		return nil, fmt.Errorf("%s expects %d type arguments, got %d", fn.Name(), fn.TypeParams().Len(), len(typeArgs))
+	}
+
+	target := fn.Pkg.Pkg
+	aliases := map[*types.Package]string{target: "target"}
+	qualifier := func(p *types.Package) string {
+		if alias, ok := aliases[p]; ok {
+			return alias
+		}
+		alias := fmt.Sprintf("p%d", len(aliases))
+		aliases[p] = alias
+		return alias
+	}
+
+	args := make([]string, len(typeArgs))
+	for i, arg := range typeArgs {
+		tv, err := types.Eval(prog.Fset, target, token.NoPos, arg)
+		if err != nil || !tv.IsType() {
+			return nil, fmt.Errorf("invalid type argument %q for %s: %v", arg, fn.Name(), err)
+		}
+		args[i] = types.TypeString(tv.Type, qualifier)
+	}
+
+	var src strings.Builder
+	src.WriteString("package entrypoint\n\n")
+	for p, alias := range aliases {
+		fmt.Fprintf(&src, "import %s %q\n", alias, p.Path())
+	}
+	fmt.Fprintf(&src, "\nvar Instance = target.%s[%s]\n", fn.Name(), strings.Join(args, ", "))
+
+	file, err := parser.ParseFile(prog.Fset, "entrypoint.go", src.String(), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	packagesByPath := make(map[string]*types.Package)
+	for _, pkg := range prog.AllPackages() {
+		packagesByPath[pkg.Pkg.Path()] = pkg.Pkg
+	}
+	conf := &types.Config{Importer: importerFunc(func(path string) (*types.Package, error) {
+		if p, ok := packagesByPath[path]; ok {
+			return p, nil
+		}
+		return nil, fmt.Errorf("package %s is not loaded", path)
+	})}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Instances:  make(map[*ast.Ident]types.Instance),
+		Scopes:     make(map[ast.Node]*types.Scope),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	path := fmt.Sprintf("entrypoint%d", len(prog.AllPackages()))
+	shim, err := conf.Check(path, prog.Fset, []*ast.File{file}, info)
+	if err != nil {
+		return nil, fmt.Errorf("cannot instantiate %s[%s]: %v", fn.Name(), strings.Join(typeArgs, ", "), err)
+	}
+
+	shimPkg := prog.CreatePackage(shim, []*ast.File{file}, info, false)
+	shimPkg.Build()
+
+	var operands []*ssa.Value
+	for _, block := range shimPkg.Func("init").Blocks {
+		for _, instr := range block.Instrs {
+			for _, op := range instr.Operands(operands[:0]) {
+				if instance, ok := (*op).(*ssa.Function); ok && instance.Origin() == fn {
+					return instance, nil
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("cannot instantiate %s[%s]", fn.Name(), strings.Join(typeArgs, ", "))
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
@@ -0,0 +1,174 @@
+package ssabuilder
+
+import (
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const methodsSource = `package main
+
+type Counter struct {
+	n int
+}
+
+func (c Counter) Big() bool {
+	return c.n > 10
+}
+
+func (c *Counter) Inc(d int) int {
+	c.n += d
+	return c.n
+}
+`
+
+func TestLookupMethods(t *testing.T) {
+	tests := []struct {
+		name    string
+		pointer bool
+	}{
+		{"Counter.Big", false},
+		{"(Counter).Big", false},
+		{"(*Counter).Big", false},
+		{"Counter.Inc", true},
+		{"(*Counter).Inc", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := NewBuilder().ParseAndBuildSSA(methodsSource, tt.name)
+			if err != nil {
+				t.Fatalf("lookup: %v", err)
+			}
+			if fn.Synthetic != "" {
+				t.Errorf("got synthetic %s (%s), want the declared method", fn, fn.Synthetic)
+			}
+			recv := fn.Signature.Recv()
+			if recv == nil {
+				t.Fatalf("%s has no receiver", fn)
+			}
+			if _, pointer := recv.Type().(*types.Pointer); pointer != tt.pointer {
+				t.Errorf("receiver of %s = %s, want pointer %v", fn, recv.Type(), tt.pointer)
+			}
+		})
+	}
+
+	if _, err := NewBuilder().ParseAndBuildSSA(methodsSource, "Counter.Missing"); err == nil {
+		t.Error("expected an error for a missing method")
+	}
+}
+
+func TestProgramFunctionsMethods(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module example.com/counter\n\ngo 1.21\n",
+		"main.go": methodsSource,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	program, err := LoadPackages(dir, "./...")
+	if err != nil {
+		t.Fatalf("LoadPackages: %v", err)
+	}
+	found := make(map[string]bool)
+	for _, fn := range program.Functions() {
+		if fn.Synthetic != "" {
+			t.Errorf("synthetic function %s enumerated", fn)
+		}
+		found[fn.Name()] = true
+	}
+	for _, name := range []string{"Big", "Inc"} {
+		if !found[name] {
+			t.Errorf("method %s not enumerated, got %v", name, found)
+		}
+	}
+}
+
+const entriesSource = `package main
+
+func Max[T int | float64](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func Outer(x int) func() int {
+	inc := func() int {
+		return x + 1
+	}
+	return inc
+}
+
+func Pair() (func() int, func() int) {
+	return func() int { return 1 }, func() int { return 2 }
+}
+`
+
+// position возвращает строку и столбец первого вхождения substr в source
+func position(source, substr string) (line, column int) {
+	before := source[:strings.Index(source, substr)]
+	line = strings.Count(before, "\n") + 1
+	column = len(before) - strings.LastIndex(before, "\n")
+	return line, column
+}
+
+func TestLookupEntries(t *testing.T) {
+	incLine, _ := position(entriesSource, "inc := func")
+	pairLine, firstColumn := position(entriesSource, "func() int { return 1 }")
+	_, secondColumn := position(entriesSource, "func() int { return 2 }")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Max[int]", "Max[int]"},
+		{"Max[float64]", "Max[float64]"},
+		{"Outer$1", "Outer$1"},
+		{fmt.Sprintf("main.go:%d", incLine), "Outer$1"},
+		{fmt.Sprintf("main.go:%d:%d", pairLine, firstColumn), "Pair$1"},
+		{fmt.Sprintf("main.go:%d:%d", pairLine, secondColumn), "Pair$2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := NewBuilder().ParseAndBuildSSA(entriesSource, tt.name)
+			if err != nil {
+				t.Fatalf("lookup: %v", err)
+			}
+			if fn.Name() != tt.want {
+				t.Errorf("found %s, want %s", fn.Name(), tt.want)
+			}
+			if len(fn.Blocks) == 0 {
+				t.Errorf("%s has no body", fn)
+			}
+		})
+	}
+
+	if fn, err := NewBuilder().ParseAndBuildSSA(entriesSource, "Max[int]"); err == nil {
+		if param := fn.Params[0].Type(); !types.Identical(param, types.Typ[types.Int]) {
+			t.Errorf("parameter of Max[int] has type %s, want int", param)
+		}
+	}
+
+	errors := []struct {
+		name string
+		want string
+	}{
+		{"Max", "generic"},
+		{"Max[string]", "does not satisfy"},
+		{"Outer$2", "not found"},
+		{fmt.Sprintf("main.go:%d", pairLine), "add a column"},
+		{"main.go:1", "no function"},
+	}
+	for _, tt := range errors {
+		if _, err := NewBuilder().ParseAndBuildSSA(entriesSource, tt.name); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("lookup %s error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
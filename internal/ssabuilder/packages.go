@@ -0,0 +1,152 @@
+package ssabuilder
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// loadMode - всё, что нужно для SSA с телами функций зависимостей
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+	packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps
+
+// Program - SSA программа, загруженная через go/packages.
+// Initial - пакеты, заданные шаблонами; остальные пакеты программы - их зависимости.
+type Program struct {
+	Prog    *ssa.Program
+	Initial []*ssa.Package
+}
+
+// LoadPackages загружает пакеты по шаблонам go list ("./...", "./pkg",
+// путь импорта) относительно каталога dir и строит SSA для них
+// и всех их зависимостей, включая тела импортируемых функций
+func LoadPackages(dir string, patterns ...string) (*Program, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	cfg := &packages.Config{Mode: loadMode, Dir: dir, Tests: false}
+	initial, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if len(initial) == 0 {
+		return nil, fmt.Errorf("no packages match %s", strings.Join(patterns, " "))
+	}
+
+	var errs []string
+	packages.Visit(initial, nil, func(pkg *packages.Package) {
+		for _, e := range pkg.Errors {
+			errs = append(errs, e.Error())
+		}
+	})
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to load packages:\n  %s", strings.Join(errs, "\n  "))
+	}
+
+	prog, pkgs := ssautil.AllPackages(initial, ssa.SanityCheckFunctions|ssa.InstantiateGenerics)
+	prog.Build()
+
+	res := &Program{Prog: prog}
+	for _, pkg := range pkgs {
+		if pkg != nil {
+			res.Initial = append(res.Initial, pkg)
+		}
+	}
+	return res, nil
+}
+
+// Func ищет функцию по квалифицированному имени "путь/пакета.Имя",
+// где Имя - любая форма, понятная Lookup. Имя без пакета ищется среди
+// исходных пакетов и должно быть однозначным.
+func (p *Program) Func(name string) (*ssa.Function, error) {
+	if file, line, column, ok := parsePosition(name); ok {
+		return lookupPosition(p.Prog, p.Initial, file, line, column)
+	}
+
+	// Путь пакета сам может содержать точки, поэтому берётся самый длинный подходящий
+	var pkg *ssa.Package
+	for _, candidate := range p.Prog.AllPackages() {
+		path := candidate.Pkg.Path()
+		if strings.HasPrefix(name, path+".") && (pkg == nil || len(path) > len(pkg.Pkg.Path())) {
+			pkg = candidate
+		}
+	}
+	if pkg != nil {
+		return Lookup(p.Prog, pkg, strings.TrimPrefix(name, pkg.Pkg.Path()+"."))
+	}
+
+	var (
+		found []*ssa.Function
+		errs  []string
+	)
+	for _, pkg := range p.Initial {
+		fn, err := Lookup(p.Prog, pkg, name)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		found = append(found, fn)
+	}
+	switch len(found) {
+	case 0:
+		if len(errs) == 1 {
+			return nil, fmt.Errorf("%s", errs[0])
+		}
+		return nil, fmt.Errorf("function %s not found", name)
+	case 1:
+		return found[0], nil
+	}
+	return nil, fmt.Errorf("function %s is ambiguous: found in %d packages, use a qualified name", name, len(found))
+}
+
+// Functions возвращает функции исходных пакетов в порядке их объявления
+func (p *Program) Functions() []*ssa.Function {
+	var res []*ssa.Function
+	for _, pkg := range p.Initial {
+		var fns []*ssa.Function
+		for _, member := range pkg.Members {
+			switch member := member.(type) {
+			case *ssa.Function:
+				if member.Synthetic != "" || len(member.Blocks) == 0 || member.TypeParams().Len() > 0 ||
+					member.Name() == "init" || member.Name() == "main" {
+					continue
+				}
+				fns = append(fns, member)
+			case *ssa.Type:
+				fns = append(fns, methods(p.Prog, pkg, member)...)
+			}
+		}
+		sort.Slice(fns, func(i, j int) bool {
+			a, b := p.Prog.Fset.Position(fns[i].Pos()), p.Prog.Fset.Position(fns[j].Pos())
+			if a.Filename != b.Filename {
+				return a.Filename < b.Filename
+			}
+			return a.Offset < b.Offset
+		})
+		res = append(res, fns...)
+	}
+	return res
+}
+
+// methods возвращает методы, объявленные у именованного типа пакета
+func methods(prog *ssa.Program, pkg *ssa.Package, typ *ssa.Type) []*ssa.Function {
+	named, ok := typ.Type().(*types.Named)
+	if !ok || named.TypeParams().Len() > 0 {
+		return nil
+	}
+
+	var res []*ssa.Function
+	for i := 0; i < named.NumMethods(); i++ {
+		fn := prog.FuncValue(named.Method(i))
+		if fn != nil && fn.Package() == pkg && len(fn.Blocks) > 0 {
+			res = append(res, fn)
+		}
+	}
+	return res
+}
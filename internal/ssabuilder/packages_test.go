@@ -0,0 +1,92 @@
+package ssabuilder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// shopModule - модуль из двух пакетов, в каждом объявлена своя Discount
+var shopModule = map[string]string{
+	"go.mod": "module example.com/shop\n\ngo 1.21\n",
+	"price/price.go": `package price
+
+func Discount(total int) int {
+	if total > 100 {
+		return total / 10
+	}
+	return 0
+}
+`,
+	"cart/cart.go": `package cart
+
+import "example.com/shop/price"
+
+func Discount(items int) int {
+	return items
+}
+
+func Total(sum, items int) int {
+	return sum - price.Discount(sum) - Discount(items)
+}
+`,
+}
+
+// writeModule раскладывает файлы модуля по временному каталогу
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestProgramFuncQualifiedNames(t *testing.T) {
+	program, err := LoadPackages(writeModule(t, shopModule), "./...")
+	if err != nil {
+		t.Fatalf("LoadPackages: %v", err)
+	}
+	if len(program.Initial) != 2 {
+		t.Fatalf("loaded %d packages, want 2", len(program.Initial))
+	}
+
+	tests := []struct {
+		name string
+		pkg  string
+	}{
+		{"example.com/shop/price.Discount", "example.com/shop/price"},
+		{"example.com/shop/cart.Discount", "example.com/shop/cart"},
+		{"example.com/shop/cart.Total", "example.com/shop/cart"},
+		{"Total", "example.com/shop/cart"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := program.Func(tt.name)
+			if err != nil {
+				t.Fatalf("Func: %v", err)
+			}
+			if path := fn.Pkg.Pkg.Path(); path != tt.pkg {
+				t.Errorf("%s found in %s, want %s", tt.name, path, tt.pkg)
+			}
+			// Тело строится и у функций, вызываемых из другого пакета
+			if len(fn.Blocks) == 0 {
+				t.Errorf("%s has no body", fn)
+			}
+		})
+	}
+
+	if _, err := program.Func("Discount"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("Func(Discount) error = %v, want ambiguity", err)
+	}
+	if _, err := program.Func("example.com/shop/cart.Missing"); err == nil {
+		t.Error("expected an error for a missing function")
+	}
+}
@@ -0,0 +1,388 @@
+package symbolic
+
+import (
+	"fmt"
+	"math"
+)
+
+// Node - выражение в таблице кодировщика. Операнды записываются номерами
+// других узлов таблицы, -1 - отсутствующий операнд. Значения полей зависят
+// от вида узла, см. Encoder.Encode.
+type Node struct {
+	Kind  string         `json:"kind"`
+	Nil   bool           `json:"nil,omitempty"`
+	Name  string         `json:"name,omitempty"`
+	Type  ExpressionType `json:"type,omitempty"`
+	Int   int64          `json:"int,omitempty"`
+	Float uint32         `json:"float,omitempty"` // биты float32: JSON не умеет NaN и бесконечности
+	Bool  bool           `json:"bool,omitempty"`
+	Op    int            `json:"op,omitempty"`
+	Index int            `json:"index,omitempty"`
+
+	Operands []int            `json:"operands,omitempty"`
+	True     []int            `json:"true,omitempty"`
+	False    []int            `json:"false,omitempty"`
+	Types    []ExpressionType `json:"types,omitempty"`
+}
+
+// Encoder записывает выражения в общую таблицу узлов. Выражение, встреченное
+// повторно, получает тот же номер, поэтому после декодирования общие
+// подвыражения и указатели остаются общими объектами. Операнды записываются
+// раньше выражения, так что таблицу можно декодировать за один проход.
+type Encoder struct {
+	Nodes []Node
+
+	ids map[SymbolicExpression]int
+}
+
+func NewEncoder() *Encoder {
+	return &Encoder{ids: make(map[SymbolicExpression]int)}
+}
+
+// Encode добавляет выражение в таблицу и возвращает его номер; nil - -1
+func (enc *Encoder) Encode(expr SymbolicExpression) int {
+	if expr == nil {
+		return -1
+	}
+	if id, ok := enc.ids[expr]; ok {
+		return id
+	}
+
+	node := Node{Kind: kindOf(expr)}
+	switch e := expr.(type) {
+	case *SymbolicVariable:
+		if node.Nil = e == nil; !node.Nil {
+			node.Name, node.Type = e.Name, e.ExprType
+		}
+	case *SymbolicPointer:
+		if node.Nil = e == nil; !node.Nil {
+			node.Index, node.Type, node.Name = int(e.Address), e.PointerType, e.Name
+			node.Operands = []int{enc.Encode(e.Expr)}
+		}
+	case *SymbolicArray:
+		if node.Nil = e == nil; !node.Nil {
+			node.Name, node.Type, node.Index = e.Name, e.ElemType, int(e.Size)
+		}
+	case *IntConstant:
+		if node.Nil = e == nil; !node.Nil {
+			node.Int = e.Value
+		}
+	case *FloatConstant:
+		if node.Nil = e == nil; !node.Nil {
+			node.Float = math.Float32bits(e.Value)
+		}
+	case *BoolConstant:
+		if node.Nil = e == nil; !node.Nil {
+			node.Bool = e.Value
+		}
+	case *BinaryOperation:
+		if node.Nil = e == nil; !node.Nil {
+			node.Op = int(e.Operator)
+			node.Operands = []int{enc.Encode(e.Left), enc.Encode(e.Right)}
+		}
+	case *LogicalOperation:
+		if node.Nil = e == nil; !node.Nil {
+			node.Op = int(e.Operator)
+			node.Operands = enc.EncodeAll(e.Operands)
+		}
+	case *UnaryOperation:
+		if node.Nil = e == nil; !node.Nil {
+			node.Op = int(e.Operator)
+			node.Operands = []int{enc.Encode(e.Operand)}
+		}
+	case *ArrayAccess:
+		if node.Nil = e == nil; !node.Nil {
+			node.Name, node.Type, node.Index = e.Array.Name, e.Array.ElemType, int(e.Array.Size)
+			node.Operands = []int{enc.Encode(e.Index)}
+		}
+	case *ConditionalOperation:
+		if node.Nil = e == nil; !node.Nil {
+			node.Operands = []int{enc.Encode(e.Condition)}
+			node.True, node.False = enc.EncodeAll(e.TrueBlock), enc.EncodeAll(e.FalseBlock)
+		}
+	case *FieldAccess:
+		if node.Nil = e == nil; !node.Nil {
+			node.Index, node.Name, node.Type = e.FieldIdx, e.StructName, e.Ty
+			node.Operands = []int{enc.Encode(e.Obj), enc.Encode(e.Key)}
+		}
+	case *FieldAssign:
+		if node.Nil = e == nil; !node.Nil {
+			node.Index, node.Name = e.FieldIdx, e.StructName
+			node.Operands = []int{enc.Encode(e.Obj), enc.Encode(e.Value)}
+		}
+	case *Function:
+		if node.Nil = e == nil; !node.Nil {
+			node.Name, node.Types, node.Type = e.Name, e.Args, e.ReturnType
+		}
+	case *FunctionCall:
+		if node.Nil = e == nil; !node.Nil {
+			node.Name, node.Types, node.Type = e.FunctionDecl.Name, e.FunctionDecl.Args, e.FunctionDecl.ReturnType
+			node.Operands = enc.EncodeAll(e.Args)
+		}
+	case *FieldAddr:
+		if node.Nil = e == nil; !node.Nil {
+			node.Index = e.FieldIndex
+			node.Operands = []int{enc.Encode(e.Ptr)}
+		}
+	case *IndexAddr:
+		if node.Nil = e == nil; !node.Nil {
+			node.Index = e.Index
+			node.Operands = []int{enc.Encode(e.Ptr)}
+		}
+	case *Tuple:
+		if node.Nil = e == nil; !node.Nil {
+			node.Operands = enc.EncodeAll(e.Elements)
+		}
+	default:
+		panic(fmt.Sprintf("encode: unsupported expression %T", expr))
+	}
+
+	id := len(enc.Nodes)
+	enc.Nodes = append(enc.Nodes, node)
+	enc.ids[expr] = id
+	return id
+}
+
+// EncodeAll кодирует список выражений
+func (enc *Encoder) EncodeAll(exprs []SymbolicExpression) []int {
+	if exprs == nil {
+		return nil
+	}
+	ids := make([]int, len(exprs))
+	for i, expr := range exprs {
+		ids[i] = enc.Encode(expr)
+	}
+	return ids
+}
+
+func kindOf(expr SymbolicExpression) string {
+	switch expr.(type) {
+	case *SymbolicVariable:
+		return "var"
+	case *SymbolicPointer:
+		return "pointer"
+	case *SymbolicArray:
+		return "array"
+	case *IntConstant:
+		return "int"
+	case *FloatConstant:
+		return "float"
+	case *BoolConstant:
+		return "bool"
+	case *BinaryOperation:
+		return "binary"
+	case *LogicalOperation:
+		return "logical"
+	case *UnaryOperation:
+		return "unary"
+	case *ArrayAccess:
+		return "array-access"
+	case *ConditionalOperation:
+		return "ite"
+	case *FieldAccess:
+		return "field-access"
+	case *FieldAssign:
+		return "field-assign"
+	case *Function:
+		return "function"
+	case *FunctionCall:
+		return "call"
+	case *FieldAddr:
+		return "field-addr"
+	case *IndexAddr:
+		return "index-addr"
+	case *Tuple:
+		return "tuple"
+	}
+	return ""
+}
+
+// Decoder восстанавливает выражения из таблицы узлов Encoder
+type Decoder struct {
+	exprs []SymbolicExpression
+}
+
+// NewDecoder декодирует всю таблицу; ошибка - если таблица повреждена
+func NewDecoder(nodes []Node) (*Decoder, error) {
+	dec := &Decoder{exprs: make([]SymbolicExpression, 0, len(nodes))}
+	for i, node := range nodes {
+		expr, err := dec.decode(node)
+		if err != nil {
+			return nil, fmt.Errorf("decode expression %d: %w", i, err)
+		}
+		dec.exprs = append(dec.exprs, expr)
+	}
+	return dec, nil
+}
+
+// Decode возвращает выражение по номеру; -1 - nil
+func (dec *Decoder) Decode(id int) (SymbolicExpression, error) {
+	if id == -1 {
+		return nil, nil
+	}
+	if id < 0 || id >= len(dec.exprs) {
+		return nil, fmt.Errorf("no expression %d", id)
+	}
+	return dec.exprs[id], nil
+}
+
+// DecodeAll декодирует список выражений
+func (dec *Decoder) DecodeAll(ids []int) ([]SymbolicExpression, error) {
+	if ids == nil {
+		return nil, nil
+	}
+	exprs := make([]SymbolicExpression, len(ids))
+	for i, id := range ids {
+		expr, err := dec.Decode(id)
+		if err != nil {
+			return nil, err
+		}
+		exprs[i] = expr
+	}
+	return exprs, nil
+}
+
+// DecodePointer возвращает выражение-указатель по номеру; -1 - nil
+func (dec *Decoder) DecodePointer(id int) (*SymbolicPointer, error) {
+	expr, err := dec.Decode(id)
+	if err != nil || expr == nil {
+		return nil, err
+	}
+	ptr, ok := expr.(*SymbolicPointer)
+	if !ok {
+		return nil, fmt.Errorf("expression %d is %s, not a pointer", id, kindOf(expr))
+	}
+	return ptr, nil
+}
+
+func (dec *Decoder) decode(node Node) (SymbolicExpression, error) {
+	// Операнды записаны раньше узла и уже декодированы
+	operands, err := dec.DecodeAll(node.Operands)
+	if err != nil {
+		return nil, err
+	}
+	operand := func(i int) SymbolicExpression {
+		if i < len(operands) {
+			return operands[i]
+		}
+		return nil
+	}
+	pointer := func() (*SymbolicPointer, error) {
+		if len(node.Operands) == 0 {
+			return nil, nil
+		}
+		return dec.DecodePointer(node.Operands[0])
+	}
+
+	switch node.Kind {
+	case "var":
+		if node.Nil {
+			return (*SymbolicVariable)(nil), nil
+		}
+		return &SymbolicVariable{Name: node.Name, ExprType: node.Type}, nil
+	case "pointer":
+		if node.Nil {
+			return (*SymbolicPointer)(nil), nil
+		}
+		return &SymbolicPointer{Address: uint(node.Index), PointerType: node.Type, Expr: operand(0), Name: node.Name}, nil
+	case "array":
+		if node.Nil {
+			return (*SymbolicArray)(nil), nil
+		}
+		return &SymbolicArray{Name: node.Name, ElemType: node.Type, Size: uint(node.Index)}, nil
+	case "int":
+		if node.Nil {
+			return (*IntConstant)(nil), nil
+		}
+		return &IntConstant{Value: node.Int}, nil
+	case "float":
+		if node.Nil {
+			return (*FloatConstant)(nil), nil
+		}
+		return &FloatConstant{Value: math.Float32frombits(node.Float)}, nil
+	case "bool":
+		if node.Nil {
+			return (*BoolConstant)(nil), nil
+		}
+		return &BoolConstant{Value: node.Bool}, nil
+	case "binary":
+		if node.Nil {
+			return (*BinaryOperation)(nil), nil
+		}
+		return &BinaryOperation{Left: operand(0), Right: operand(1), Operator: BinaryOperator(node.Op)}, nil
+	case "logical":
+		if node.Nil {
+			return (*LogicalOperation)(nil), nil
+		}
+		return &LogicalOperation{Operands: operands, Operator: LogicalOperator(node.Op)}, nil
+	case "unary":
+		if node.Nil {
+			return (*UnaryOperation)(nil), nil
+		}
+		return &UnaryOperation{Operand: operand(0), Operator: UnaryOperator(node.Op)}, nil
+	case "array-access":
+		if node.Nil {
+			return (*ArrayAccess)(nil), nil
+		}
+		array := SymbolicArray{Name: node.Name, ElemType: node.Type, Size: uint(node.Index)}
+		return &ArrayAccess{Array: array, Index: operand(0)}, nil
+	case "ite":
+		if node.Nil {
+			return (*ConditionalOperation)(nil), nil
+		}
+		trueBlock, err := dec.DecodeAll(node.True)
+		if err != nil {
+			return nil, err
+		}
+		falseBlock, err := dec.DecodeAll(node.False)
+		if err != nil {
+			return nil, err
+		}
+		return &ConditionalOperation{Condition: operand(0), TrueBlock: trueBlock, FalseBlock: falseBlock}, nil
+	case "field-access":
+		if node.Nil {
+			return (*FieldAccess)(nil), nil
+		}
+		return &FieldAccess{Obj: operand(0), FieldIdx: node.Index, Key: operand(1), StructName: node.Name, Ty: node.Type}, nil
+	case "field-assign":
+		if node.Nil {
+			return (*FieldAssign)(nil), nil
+		}
+		return &FieldAssign{Obj: operand(0), FieldIdx: node.Index, Value: operand(1), StructName: node.Name}, nil
+	case "function":
+		if node.Nil {
+			return (*Function)(nil), nil
+		}
+		return &Function{Name: node.Name, Args: node.Types, ReturnType: node.Type}, nil
+	case "call":
+		if node.Nil {
+			return (*FunctionCall)(nil), nil
+		}
+		decl := Function{Name: node.Name, Args: node.Types, ReturnType: node.Type}
+		return &FunctionCall{FunctionDecl: decl, Args: operands}, nil
+	case "field-addr":
+		if node.Nil {
+			return (*FieldAddr)(nil), nil
+		}
+		ptr, err := pointer()
+		if err != nil {
+			return nil, err
+		}
+		return &FieldAddr{Ptr: ptr, FieldIndex: node.Index}, nil
+	case "index-addr":
+		if node.Nil {
+			return (*IndexAddr)(nil), nil
+		}
+		ptr, err := pointer()
+		if err != nil {
+			return nil, err
+		}
+		return &IndexAddr{Ptr: ptr, Index: node.Index}, nil
+	case "tuple":
+		if node.Nil {
+			return (*Tuple)(nil), nil
+		}
+		return &Tuple{Elements: operands}, nil
+	}
+	return nil, fmt.Errorf("unknown expression kind %q", node.Kind)
+}
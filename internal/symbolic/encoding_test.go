@@ -0,0 +1,102 @@
+package symbolic
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// encodeDecode кодирует выражения, пропускает таблицу через JSON и декодирует обратно
+func encodeDecode(t *testing.T, exprs []SymbolicExpression) []SymbolicExpression {
+	t.Helper()
+	enc := NewEncoder()
+	ids := enc.EncodeAll(exprs)
+	data, err := json.Marshal(enc.Nodes)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var nodes []Node
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	dec, err := NewDecoder(nodes)
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	decoded, err := dec.DecodeAll(ids)
+	if err != nil {
+		t.Fatalf("DecodeAll: %v", err)
+	}
+	return decoded
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+	x := NewSymbolicVariable("x", IntType)
+	f := NewSymbolicVariable("f", FloatType)
+	ptr := NewSymbolicPointer(3, ObjType)
+	ptr.Name, ptr.Expr = "Point", NewSymbolicVariable("Point", ObjType)
+	array := NewSymbolicArray("a", IntType, 4)
+	decl := NewFunction("abs", []ExpressionType{IntType}, IntType)
+
+	exprs := []SymbolicExpression{
+		x,
+		ptr,
+		array,
+		NewIntConstant(-42),
+		NewFloatConstant(1.5),
+		NewFloatConstant(float32(math.Inf(-1))),
+		NewBoolConstant(true),
+		NewBinaryOperation(x, NewIntConstant(2), MOD),
+		NewLogicalOperation([]SymbolicExpression{NewBoolConstant(false), NewBinaryOperation(x, NewIntConstant(0), GT)}, OR),
+		NewUnaryOperation(f, INCREMENT),
+		NewArrayAccess(*array, x),
+		NewConditionalOperation(NewBinaryOperation(x, NewIntConstant(0), LT), []SymbolicExpression{x}, []SymbolicExpression{NewIntConstant(0)}),
+		NewFieldAccess(ptr.Expr, 1, NewIntConstant(7), "Point", IntType),
+		NewFieldAssign(ptr.Expr, 0, x, "Point"),
+		decl,
+		NewFunctionCall(*decl, []SymbolicExpression{x}),
+		NewFieldAddr(ptr, 2),
+		NewIndexAddr(NewSymbolicPointer(1, ArrayType), 3),
+		NewTuple([]SymbolicExpression{x, NewBoolConstant(false)}),
+		(*IntConstant)(nil),
+		(*SymbolicPointer)(nil),
+		nil,
+	}
+	decoded := encodeDecode(t, exprs)
+
+	kinds := make(map[string]bool)
+	for i, expr := range exprs {
+		if !reflect.DeepEqual(decoded[i], expr) {
+			t.Errorf("%T decoded as %#v, want %#v", expr, decoded[i], expr)
+		}
+		kinds[kindOf(expr)] = true
+	}
+	for _, kind := range []string{"var", "pointer", "array", "int", "float", "bool", "binary", "logical", "unary",
+		"array-access", "ite", "field-access", "field-assign", "function", "call", "field-addr", "index-addr", "tuple"} {
+		if !kinds[kind] {
+			t.Errorf("kind %s is not covered", kind)
+		}
+	}
+
+	// Общие подвыражения и указатели остаются общими объектами
+	if decoded[0] != decoded[7].(*BinaryOperation).Left {
+		t.Error("the variable is not shared after decoding")
+	}
+	if decoded[1] != decoded[16].(*FieldAddr).Ptr {
+		t.Error("the pointer is not shared after decoding")
+	}
+}
+
+func TestDecoderRejectsBrokenTables(t *testing.T) {
+	tables := map[string][]Node{
+		"unknown kind":     {{Kind: "matrix"}},
+		"forward operand":  {{Kind: "unary", Operands: []int{1}}, {Kind: "int"}},
+		"pointer expected": {{Kind: "int"}, {Kind: "field-addr", Operands: []int{0}}},
+	}
+	for name, nodes := range tables {
+		if _, err := NewDecoder(nodes); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
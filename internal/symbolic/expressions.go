@@ -4,6 +4,7 @@ package symbolic
 import (
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 // SymbolicExpression - базовый интерфейс для всех символьных выражений
@@ -204,20 +205,15 @@ func (bo *BinaryOperation) Type() ExpressionType {
 	// Определить результирующий тип на основе операции и типов операндов
 	// Например: int + int = int, int < int = bool
 	switch bo.Operator {
-	case EQ:
-	case GE:
-	case GT:
-	case LE:
-	case LT:
-	case NE:
+	case EQ, GE, GT, LE, LT, NE:
 		return BoolType
-	case ADD:
-	case SUB:
-	case MUL:
+	case ADD, SUB, MUL, DIV:
+		if bo.Left.Type() == FloatType {
+			return FloatType
+		}
+		return IntType
 	case MOD:
 		return IntType
-	case DIV:
-		return FloatType
 	}
 
 	// UNREACHABLE?
@@ -406,16 +402,11 @@ type UnaryOperation struct {
 
 func (uo *UnaryOperation) Type() ExpressionType {
 	switch uo.Operator {
-	case MINUS:
-	case INCREMENT:
-	case DECREMENT:
-		return IntType
-	case NOT:
-		return BoolType
+	case MINUS, INCREMENT, DECREMENT:
+		return uo.Operand.Type()
 	default:
 		return BoolType
 	}
-	panic("unreachable")
 }
 
 func (uo *UnaryOperation) String() string {
@@ -471,6 +462,9 @@ type ConditionalOperation struct {
 }
 
 func (co *ConditionalOperation) Type() ExpressionType {
+	if len(co.TrueBlock) > 0 {
+		return co.TrueBlock[len(co.TrueBlock)-1].Type()
+	}
 	return co.Condition.Type()
 }
 
@@ -653,6 +647,35 @@ func (ia *IndexAddr) Accept(visitor Visitor) interface{} {
 	return visitor.VisitIndexAddr(ia)
 }
 
+// Tuple - результат функции с несколькими возвращаемыми значениями.
+// Кортеж не бывает операндом других выражений: его элементы
+// достаются инструкцией Extract.
+type Tuple struct {
+	Elements []SymbolicExpression
+}
+
+func NewTuple(elements []SymbolicExpression) *Tuple {
+	return &Tuple{
+		Elements: elements,
+	}
+}
+
+func (t *Tuple) Type() ExpressionType {
+	return TupleType
+}
+
+func (t *Tuple) String() string {
+	elements := make([]string, len(t.Elements))
+	for i, element := range t.Elements {
+		elements[i] = element.String()
+	}
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
+func (t *Tuple) Accept(visitor Visitor) interface{} {
+	return visitor.VisitTuple(t)
+}
+
 // TODO: Добавьте дополнительные типы выражений по необходимости:
 // -[x] SymbolicArray
 // -[x] UnaryOperation (унарные операции: -x, !x)
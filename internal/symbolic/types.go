@@ -14,6 +14,7 @@ const (
 	AddrType
 	ObjType
 	FuncType
+	TupleType
 	// Добавьте другие типы по необходимости
 )
 
@@ -32,6 +33,8 @@ func (et ExpressionType) String() string {
 		return "address"
 	case ObjType:
 		return "object"
+	case TupleType:
+		return "tuple"
 	default:
 		return "unknown"
 	}
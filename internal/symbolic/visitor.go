@@ -17,6 +17,7 @@ type Visitor interface {
 	VisitFieldAssign(expr *FieldAssign) interface{}
 	VisitIndexAddr(expr *IndexAddr) interface{}
 	VisitFieldAddr(expr *FieldAddr) interface{}
+	VisitTuple(expr *Tuple) interface{}
 
 	// funcs
 	VisitFunction(fu *Function) interface{}
@@ -0,0 +1,343 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"symbolic-execution-course/internal/solver"
+	"symbolic-execution-course/internal/symbolic"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// Target - цель направленного исполнения: строка исходника (File:Line)
+// или инструкция функции (Function#Instr, номер в порядке блоков SSA)
+type Target struct {
+	File     string
+	Line     int
+	Function string
+	Instr    int
+}
+
+// ParseTarget разбирает цель в виде "file.go:12", ":12" или "Func#3"
+func ParseTarget(spec string) (Target, error) {
+	if name, instr, ok := strings.Cut(spec, "#"); ok {
+		n, err := strconv.Atoi(instr)
+		if err != nil || name == "" || n < 0 {
+			return Target{}, fmt.Errorf("invalid target %q: expected Function#Instr", spec)
+		}
+		return Target{Function: name, Instr: n}, nil
+	}
+
+	i := strings.LastIndex(spec, ":")
+	if i < 0 {
+		return Target{}, fmt.Errorf("invalid target %q: expected file:line or Function#Instr", spec)
+	}
+	line, err := strconv.Atoi(spec[i+1:])
+	if err != nil || line <= 0 {
+		return Target{}, fmt.Errorf("invalid target %q: bad line number", spec)
+	}
+	return Target{File: spec[:i], Line: line}, nil
+}
+
+func (t Target) String() string {
+	if t.Function != "" {
+		return fmt.Sprintf("%s#%d", t.Function, t.Instr)
+	}
+	return fmt.Sprintf("%s:%d", t.File, t.Line)
+}
+
+// locate находит все инструкции программы, соответствующие цели
+func (t Target) locate(prog *ssa.Program) (map[*ssa.BasicBlock][]int, error) {
+	locations := make(map[*ssa.BasicBlock][]int)
+
+	if t.Function != "" {
+		fn, err := t.function(prog)
+		if err != nil {
+			return nil, err
+		}
+		n := t.Instr
+		for _, block := range fn.Blocks {
+			if n < len(block.Instrs) {
+				locations[block] = append(locations[block], n)
+				break
+			}
+			n -= len(block.Instrs)
+		}
+		return locations, nil
+	}
+
+	for fn := range ssautil.AllFunctions(prog) {
+		for _, block := range fn.Blocks {
+			for i, instr := range block.Instrs {
+				pos := instr.Pos()
+				if !pos.IsValid() {
+					continue
+				}
+				position := prog.Fset.Position(pos)
+				if position.Line != t.Line {
+					continue
+				}
+				if t.File != "" && filepath.Base(position.Filename) != filepath.Base(t.File) {
+					continue
+				}
+				locations[block] = append(locations[block], i)
+			}
+		}
+	}
+
+	return locations, nil
+}
+
+// function находит функцию цели Function#Instr по полному имени, как его
+// печатает fn.String(), а если такой нет - по короткому. Короткое имя,
+// подходящее нескольким функциям или методам, - ошибка.
+func (t Target) function(prog *ssa.Program) (*ssa.Function, error) {
+	var candidates []*ssa.Function
+	for fn := range ssautil.AllFunctions(prog) {
+		if len(fn.Blocks) == 0 {
+			continue
+		}
+		if fn.String() == t.Function {
+			return fn, nil
+		}
+		if fn.Name() == t.Function {
+			candidates = append(candidates, fn)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("target %s: no function %s", t, t.Function)
+	case 1:
+		return candidates[0], nil
+	}
+	names := make([]string, len(candidates))
+	for i, fn := range candidates {
+		names[i] = fn.String()
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("target %s: function name %s is ambiguous, use one of %s", t, t.Function, strings.Join(names, ", "))
+}
+
+// ExplorationStopper - селектор, который может завершить анализ досрочно.
+// Анализатор спрашивает его перед выполнением каждой инструкции.
+type ExplorationStopper interface {
+	ShouldStop(interpreter *Interpreter, instr ssa.Instruction) bool
+}
+
+// TargetSelector ведёт исследование к цели по расстоянию в графе потока
+// управления, дополненном дугами графа вызовов, и останавливает анализ,
+// как только какое-то состояние доходит до цели.
+type TargetSelector struct {
+	Target  Target
+	Reached *Interpreter
+
+	locations map[*ssa.BasicBlock][]int
+	distances map[*ssa.BasicBlock]int
+}
+
+// NewTargetSelector находит цель в программе функции fn и заранее
+// считает расстояния до неё от каждого базового блока
+func NewTargetSelector(target Target, fn *ssa.Function) (*TargetSelector, error) {
+	locations, err := target.locate(fn.Prog)
+	if err != nil {
+		return nil, err
+	}
+	if len(locations) == 0 {
+		return nil, fmt.Errorf("target %s does not match any instruction", target)
+	}
+
+	ts := &TargetSelector{
+		Target:    target,
+		locations: locations,
+		distances: make(map[*ssa.BasicBlock]int),
+	}
+	ts.computeDistances(fn.Prog)
+	return ts, nil
+}
+
+// computeDistances считает расстояние в блоках от начала каждого блока
+// до ближайшей цели. Вызов функции, из которой цель достижима, даёт дугу
+// к её входному блоку. Считается до неподвижной точки, как Беллман-Форд.
+func (ts *TargetSelector) computeDistances(prog *ssa.Program) {
+	var blocks []*ssa.BasicBlock
+	for fn := range ssautil.AllFunctions(prog) {
+		for _, block := range fn.Blocks {
+			blocks = append(blocks, block)
+			ts.distances[block] = unreachableDistance
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, block := range blocks {
+			if d := ts.distanceFrom(block, 0); d < ts.distances[block] {
+				ts.distances[block] = d
+				changed = true
+			}
+		}
+	}
+}
+
+// distanceFrom - расстояние до цели от инструкции index блока block
+func (ts *TargetSelector) distanceFrom(block *ssa.BasicBlock, index int) int {
+	for _, i := range ts.locations[block] {
+		if i >= index {
+			return 0
+		}
+	}
+
+	best := unreachableDistance
+	for i := index; i < len(block.Instrs); i++ {
+		call, ok := block.Instrs[i].(ssa.CallInstruction)
+		if !ok {
+			continue
+		}
+		if callee := call.Common().StaticCallee(); callee != nil && len(callee.Blocks) > 0 {
+			best = min(best, ts.distance(callee.Blocks[0])+1)
+		}
+	}
+	for _, succ := range block.Succs {
+		best = min(best, ts.distance(succ)+1)
+	}
+	return best
+}
+
+func (ts *TargetSelector) distance(block *ssa.BasicBlock) int {
+	if d, ok := ts.distances[block]; ok {
+		return d
+	}
+	return unreachableDistance
+}
+
+// Distance - расстояние от состояния до цели с учётом возврата в вызывающие функции
+func (ts *TargetSelector) Distance(interpreter *Interpreter) int {
+	if interpreter.IsFinished() {
+		return unreachableDistance
+	}
+
+	best := ts.distanceFrom(interpreter.CurrentBlock, interpreter.InstrIndex)
+
+	// Кадр вызванной функции хранит место возврата в вызывающую
+	penalty := 1
+	for i := len(interpreter.CallStack) - 1; i > 0; i-- {
+		frame := interpreter.CallStack[i]
+		if frame.CurrentBlock == nil {
+			break
+		}
+		best = min(best, ts.distanceFrom(frame.CurrentBlock, frame.ReturnToIndex)+penalty)
+		penalty++
+	}
+	return min(best, unreachableDistance)
+}
+
+func (ts *TargetSelector) CalculatePriority(interpreter Interpreter) int {
+	steps := min(interpreter.ExecutionSteps, stepsPriorityWeight-1)
+	return -ts.Distance(&interpreter)*stepsPriorityWeight + steps
+}
+
+func (ts *TargetSelector) ShouldStop(interpreter *Interpreter, instr ssa.Instruction) bool {
+	for _, i := range ts.locations[interpreter.CurrentBlock] {
+		if i == interpreter.InstrIndex {
+			ts.Reached = interpreter
+			return true
+		}
+	}
+	return false
+}
+
+type TargetStatus int
+
+const (
+	// TargetUnknown - бюджет шагов или ограничения на пути исчерпаны раньше, чем цель найдена
+	TargetUnknown TargetStatus = iota
+	// TargetReached - найден путь до цели
+	TargetReached
+	// TargetUnreachable - все пути в пределах ограничения на развёртку циклов
+	// исследованы, ни один не дошёл до цели и ни один вызов не пропущен
+	// из-за глубины вызовов или рекурсии
+	TargetUnreachable
+)
+
+func (s TargetStatus) String() string {
+	switch s {
+	case TargetReached:
+		return "reached"
+	case TargetUnreachable:
+		return "unreachable within bounds"
+	}
+	return "unknown"
+}
+
+// TargetResult - результат направленного исполнения
+type TargetResult struct {
+	Status TargetStatus
+	// State - состояние в момент достижения цели
+	State *Interpreter
+	// Inputs - значения символьных входов, ведущие к цели
+	Inputs solver.Model
+	Steps  int
+}
+
+// AnalyseTarget ищет путь от начала функции до цели. Стратегия выбора
+// путей из options не используется: пути выбираются по близости к цели.
+func AnalyseTarget(ctx context.Context, sources map[string]string, functionName string, target Target, options Options) (*TargetResult, error) {
+	fn, err := BuildFunction(sources, functionName)
+	if err != nil {
+		return nil, err
+	}
+	return AnalyseTargetFunction(ctx, fn, target, options)
+}
+
+// AnalyseTargetFunction - как AnalyseTarget для уже построенной SSA функции
+func AnalyseTargetFunction(ctx context.Context, fn *ssa.Function, target Target, options Options) (*TargetResult, error) {
+	analyser, err := newAnalyser(ctx, fn, options)
+	if err != nil {
+		return nil, err
+	}
+	defer analyser.close()
+
+	selector, err := NewTargetSelector(target, fn)
+	if err != nil {
+		return nil, err
+	}
+	analyser.PathSelector = selector
+	analyser.reprioritize()
+
+	analyser.explore()
+
+	result := &TargetResult{Status: TargetUnknown, Steps: analyser.stepsCounter}
+	switch {
+	case selector.Reached != nil:
+		result.Status = TargetReached
+		result.State = selector.Reached
+		result.Inputs = analyser.inputsFor(selector.Reached.PathCondition)
+	case !analyser.Truncated:
+		result.Status = TargetUnreachable
+	}
+	return result, nil
+}
+
+// inputsFor решает условие пути и оставляет в модели только его переменные
+func (analyser *Analyser) inputsFor(pathCondition *PathCondition) solver.Model {
+	constraints := pathCondition.Constraints()
+	res, model, err := solver.CheckConstraints(analyser.Solver, constraints)
+	if err != nil || res != solver.Sat {
+		return nil
+	}
+
+	symbols := solver.FreeSymbols(symbolic.NewLogicalOperation(constraints, symbolic.AND))
+	inputs := make(solver.Model)
+	for name, value := range model {
+		if symbols[name] {
+			inputs[name] = value
+		}
+	}
+	return inputs
+}
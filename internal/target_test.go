@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const targetSource = `package main
+
+func Reach(x int) int {
+	if x > 5 {
+		return 1 // reach
+	}
+	return 0
+}
+
+func Unreach(x int) int {
+	if x > 5 {
+		if x < 3 {
+			return 1 // unreach
+		}
+	}
+	return 0
+}
+
+func Outer(n int) int {
+	return inner(n, 0)
+}
+
+func inner(n, depth int) int {
+	if depth > 0 {
+		return 7 // nested
+	}
+	return inner(n, depth+1)
+}
+
+func Wrap(x int) int {
+	return mid(x)
+}
+
+func mid(x int) int {
+	return leaf(x)
+}
+
+func leaf(x int) int {
+	return x + 1 // leaf
+}
+`
+
+// lineOf возвращает номер строки исходника с комментарием // marker
+func lineOf(t *testing.T, source, marker string) int {
+	t.Helper()
+	for i, line := range strings.Split(source, "\n") {
+		if strings.HasSuffix(line, "// "+marker) {
+			return i + 1
+		}
+	}
+	t.Fatalf("no line marked %s", marker)
+	return 0
+}
+
+func TestAnalyseTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		function string
+		marker   string
+		options  Options
+		status   TargetStatus
+	}{
+		{"reached", "Reach", "reach", Options{}, TargetReached},
+		{"unreachable", "Unreach", "unreach", Options{}, TargetUnreachable},
+		// Рекурсивный вызов не исполняется, поэтому недостижимость не доказана
+		{"recursion", "Outer", "nested", Options{}, TargetUnknown},
+		{"call depth", "Wrap", "leaf", Options{MaxCallDepth: 1}, TargetUnknown},
+		{"callee", "Wrap", "leaf", Options{}, TargetReached},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := Target{File: "main.go", Line: lineOf(t, targetSource, tt.marker)}
+			res, err := AnalyseTarget(context.Background(), map[string]string{"main.go": targetSource}, tt.function, target, tt.options)
+			if err != nil {
+				t.Fatalf("AnalyseTarget: %v", err)
+			}
+			if res.Status != tt.status {
+				t.Errorf("status = %s, want %s", res.Status, tt.status)
+			}
+			if tt.status == TargetReached && res.State == nil {
+				t.Error("expected the state that reached the target")
+			}
+		})
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Target
+		ok   bool
+	}{
+		{"main.go:12", Target{File: "main.go", Line: 12}, true},
+		{":7", Target{Line: 7}, true},
+		{"Reach#3", Target{Function: "Reach", Instr: 3}, true},
+		{"main.go", Target{}, false},
+		{"main.go:0", Target{}, false},
+		{"#3", Target{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseTarget(tt.spec)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseTarget(%q) = %+v, %v; want %+v, ok %v", tt.spec, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+// locateSource - функция и метод с одним коротким именем
+const locateSource = `package main
+
+func Reach(x int) int {
+	return x
+}
+
+type counter struct{}
+
+func (counter) Reach(x int) int {
+	return x + 1
+}
+
+func Count(x int) int {
+	var c counter
+	return c.Reach(x)
+}
+
+func Once(x int) int {
+	return x
+}
+`
+
+func TestLocateFunction(t *testing.T) {
+	fn, err := BuildFunction(map[string]string{"main.go": locateSource}, "Reach")
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		function string
+		// block - блок найденной инструкции в функции Reach, -1 - другая функция
+		block int
+		err   string
+	}{
+		{"qualified", fn.String(), 0, ""},
+		{"unique short name", "Once", -1, ""},
+		{"ambiguous", "Reach", 0, "ambiguous"},
+		{"missing", "Missing", 0, "no function"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locations, err := Target{Function: tt.function}.locate(fn.Prog)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("locate error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || len(locations) != 1 {
+				t.Fatalf("locate = %v, %v; want one location", locations, err)
+			}
+			for block := range locations {
+				if (block.Parent() == fn) != (tt.block >= 0) {
+					t.Errorf("located %s, want function %s", block.Parent(), tt.function)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,479 @@
+// Package translator содержит реализацию транслятора в текст SMT-LIB2
+package translator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+// SMTLibTranslator транслирует символьные выражения в термы SMT-LIB2.
+// Попутно он собирает объявления всех встреченных свободных символов.
+type SMTLibTranslator struct {
+	decls   map[string]smtDecl // Символ SMT-LIB -> объявление
+	order   []string           // Порядок появления объявлений
+	pending int                // Индекс первого ещё не выданного объявления
+}
+
+// smtDecl - объявление свободного символа
+type smtDecl struct {
+	command string
+	name    string // Исходное имя символьного выражения
+	ty      symbolic.ExpressionType
+	isConst bool // Константа базового типа, её значение можно запросить у решателя
+}
+
+// NewSMTLibTranslator создаёт новый экземпляр SMT-LIB2 транслятора
+func NewSMTLibTranslator() *SMTLibTranslator {
+	return &SMTLibTranslator{
+		decls: make(map[string]smtDecl),
+	}
+}
+
+// GetContext у текстового транслятора нет контекста
+func (st *SMTLibTranslator) GetContext() interface{} {
+	return nil
+}
+
+// Reset забывает все собранные объявления
+func (st *SMTLibTranslator) Reset() {
+	st.decls = make(map[string]smtDecl)
+	st.order = nil
+	st.pending = 0
+}
+
+// TranslateExpression транслирует выражение в терм SMT-LIB2 (string)
+func (st *SMTLibTranslator) TranslateExpression(expr symbolic.SymbolicExpression) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if te, ok := r.(*TranslationError); ok {
+				result, err = nil, te
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	return st.term(expr), nil
+}
+
+// Declarations возвращает все объявления в порядке появления
+func (st *SMTLibTranslator) Declarations() []string {
+	res := make([]string, 0, len(st.order))
+	for _, name := range st.order {
+		res = append(res, st.decls[name].command)
+	}
+	return res
+}
+
+// TakeNewDeclarations возвращает объявления, появившиеся с прошлого вызова
+func (st *SMTLibTranslator) TakeNewDeclarations() []string {
+	res := make([]string, 0, len(st.order)-st.pending)
+	for _, name := range st.order[st.pending:] {
+		res = append(res, st.decls[name].command)
+	}
+	st.pending = len(st.order)
+	return res
+}
+
+// ForgetDeclarations откатывает объявления до указанного количества.
+// Нужен, чтобы повторить объявления после (pop) в решателе.
+func (st *SMTLibTranslator) ForgetDeclarations(count int) {
+	for _, name := range st.order[count:] {
+		delete(st.decls, name)
+	}
+	st.order = st.order[:count]
+	if st.pending > count {
+		st.pending = count
+	}
+}
+
+// DeclarationsCount возвращает количество собранных объявлений
+func (st *SMTLibTranslator) DeclarationsCount() int {
+	return len(st.order)
+}
+
+// Constants возвращает объявленные константы базовых типов:
+// символ SMT-LIB -> исходное имя и тип. По ним строится модель.
+func (st *SMTLibTranslator) Constants() map[string]symbolic.SymbolicVariable {
+	res := make(map[string]symbolic.SymbolicVariable)
+	for _, symbol := range st.order {
+		if decl := st.decls[symbol]; decl.isConst {
+			res[symbol] = symbolic.SymbolicVariable{Name: decl.name, ExprType: decl.ty}
+		}
+	}
+	return res
+}
+
+func (st *SMTLibTranslator) term(expr symbolic.SymbolicExpression) string {
+	if expr == nil {
+		panic(NewTranslationError("nil expression", expr))
+	}
+	return expr.Accept(st).(string)
+}
+
+func (st *SMTLibTranslator) declare(symbol string, decl smtDecl) {
+	if _, exists := st.decls[symbol]; exists {
+		return
+	}
+	st.decls[symbol] = decl
+	st.order = append(st.order, symbol)
+}
+
+func (st *SMTLibTranslator) VisitVariable(expr *symbolic.SymbolicVariable) interface{} {
+	symbol := SMTLibSymbol(expr.Name)
+	st.declare(symbol, smtDecl{
+		command: fmt.Sprintf("(declare-const %s %s)", symbol, smtSort(expr.ExprType, expr)),
+		name:    expr.Name,
+		ty:      expr.ExprType,
+		isConst: true,
+	})
+	return symbol
+}
+
+func (st *SMTLibTranslator) VisitIntConstant(expr *symbolic.IntConstant) interface{} {
+	return smtInt(expr.Value)
+}
+
+func (st *SMTLibTranslator) VisitFloatConstant(expr *symbolic.FloatConstant) interface{} {
+	return smtFloat(expr.Value)
+}
+
+func (st *SMTLibTranslator) VisitBoolConstant(expr *symbolic.BoolConstant) interface{} {
+	return strconv.FormatBool(expr.Value)
+}
+
+func (st *SMTLibTranslator) VisitBinaryOperation(expr *symbolic.BinaryOperation) interface{} {
+	left := st.term(expr.Left)
+	right := st.term(expr.Right)
+
+	if expr.Left.Type() == symbolic.FloatType {
+		switch expr.Operator {
+		case symbolic.ADD:
+			return fmt.Sprintf("(fp.add RNE %s %s)", left, right)
+		case symbolic.SUB:
+			return fmt.Sprintf("(fp.sub RNE %s %s)", left, right)
+		case symbolic.MUL:
+			return fmt.Sprintf("(fp.mul RNE %s %s)", left, right)
+		case symbolic.DIV:
+			return fmt.Sprintf("(fp.div RNE %s %s)", left, right)
+		case symbolic.EQ:
+			return fmt.Sprintf("(fp.eq %s %s)", left, right)
+		case symbolic.NE:
+			return fmt.Sprintf("(not (fp.eq %s %s))", left, right)
+		case symbolic.LT:
+			return fmt.Sprintf("(fp.lt %s %s)", left, right)
+		case symbolic.LE:
+			return fmt.Sprintf("(fp.leq %s %s)", left, right)
+		case symbolic.GT:
+			return fmt.Sprintf("(fp.gt %s %s)", left, right)
+		case symbolic.GE:
+			return fmt.Sprintf("(fp.geq %s %s)", left, right)
+		}
+		panic(NewTranslationError("unsupported float operator "+expr.Operator.String(), expr))
+	}
+
+	switch expr.Operator {
+	case symbolic.ADD:
+		return fmt.Sprintf("(+ %s %s)", left, right)
+	case symbolic.SUB:
+		return fmt.Sprintf("(- %s %s)", left, right)
+	case symbolic.MUL:
+		return fmt.Sprintf("(* %s %s)", left, right)
+	case symbolic.DIV:
+		// div и mod в SMT-LIB евклидовы, а Go округляет частное к нулю:
+		// отрицательное делимое делится по модулю, знак результата меняется
+		return fmt.Sprintf("(ite (>= %s 0) (div %s %s) (- (div (- %s) %s)))", left, left, right, left, right)
+	case symbolic.MOD:
+		return fmt.Sprintf("(ite (>= %s 0) (mod %s %s) (- (mod (- %s) %s)))", left, left, right, left, right)
+	case symbolic.EQ:
+		return fmt.Sprintf("(= %s %s)", left, right)
+	case symbolic.NE:
+		return fmt.Sprintf("(not (= %s %s))", left, right)
+	case symbolic.LT:
+		return fmt.Sprintf("(< %s %s)", left, right)
+	case symbolic.LE:
+		return fmt.Sprintf("(<= %s %s)", left, right)
+	case symbolic.GT:
+		return fmt.Sprintf("(> %s %s)", left, right)
+	case symbolic.GE:
+		return fmt.Sprintf("(>= %s %s)", left, right)
+	}
+
+	panic(NewTranslationError("unsupported operator "+expr.Operator.String(), expr))
+}
+
+func (st *SMTLibTranslator) VisitLogicalOperation(expr *symbolic.LogicalOperation) interface{} {
+	operands := make([]string, 0, len(expr.Operands))
+	for _, op := range expr.Operands {
+		operands = append(operands, st.term(op))
+	}
+
+	switch expr.Operator {
+	case symbolic.AND:
+		if len(operands) == 0 {
+			return "true"
+		}
+		if len(operands) == 1 {
+			return operands[0]
+		}
+		return "(and " + strings.Join(operands, " ") + ")"
+	case symbolic.OR:
+		if len(operands) == 0 {
+			return "false"
+		}
+		if len(operands) == 1 {
+			return operands[0]
+		}
+		return "(or " + strings.Join(operands, " ") + ")"
+	case symbolic.IMPLIES:
+		return fmt.Sprintf("(=> %s %s)", operands[0], operands[1])
+	}
+
+	panic(NewTranslationError("unsupported logical operator", expr))
+}
+
+func (st *SMTLibTranslator) VisitUnaryOperation(expr *symbolic.UnaryOperation) interface{} {
+	operand := st.term(expr.Operand)
+	isFloat := expr.Operand.Type() == symbolic.FloatType
+
+	switch expr.Operator {
+	case symbolic.NOT:
+		return fmt.Sprintf("(not %s)", operand)
+	case symbolic.MINUS:
+		if isFloat {
+			return fmt.Sprintf("(fp.neg %s)", operand)
+		}
+		return fmt.Sprintf("(- %s)", operand)
+	case symbolic.INCREMENT:
+		if isFloat {
+			return fmt.Sprintf("(fp.add RNE %s %s)", operand, smtFloat(1))
+		}
+		return fmt.Sprintf("(+ %s 1)", operand)
+	case symbolic.DECREMENT:
+		if isFloat {
+			return fmt.Sprintf("(fp.sub RNE %s %s)", operand, smtFloat(1))
+		}
+		return fmt.Sprintf("(- %s 1)", operand)
+	}
+
+	panic(NewTranslationError("unsupported unary operator", expr))
+}
+
+func (st *SMTLibTranslator) VisitConditional(expr *symbolic.ConditionalOperation) interface{} {
+	if len(expr.TrueBlock) == 0 || len(expr.FalseBlock) == 0 {
+		panic(NewTranslationError("conditional without branches", expr))
+	}
+
+	// Как и в Z3Translator, значением ветки считается последнее выражение
+	cond := st.term(expr.Condition)
+	btrue := st.term(expr.TrueBlock[len(expr.TrueBlock)-1])
+	bfalse := st.term(expr.FalseBlock[len(expr.FalseBlock)-1])
+
+	return fmt.Sprintf("(ite %s %s %s)", cond, btrue, bfalse)
+}
+
+func (st *SMTLibTranslator) VisitArray(expr *symbolic.SymbolicArray) interface{} {
+	symbol := SMTLibSymbol(expr.Name)
+	st.declare(symbol, smtDecl{
+		command: fmt.Sprintf("(declare-const %s (Array Int %s))", symbol, smtSort(expr.ElemType, expr)),
+		name:    expr.Name,
+		ty:      symbolic.ArrayType,
+	})
+	return symbol
+}
+
+func (st *SMTLibTranslator) VisitArrayAccess(expr *symbolic.ArrayAccess) interface{} {
+	return fmt.Sprintf("(select %s %s)", st.term(&expr.Array), st.term(expr.Index))
+}
+
+// VisitPointer как и Z3Translator, представляет адрес целым числом
+func (st *SMTLibTranslator) VisitPointer(expr *symbolic.SymbolicPointer) interface{} {
+	return smtInt(int64(expr.Address))
+}
+
+// VisitFieldAccess повторяет кодирование Z3Translator: каждое поле структуры -
+// массив Int -> T, индексированный константой ключа объекта
+func (st *SMTLibTranslator) VisitFieldAccess(expr *symbolic.FieldAccess) interface{} {
+	if expr.Key == nil {
+		panic(NewTranslationError("field access without key", expr))
+	}
+
+	fieldArray := st.fieldArray(expr.StructName, expr.FieldIdx, expr.Type(), expr)
+	index := st.fieldIndex(expr.Key.String(), expr.FieldIdx)
+	return fmt.Sprintf("(select %s %s)", fieldArray, index)
+}
+
+func (st *SMTLibTranslator) VisitFieldAssign(expr *symbolic.FieldAssign) interface{} {
+	fieldArray := st.fieldArray(expr.StructName, expr.FieldIdx, expr.Type(), expr)
+	index := st.fieldIndex(expr.Obj.String(), expr.FieldIdx)
+	return fmt.Sprintf("(store %s %s %s)", fieldArray, index, st.term(expr.Value))
+}
+
+func (st *SMTLibTranslator) VisitIndexAddr(expr *symbolic.IndexAddr) interface{} {
+	return smtInt(int64(int(expr.Ptr.Address)*1000 + expr.Index))
+}
+
+func (st *SMTLibTranslator) VisitFieldAddr(expr *symbolic.FieldAddr) interface{} {
+	return smtInt(int64(int(expr.Ptr.Address)*1000 + expr.FieldIndex))
+}
+
+func (st *SMTLibTranslator) VisitTuple(expr *symbolic.Tuple) interface{} {
+	panic(NewTranslationError("tuple is not an SMT-LIB term, extract its elements", expr))
+}
+
+// VisitFunction объявляет неинтерпретируемую функцию
+func (st *SMTLibTranslator) VisitFunction(expr *symbolic.Function) interface{} {
+	args := make([]string, 0, len(expr.Args))
+	for _, arg := range expr.Args {
+		args = append(args, smtSort(arg, expr))
+	}
+
+	symbol := SMTLibSymbol(expr.Name)
+	st.declare(symbol, smtDecl{
+		command: fmt.Sprintf("(declare-fun %s (%s) %s)", symbol, strings.Join(args, " "), smtSort(expr.ReturnType, expr)),
+		name:    expr.Name,
+		ty:      symbolic.FuncType,
+	})
+	return symbol
+}
+
+func (st *SMTLibTranslator) VisitFunctionCall(expr *symbolic.FunctionCall) interface{} {
+	symbol := st.VisitFunction(&expr.FunctionDecl).(string)
+	if len(expr.Args) == 0 {
+		return symbol
+	}
+
+	args := make([]string, 0, len(expr.Args))
+	for _, arg := range expr.Args {
+		args = append(args, st.term(arg))
+	}
+	return "(" + symbol + " " + strings.Join(args, " ") + ")"
+}
+
+func (st *SMTLibTranslator) fieldArray(structName string, fieldIdx int, ty symbolic.ExpressionType, expr symbolic.SymbolicExpression) string {
+	name := getFieldName(structName, fieldIdx)
+	symbol := SMTLibSymbol(name)
+	st.declare(symbol, smtDecl{
+		command: fmt.Sprintf("(declare-const %s (Array Int %s))", symbol, smtSort(ty, expr)),
+		name:    name,
+		ty:      symbolic.ArrayType,
+	})
+	return symbol
+}
+
+func (st *SMTLibTranslator) fieldIndex(key string, fieldIdx int) string {
+	name := getFieldName(key, fieldIdx)
+	symbol := SMTLibSymbol(name)
+	st.declare(symbol, smtDecl{
+		command: fmt.Sprintf("(declare-const %s Int)", symbol),
+		name:    name,
+		ty:      symbolic.IntType,
+	})
+	return symbol
+}
+
+// SMTLibScript строит самостоятельный SMT-LIB2 скрипт для конъюнкции ограничений:
+// объявления всех свободных символов, assert'ы, (check-sat) и (get-model).
+// Такой файл можно отдать любому решателю отдельно от инструмента.
+func SMTLibScript(comment string, constraints []symbolic.SymbolicExpression) (string, error) {
+	st := NewSMTLibTranslator()
+
+	asserts := make([]string, 0, len(constraints))
+	for _, c := range constraints {
+		term, err := st.TranslateExpression(c)
+		if err != nil {
+			return "", err
+		}
+		asserts = append(asserts, "(assert "+term.(string)+")")
+	}
+
+	var sb strings.Builder
+	for _, line := range strings.Split(comment, "\n") {
+		if line != "" {
+			sb.WriteString("; " + line + "\n")
+		}
+	}
+	sb.WriteString("(set-option :produce-models true)\n")
+	sb.WriteString("(set-logic ALL)\n")
+	for _, decl := range st.Declarations() {
+		sb.WriteString(decl + "\n")
+	}
+	for _, a := range asserts {
+		sb.WriteString(a + "\n")
+	}
+	sb.WriteString("(check-sat)\n")
+	sb.WriteString("(get-model)\n")
+
+	return sb.String(), nil
+}
+
+// SMTLibSymbol экранирует имя, если оно не является простым символом SMT-LIB2
+func SMTLibSymbol(name string) string {
+	if name == "" {
+		return "|_|"
+	}
+
+	simple := name[0] < '0' || name[0] > '9'
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			strings.ContainsRune("~!@$%^&*_-+=<>.?/", c)) {
+			simple = false
+			break
+		}
+	}
+	if simple {
+		return name
+	}
+
+	name = strings.NewReplacer("|", "_", "\\", "_").Replace(name)
+	return "|" + name + "|"
+}
+
+func smtSort(ty symbolic.ExpressionType, expr symbolic.SymbolicExpression) string {
+	switch ty {
+	case symbolic.IntType:
+		return "Int"
+	case symbolic.BoolType:
+		return "Bool"
+	case symbolic.FloatType:
+		return "(_ FloatingPoint 8 24)"
+	case symbolic.AddrType, symbolic.ObjType:
+		// Адреса и ссылки на объекты кодируются целыми, как в Z3Translator
+		return "Int"
+	default:
+		panic(NewTranslationError("no SMT-LIB sort for type "+ty.String(), expr))
+	}
+}
+
+func smtInt(value int64) string {
+	if value < 0 {
+		return "(- " + new(big.Int).Neg(big.NewInt(value)).String() + ")"
+	}
+	return strconv.FormatInt(value, 10)
+}
+
+func smtFloat(value float32) string {
+	v := float64(value)
+	switch {
+	case math.IsNaN(v):
+		return "(_ NaN 8 24)"
+	case math.IsInf(v, 1):
+		return "(_ +oo 8 24)"
+	case math.IsInf(v, -1):
+		return "(_ -oo 8 24)"
+	}
+
+	lit := strconv.FormatFloat(math.Abs(v), 'f', -1, 64)
+	if !strings.Contains(lit, ".") {
+		lit += ".0"
+	}
+	if v < 0 {
+		lit = "(- " + lit + ")"
+	}
+	return fmt.Sprintf("((_ to_fp 8 24) RNE %s)", lit)
+}
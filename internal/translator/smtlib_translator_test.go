@@ -0,0 +1,127 @@
+package translator
+
+import (
+	"strings"
+	"testing"
+
+	"symbolic-execution-course/internal/symbolic"
+)
+
+func TestSMTLibTranslateExpression(t *testing.T) {
+	st := NewSMTLibTranslator()
+
+	x := symbolic.NewSymbolicVariable("x", symbolic.IntType)
+	flag := symbolic.NewSymbolicVariable("flag", symbolic.BoolType)
+
+	// (x + 5 > -3) && !flag
+	expr := symbolic.NewLogicalOperation([]symbolic.SymbolicExpression{
+		symbolic.NewBinaryOperation(
+			symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(5), symbolic.ADD),
+			symbolic.NewIntConstant(-3),
+			symbolic.GT,
+		),
+		symbolic.NewUnaryOperation(flag, symbolic.NOT),
+	}, symbolic.AND)
+
+	term, err := st.TranslateExpression(expr)
+	if err != nil {
+		t.Fatalf("Unexpected translation error: %v", err)
+	}
+
+	expected := "(and (> (+ x 5) (- 3)) (not flag))"
+	if term != expected {
+		t.Errorf("Expected %s, got %s", expected, term)
+	}
+
+	decls := st.Declarations()
+	if len(decls) != 2 || decls[0] != "(declare-const x Int)" || decls[1] != "(declare-const flag Bool)" {
+		t.Errorf("Unexpected declarations: %v", decls)
+	}
+}
+
+func TestSMTLibDeclarationsAfterForget(t *testing.T) {
+	st := NewSMTLibTranslator()
+
+	st.TranslateExpression(symbolic.NewSymbolicVariable("a", symbolic.IntType))
+	if got := st.TakeNewDeclarations(); len(got) != 1 {
+		t.Fatalf("Expected 1 new declaration, got %v", got)
+	}
+
+	// Имитируем (push) ... (pop): объявление b должно быть выдано повторно
+	scope := st.DeclarationsCount()
+	st.TranslateExpression(symbolic.NewSymbolicVariable("b", symbolic.IntType))
+	st.TakeNewDeclarations()
+	st.ForgetDeclarations(scope)
+
+	st.TranslateExpression(symbolic.NewSymbolicVariable("b", symbolic.IntType))
+	got := st.TakeNewDeclarations()
+	if len(got) != 1 || got[0] != "(declare-const b Int)" {
+		t.Errorf("Expected b to be declared again, got %v", got)
+	}
+}
+
+func TestSMTLibScript(t *testing.T) {
+	arr := symbolic.NewSymbolicArray("arr", symbolic.IntType, 10)
+	i := symbolic.NewSymbolicVariable("weird name", symbolic.IntType)
+	f := symbolic.NewFunction("f", []symbolic.ExpressionType{symbolic.IntType}, symbolic.BoolType)
+
+	constraints := []symbolic.SymbolicExpression{
+		symbolic.NewBinaryOperation(symbolic.NewArrayAccess(*arr, i), symbolic.NewIntConstant(1), symbolic.EQ),
+		symbolic.NewFunctionCall(*f, []symbolic.SymbolicExpression{i}),
+	}
+
+	script, err := SMTLibScript("test", constraints)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, line := range []string{
+		"; test",
+		"(declare-const arr (Array Int Int))",
+		"(declare-const |weird name| Int)",
+		"(declare-fun f (Int) Bool)",
+		"(assert (= (select arr |weird name|) 1))",
+		"(assert (f |weird name|))",
+		"(check-sat)",
+	} {
+		if !strings.Contains(script, line+"\n") {
+			t.Errorf("Expected line %q in script:\n%s", line, script)
+		}
+	}
+}
+
+func TestSMTLibUnsupportedType(t *testing.T) {
+	st := NewSMTLibTranslator()
+
+	_, err := st.TranslateExpression(symbolic.NewSymbolicVariable("fn", symbolic.FuncType))
+	if err == nil {
+		t.Fatal("Expected translation error for function-typed variable")
+	}
+}
+
+func TestSMTLibArithmetic(t *testing.T) {
+	x := symbolic.NewSymbolicVariable("x", symbolic.IntType)
+	f := symbolic.NewSymbolicVariable("f", symbolic.FloatType)
+	one := "((_ to_fp 8 24) RNE 1.0)"
+
+	tests := []struct {
+		name string
+		expr symbolic.SymbolicExpression
+		want string
+	}{
+		// Деление и остаток Go округляют частное к нулю
+		{"div", symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(2), symbolic.DIV), "(ite (>= x 0) (div x 2) (- (div (- x) 2)))"},
+		{"mod", symbolic.NewBinaryOperation(x, symbolic.NewIntConstant(2), symbolic.MOD), "(ite (>= x 0) (mod x 2) (- (mod (- x) 2)))"},
+		{"increment", symbolic.NewUnaryOperation(x, symbolic.INCREMENT), "(+ x 1)"},
+		{"float increment", symbolic.NewUnaryOperation(f, symbolic.INCREMENT), "(fp.add RNE f " + one + ")"},
+		{"float decrement", symbolic.NewUnaryOperation(f, symbolic.DECREMENT), "(fp.sub RNE f " + one + ")"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term, err := NewSMTLibTranslator().TranslateExpression(tt.expr)
+			if err != nil || term != tt.want {
+				t.Errorf("term = %s, %v; want %s", term, err, tt.want)
+			}
+		})
+	}
+}
@@ -43,6 +43,11 @@ func (zt *Z3Translator) Reset() {
 	zt.vars = make(map[string]z3.Value)
 }
 
+// Vars возвращает кэш уже созданных Z3 переменных по их символьным именам
+func (zt *Z3Translator) Vars() map[string]z3.Value {
+	return zt.vars
+}
+
 // Close освобождает ресурсы
 func (zt *Z3Translator) Close() {
 	// Z3 контекст закрывается автоматически
@@ -139,7 +144,7 @@ func (zt *Z3Translator) VisitBinaryOperation(expr *symbolic.BinaryOperation) int
 	case symbolic.DIV:
 		switch expr.Left.Type() {
 		case symbolic.IntType:
-			return left.(z3.Int).Div(right.(z3.Int))
+			return zt.truncatedDivision(left.(z3.Int), right.(z3.Int), symbolic.DIV)
 		case symbolic.FloatType:
 			return left.(z3.Float).Div(right.(z3.Float))
 		default:
@@ -148,14 +153,14 @@ func (zt *Z3Translator) VisitBinaryOperation(expr *symbolic.BinaryOperation) int
 	case symbolic.MOD:
 		switch expr.Left.Type() {
 		case symbolic.IntType:
-			return left.(z3.Int).Mod(right.(z3.Int))
+			return zt.truncatedDivision(left.(z3.Int), right.(z3.Int), symbolic.MOD)
 		default:
 			panic("you are doing something wrong")
 		}
 	case symbolic.EQ:
 		switch expr.Left.Type() {
 		case symbolic.BoolType:
-			left.(z3.Bool).Eq(right.(z3.Bool))
+			return left.(z3.Bool).Eq(right.(z3.Bool))
 		case symbolic.IntType:
 			return left.(z3.Int).Eq(right.(z3.Int))
 		case symbolic.FloatType:
@@ -166,7 +171,7 @@ func (zt *Z3Translator) VisitBinaryOperation(expr *symbolic.BinaryOperation) int
 	case symbolic.NE:
 		switch expr.Left.Type() {
 		case symbolic.BoolType:
-			left.(z3.Bool).NE(right.(z3.Bool))
+			return left.(z3.Bool).NE(right.(z3.Bool))
 		case symbolic.IntType:
 			return left.(z3.Int).NE(right.(z3.Int))
 		case symbolic.FloatType:
@@ -258,6 +263,17 @@ func (zt *Z3Translator) VisitBinaryOperation(expr *symbolic.BinaryOperation) int
 }
 
 // VisitLogicalOperation транслирует логическую операцию в Z3
+// truncatedDivision кодирует целочисленные деление и остаток Go. div и mod
+// в Z3 евклидовы, а Go округляет частное к нулю: отрицательное делимое
+// делится по модулю, знак результата меняется.
+func (zt *Z3Translator) truncatedDivision(left, right z3.Int, op symbolic.BinaryOperator) z3.Int {
+	zero := zt.Ctx.FromInt(0, zt.Ctx.IntSort()).(z3.Int)
+	if op == symbolic.DIV {
+		return left.GE(zero).IfThenElse(left.Div(right), left.Neg().Div(right).Neg()).(z3.Int)
+	}
+	return left.GE(zero).IfThenElse(left.Mod(right), left.Neg().Mod(right).Neg()).(z3.Int)
+}
+
 func (zt *Z3Translator) VisitLogicalOperation(expr *symbolic.LogicalOperation) interface{} {
 	// 1. Транслировать все операнды
 	// 2. Применить соответствующую логическую операцию
@@ -329,15 +345,22 @@ func (zt *Z3Translator) VisitUnaryOperation(expr *symbolic.UnaryOperation) inter
 	case symbolic.NOT:
 		return operand.(z3.Bool).Not()
 	case symbolic.INCREMENT:
+		if f, ok := operand.(z3.Float); ok {
+			return f.Add(zt.Ctx.FromFloat32(1, zt.Ctx.FloatSort(8, 24)))
+		}
 		one := zt.Ctx.FromInt(1, zt.Ctx.IntSort()).(z3.Int)
 		return operand.(z3.Int).Add(one)
 	case symbolic.DECREMENT:
+		if f, ok := operand.(z3.Float); ok {
+			return f.Sub(zt.Ctx.FromFloat32(1, zt.Ctx.FloatSort(8, 24)))
+		}
 		one := zt.Ctx.FromInt(1, zt.Ctx.IntSort()).(z3.Int)
 		return operand.(z3.Int).Sub(one)
 	case symbolic.MINUS:
-		// Is this bad lol?
-		value, _, _ := operand.(z3.Int).AsInt64()
-		return zt.Ctx.FromInt(value*(-1), zt.Ctx.IntSort()).(z3.Int)
+		if f, ok := operand.(z3.Float); ok {
+			return f.Neg()
+		}
+		return operand.(z3.Int).Neg()
 	}
 
 	panic("unreachable")
@@ -478,6 +501,15 @@ func (zt *Z3Translator) VisitFieldAddr(expr *symbolic.FieldAddr) any {
 	return zt.Ctx.FromInt(int64(int(expr.Ptr.Address)*1000+expr.FieldIndex), zt.Ctx.IntSort())
 }
 
+// VisitTuple транслирует элементы кортежа: отдельного терма для него в Z3 нет
+func (zt *Z3Translator) VisitTuple(expr *symbolic.Tuple) any {
+	elements := make([]z3.Value, len(expr.Elements))
+	for i, element := range expr.Elements {
+		elements[i], _ = element.Accept(zt).(z3.Value)
+	}
+	return elements
+}
+
 func (zt *Z3Translator) VisitIndexAddr(expr *symbolic.IndexAddr) any {
 	base := expr.Ptr.Accept(zt)
 	if base == nil {
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const tupleSource = `package main
+
+func Pair(x int) (int, bool) {
+	if x > 0 {
+		return x, true
+	}
+	return 0, false
+}
+
+func UsePair(x int) int {
+	v, ok := Pair(x)
+	if ok && v > 3 {
+		return 1
+	}
+	return 0
+}
+
+func Swap(a, b int) (int, int) {
+	return b, a
+}
+
+func UseSwap(a int) int {
+	x, y := Swap(a, 7)
+	if x == 7 && y == a {
+		return 1
+	}
+	return 0
+}
+`
+
+func TestTupleResults(t *testing.T) {
+	tests := []struct {
+		function string
+		// returns - результаты путей, по одному на путь
+		returns []string
+	}{
+		{"Pair", []string{"(0, false)", "(x, true)"}},
+		{"UsePair", []string{"0", "0", "1"}},
+		{"Swap", []string{"(b, a)"}},
+		// Extract берёт элемент кортежа точно, поэтому ветка с 0 невыполнима
+		{"UseSwap", []string{"1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			fn, err := BuildFunction(map[string]string{"main.go": tupleSource}, tt.function)
+			if err != nil {
+				t.Fatalf("build: %v", err)
+			}
+			results, err := ExploreFunction(context.Background(), fn, Options{})
+			if err != nil {
+				t.Fatalf("explore: %v", err)
+			}
+			var returns []string
+			for _, result := range results {
+				if frame := result.GetCurrentFrame(); frame != nil && frame.ReturnValue != nil {
+					returns = append(returns, frame.ReturnValue.String())
+				}
+			}
+			sort.Strings(returns)
+			if strings.Join(returns, ",") != strings.Join(tt.returns, ",") {
+				t.Errorf("returns = %q, want %q", returns, tt.returns)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"fmt"
+	"go/token"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// UnsupportedError - конструкция программы, которую движок не умеет
+// исполнять. Глубоко внутри интерпретатора о ней сообщает unsupported,
+// а шаг состояния завершает путь с TerminalUnsupported, не прерывая
+// анализ остальных путей.
+type UnsupportedError struct {
+	// Pos - позиция конструкции; пустая, если неизвестна
+	Pos token.Position
+	// What - что именно не поддерживается
+	What string
+}
+
+func (e *UnsupportedError) Error() string {
+	if e.Pos.IsValid() {
+		return fmt.Sprintf("%s: unsupported %s", e.Pos, e.What)
+	}
+	return "unsupported " + e.What
+}
+
+// unsupported прерывает текущий шаг интерпретатора, см. interpretStep
+func unsupported(format string, args ...interface{}) {
+	panic(&UnsupportedError{What: fmt.Sprintf(format, args...)})
+}
+
+// interpretStep исполняет инструкцию состояния. Неподдерживаемая
+// конструкция завершает путь, остальные паники пробрасываются дальше.
+func (interpreter *Interpreter) interpretStep(instr ssa.Instruction) (next []*Interpreter) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		err, ok := r.(*UnsupportedError)
+		if !ok {
+			panic(r)
+		}
+		interpreter.stopUnsupported(instr, err)
+		next = []*Interpreter{interpreter}
+	}()
+	return interpreter.interpretDynamically(instr)
+}
+
+// stopUnsupported завершает путь на инструкции instr, которую нельзя исполнить
+func (interpreter *Interpreter) stopUnsupported(instr ssa.Instruction, err *UnsupportedError) {
+	if !err.Pos.IsValid() && instr.Parent() != nil {
+		err.Pos = instr.Parent().Prog.Fset.Position(instrPos(instr))
+	}
+	Logger.Debug("unsupported construct", "function", instr.Parent().String(), "instr", instr.String(), "err", err)
+
+	interpreter.CurrentBlock = nil
+	interpreter.Terminal = TerminalUnsupported
+	interpreter.TerminalInstr = instr
+	interpreter.Reason = err.Error()
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"sync"
+
+	"symbolic-execution-course/internal/solver"
+	"symbolic-execution-course/internal/symbolic"
+	"symbolic-execution-course/internal/translator"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// worker - исполнитель шагов анализа. Контекст Z3 нельзя использовать
+// из нескольких горутин одновременно, поэтому у каждого исполнителя
+// свой транслятор, решатель и кэш запросов.
+type worker struct {
+	Z3Translator *translator.Z3Translator
+	Solver       solver.Solver
+	SolverCache  *solver.Cache
+}
+
+func newWorker(options *Options) (*worker, error) {
+	z3Translator, z3Solver, err := newSolver(options.Solver, options.SolverTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &worker{
+		Z3Translator: z3Translator,
+		Solver:       z3Solver,
+		SolverCache:  solver.NewCache(z3Solver),
+	}, nil
+}
+
+// interrupt прерывает запрос, который решатель исполнителя выполняет сейчас.
+// Внешние решатели не прерываются: запрос к ним завершится сам.
+func (w *worker) interrupt() {
+	if interrupter, ok := w.Solver.(solver.Interrupter); ok {
+		interrupter.Interrupt()
+	}
+}
+
+func (w *worker) isSatisfiable(pathCondition *PathCondition, cond symbolic.SymbolicExpression) bool {
+	return checkSatisfiable(w.Solver, w.SolverCache, pathCondition, cond)
+}
+
+// step выполняет одну инструкцию состояния на решателе исполнителя
+func (w *worker) step(interpreter *Interpreter, instr ssa.Instruction) []*Interpreter {
+	interpreter.worker = w
+	return interpreter.interpretStep(instr)
+}
+
+// task - состояние, выбранное из очереди на текущий раунд
+type task struct {
+	interpreter Interpreter
+	instr       ssa.Instruction
+	next        []*Interpreter
+	panic       interface{}
+}
+
+// startWorkers возвращает исполнителей для раундов анализа. Первый работает
+// на решателе самого анализатора, остальные получают собственные контексты Z3.
+func (analyser *Analyser) startWorkers() []*worker {
+	n := 1
+	if analyser.Scheduler != nil && analyser.Scheduler.Workers > 1 {
+		n = analyser.Scheduler.Workers
+	}
+
+	workers := []*worker{{
+		Z3Translator: analyser.Z3Translator,
+		Solver:       analyser.Solver,
+		SolverCache:  analyser.SolverCache,
+	}}
+	for len(workers) < n {
+		w, err := newWorker(&analyser.options)
+		if err != nil {
+			// Анализ продолжается на уже запущенных исполнителях
+			Logger.Warn("failed to start worker", "err", err)
+			break
+		}
+		workers = append(workers, w)
+	}
+	return workers
+}
+
+// stopWorkers освобождает контексты Z3 дополнительных исполнителей
+// и добавляет статистику их кэшей к статистике анализатора
+func (analyser *Analyser) stopWorkers(workers []*worker) {
+	for _, w := range workers[1:] {
+		stats := w.SolverCache.Stats
+		analyser.SolverCache.Stats.Hits += stats.Hits
+		analyser.SolverCache.Stats.ModelReuses += stats.ModelReuses
+		analyser.SolverCache.Stats.CoreHits += stats.CoreHits
+		analyser.SolverCache.Stats.Misses += stats.Misses
+		w.Solver.Close()
+	}
+}
+
+// runRound исполняет по одному шагу каждой задачи раунда, i-я задача -
+// на i-м исполнителе. Раунды синхронные: все решения об очереди, дереве
+// исполнения и результатах принимаются после раунда в порядке задач,
+// поэтому результат анализа не зависит от планирования горутин.
+func runRound(workers []*worker, tasks []*task) {
+	if len(tasks) == 1 {
+		tasks[0].next = workers[0].step(&tasks[0].interpreter, tasks[0].instr)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i, t := range tasks {
+		wg.Add(1)
+		go func(w *worker, t *task) {
+			defer wg.Done()
+			defer func() {
+				t.panic = recover()
+			}()
+			t.next = w.step(&t.interpreter, t.instr)
+		}(workers[i], t)
+	}
+	wg.Wait()
+
+	// Паника исполнителя переносится в основную горутину, как при последовательном анализе
+	for _, t := range tasks {
+		if t.panic != nil {
+			panic(t.panic)
+		}
+	}
+}
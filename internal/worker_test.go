@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWorkersFindTheSamePaths(t *testing.T) {
+	// pathsWith исследует Forks и возвращает отсортированные условия путей и их модели
+	pathsWith := func(workers int) (string, *Report) {
+		report, err := AnalyseSources(context.Background(), map[string]string{"main.go": forksSource}, "Forks",
+			Options{Scheduler: &Scheduler{Workers: workers}, MaxStates: -1})
+		if err != nil {
+			t.Fatalf("analyse with %d workers: %v", workers, err)
+		}
+		var paths []string
+		for _, path := range report.Paths {
+			paths = append(paths, path.PathCondition+" -> "+path.ReturnValue)
+		}
+		sort.Strings(paths)
+		return strings.Join(paths, "\n"), report
+	}
+
+	want, _ := pathsWith(1)
+	for _, workers := range []int{2, 4, 8} {
+		got, report := pathsWith(workers)
+		if got != want {
+			t.Errorf("%d workers found\n%s\nwant\n%s", workers, got, want)
+		}
+		for _, path := range report.Paths {
+			if path.Model == nil {
+				t.Errorf("%d workers: path %s has no model", workers, path.PathCondition)
+			}
+		}
+	}
+}
@@ -0,0 +1,306 @@
+// Package symexec - публичный интерфейс движка символьного исполнения Go
+// программ. Пакет принимает исходный код или пакеты модуля, исследует пути
+// выбранной функции и возвращает их условия, результаты и входные данные,
+// ведущие по каждому пути. Типы пакета не зависят от внутреннего устройства
+// движка, которое может меняться.
+package symexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"symbolic-execution-course/internal"
+	"symbolic-execution-course/internal/ssabuilder"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// Options - настройки анализа. Нулевое значение поля означает значение
+// по умолчанию, так что Options{} - обход в глубину на 2000 шагов.
+type Options struct {
+	// Selector - стратегия выбора путей: dfs, bfs, random, random-path или
+	// coverage; через "+" стратегии чередуются, например "random-path:3+coverage:1".
+	// Пусто - dfs.
+	Selector string
+	// Seed - начальное значение для случайных стратегий
+	Seed int64
+
+	// MaxSteps - бюджет шагов анализа
+	MaxSteps int
+	// Timeout - бюджет времени анализа; 0 - без ограничения
+	Timeout time.Duration
+	// SolverTimeout - время на один запрос к решателю; 0 - без ограничения
+	SolverTimeout time.Duration
+	// MemoryLimit - размер кучи в байтах, выше которого состояния вытесняются
+	// из очереди; 0 - без ограничения
+	MemoryLimit uint64
+	// LoopBound - сколько раз путь может пройти по одному переходу цикла
+	LoopBound int
+	// MaxCallDepth - глубина вызовов, начиная с которой вызовы не исполняются
+	MaxCallDepth int
+	// MaxPathSteps - сколько инструкций может исполнить один путь
+	MaxPathSteps int
+	// MaxPathLength - сколько конъюнктов может быть в условии пути
+	MaxPathLength int
+	// MaxStates - сколько состояний может ждать в очереди
+	MaxStates int
+
+	// Solver - внешний SMT решатель, запускаемый процессом: z3, cvc5 или yices.
+	// Пусто - Z3 через встроенную привязку.
+	Solver string
+	// Globals - начальные значения глобальных переменных: zero (по умолчанию),
+	// init (сначала исполняется init пакета) или symbolic
+	Globals string
+	// Merge включает слияние состояний в точках слияния потока управления
+	Merge bool
+
+	// Checkpoint - файл, в который периодически записывается состояние
+	// анализа; пусто - без контрольных точек
+	Checkpoint string
+	// CheckpointInterval - как часто записывается контрольная точка;
+	// 0 - раз в минуту
+	CheckpointInterval time.Duration
+	// Resume продолжает анализ с контрольной точки из файла Checkpoint,
+	// если он есть. Программа должна быть той же, что и при записи.
+	Resume bool
+}
+
+// Outcome - чем закончился путь
+type Outcome string
+
+const (
+	// Return - функция вернула управление
+	Return Outcome = "return"
+	// Panic - путь закончился паникой
+	Panic Outcome = "panic"
+	// Truncated - путь оборван ограничением анализа
+	Truncated Outcome = "truncated"
+	// Unsupported - путь дошёл до конструкции, которую движок не умеет исполнять
+	Unsupported Outcome = "unsupported"
+)
+
+// Path - один найденный путь функции
+type Path struct {
+	// Condition - условие пути в нотации движка
+	Condition string
+	// SMTLib - условие пути термом SMT-LIB2; пусто, если его нельзя транслировать
+	SMTLib string
+	// ReturnValue - результат функции; пусто, если функция не вернула управление
+	ReturnValue string
+	Outcome     Outcome
+	// Limit - ограничение, оборвавшее путь с исходом Truncated: steps,
+	// timeout, path-steps, loop-bound, unrolls, path-length, states, memory
+	// или canceled
+	Limit string
+	// Reason - неподдерживаемая конструкция с позицией для пути с исходом Unsupported
+	Reason string
+	// Inputs - значения входов, ведущие по пути: int64, bool, float64, а для
+	// прочих значений - их запись строкой. nil, если решатель не нашёл модель.
+	Inputs map[string]interface{}
+	// Blocks - пройденные базовые блоки
+	Blocks []Block
+	// Steps - число исполненных путём инструкций
+	Steps int
+}
+
+// Block - базовый блок SSA функции
+type Block struct {
+	Function string
+	Index    int
+}
+
+// Finding - проблема, найденная анализом
+type Finding struct {
+	// Kind - panic, runtime-error, assertion или unreachable
+	Kind     string
+	Message  string
+	Function string
+	File     string
+	Line     int
+	Column   int
+	// Inputs - значения входов, на которых проблема воспроизводится
+	Inputs map[string]interface{}
+}
+
+// Result - результат анализа функции
+type Result struct {
+	Function string
+	Paths    []Path
+	Findings []Finding
+	// Steps - число шагов анализа
+	Steps int
+	// Complete сообщает, что все пути исследованы до конца:
+	// ни один не оборван ограничениями и бюджеты не исчерпаны
+	Complete bool
+	// Cuts - сколько путей оборвало каждое ограничение
+	Cuts map[string]int
+}
+
+// AnalyseSource анализирует функцию function из исходного кода одного файла
+// пакета. Функция задаётся именем, методы - как Type.Method. При отмене ctx
+// или истечении его срока анализ останавливается и возвращает пути,
+// найденные к этому моменту, с Complete = false.
+func AnalyseSource(ctx context.Context, source, function string, options Options) (*Result, error) {
+	return AnalyseFiles(ctx, map[string]string{"main.go": source}, function, options)
+}
+
+// AnalyseFiles - как AnalyseSource для пакета из нескольких файлов:
+// ключ - имя файла, значение - его исходный код
+func AnalyseFiles(ctx context.Context, files map[string]string, function string, options Options) (*Result, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no sources to analyse")
+	}
+	var fn *ssa.Function
+	err := protect(func() (err error) {
+		fn, err = ssabuilder.NewBuilder().ParseFilesAndBuildSSA(files, function)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return analyse(ctx, fn, options)
+}
+
+// AnalysePackage загружает пакеты модуля по шаблону go list относительно
+// каталога dir и анализирует функцию function. Имя может быть
+// квалифицировано путём пакета: "example.com/m/pkg.Func".
+func AnalysePackage(ctx context.Context, dir, pattern, function string, options Options) (*Result, error) {
+	var fn *ssa.Function
+	err := protect(func() error {
+		prog, err := ssabuilder.LoadPackages(dir, pattern)
+		if err != nil {
+			return err
+		}
+		fn, err = prog.Func(function)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return analyse(ctx, fn, options)
+}
+
+func analyse(ctx context.Context, fn *ssa.Function, options Options) (*Result, error) {
+	internalOptions, err := options.internal()
+	if err != nil {
+		return nil, err
+	}
+
+	var report *internal.Report
+	err = protect(func() (err error) {
+		report, err = internal.AnalyseFunctionWithOptions(ctx, fn, internalOptions)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("analysis of %s failed: %w", fn, err)
+	}
+	return newResult(report), nil
+}
+
+// internal переводит настройки в настройки движка
+func (options Options) internal() (internal.Options, error) {
+	res := internal.Options{
+		MaxSteps:      options.MaxSteps,
+		Timeout:       options.Timeout,
+		SolverTimeout: options.SolverTimeout,
+		MemoryLimit:   options.MemoryLimit,
+		MaxStates:     options.MaxStates,
+		LoopBound:     options.LoopBound,
+		MaxCallDepth:  options.MaxCallDepth,
+		MaxPathSteps:  options.MaxPathSteps,
+		MaxPathLength: options.MaxPathLength,
+		Solver:        options.Solver,
+
+		Checkpoint:         options.Checkpoint,
+		CheckpointInterval: options.CheckpointInterval,
+	}
+
+	if options.Selector != "" {
+		selector, err := internal.ParseSelector(options.Selector, options.Seed)
+		if err != nil {
+			return res, err
+		}
+		res.Selector = selector
+	}
+	if options.Globals != "" {
+		globals, err := internal.ParseGlobalsMode(options.Globals)
+		if err != nil {
+			return res, err
+		}
+		res.Globals = globals
+	}
+	if options.Merge {
+		res.Merger = internal.NewStateMerger()
+	}
+	if options.Resume && options.Checkpoint != "" {
+		checkpoint, err := internal.ReadCheckpoint(options.Checkpoint)
+		switch {
+		case err == nil:
+			res.Resume = checkpoint
+		case !errors.Is(err, fs.ErrNotExist):
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+func newResult(report *internal.Report) *Result {
+	res := &Result{
+		Function: report.Function,
+		Paths:    make([]Path, 0, len(report.Paths)),
+		Steps:    report.Stats.Steps,
+		Complete: !report.Stats.Truncated,
+	}
+	for _, path := range report.Paths {
+		p := Path{
+			Condition:   path.PathCondition,
+			SMTLib:      path.SMTLib,
+			ReturnValue: path.ReturnValue,
+			Outcome:     Outcome(path.Terminal.String()),
+			Reason:      path.Reason,
+			Inputs:      path.Model,
+			Steps:       path.Steps,
+		}
+		if path.Limit != internal.LimitNone {
+			p.Limit = path.Limit.String()
+		}
+		for _, block := range path.CoveredBlocks {
+			p.Blocks = append(p.Blocks, Block{Function: block.Function, Index: block.Block})
+		}
+		res.Paths = append(res.Paths, p)
+		if p.Outcome == Truncated || p.Outcome == Unsupported {
+			res.Complete = false
+		}
+	}
+	for limit, n := range report.Stats.Cuts {
+		if res.Cuts == nil {
+			res.Cuts = make(map[string]int)
+		}
+		res.Cuts[limit.String()] = n
+	}
+	for _, finding := range report.Findings {
+		res.Findings = append(res.Findings, Finding{
+			Kind:     finding.Kind.String(),
+			Message:  finding.Message,
+			Function: finding.Function,
+			File:     finding.Location.File,
+			Line:     finding.Location.Line,
+			Column:   finding.Location.Column,
+			Inputs:   finding.Inputs,
+		})
+	}
+	return res
+}
+
+// protect превращает панику движка в ошибку
+func protect(f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal error: %v", r)
+		}
+	}()
+	return f()
+}
@@ -0,0 +1,192 @@
+package symexec
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const source = `package main
+
+func Classify(x int) int {
+	if x > 10 {
+		return 1
+	}
+	if x < 0 {
+		panic("negative")
+	}
+	return 0
+}
+`
+
+func TestAnalyseSource(t *testing.T) {
+	res, err := AnalyseSource(context.Background(), source, "Classify", Options{})
+	if err != nil {
+		t.Fatalf("AnalyseSource: %v", err)
+	}
+	if !res.Complete {
+		t.Error("expected all paths to be explored")
+	}
+
+	outcomes := make(map[Outcome]int)
+	for _, path := range res.Paths {
+		outcomes[path.Outcome]++
+		if path.Inputs == nil {
+			t.Errorf("path %s has no inputs", path.Condition)
+			continue
+		}
+		x, ok := path.Inputs["x"].(int64)
+		if !ok {
+			t.Errorf("path %s: input x = %v, want int64", path.Condition, path.Inputs["x"])
+			continue
+		}
+		switch {
+		case path.Outcome == Panic && x >= 0:
+			t.Errorf("panic path with x = %d", x)
+		case path.ReturnValue == "1" && x <= 10:
+			t.Errorf("path returning 1 with x = %d", x)
+		}
+	}
+	if outcomes[Return] != 2 || outcomes[Panic] != 1 {
+		t.Errorf("outcomes = %v, want 2 returns and 1 panic", outcomes)
+	}
+
+	if len(res.Findings) != 1 || res.Findings[0].Kind != "panic" {
+		t.Errorf("findings = %+v, want one panic", res.Findings)
+	}
+}
+
+func TestAnalyseSourceErrors(t *testing.T) {
+	if _, err := AnalyseSource(context.Background(), source, "Missing", Options{}); err == nil {
+		t.Error("expected an error for a missing function")
+	}
+	if _, err := AnalyseSource(context.Background(), "package main\nfunc F( {", "F", Options{}); err == nil {
+		t.Error("expected an error for invalid source")
+	}
+	if _, err := AnalyseSource(context.Background(), "package main\nfunc F() T { return 0 }", "F", Options{}); err == nil || !strings.Contains(err.Error(), "main.go:2:10") {
+		t.Errorf("err = %v, want a type error with its position", err)
+	}
+	if _, err := AnalyseSource(context.Background(), source, "Classify", Options{Selector: "unknown"}); err == nil {
+		t.Error("expected an error for an unknown selector")
+	}
+}
+
+func TestAnalyseSourceUnsupported(t *testing.T) {
+	const unsupportedSource = `package main
+
+func Load(p *float64, x int) int {
+	if x > 0 {
+		return 1
+	}
+	if *p > 0 {
+		return 2
+	}
+	return 0
+}
+`
+	res, err := AnalyseSource(context.Background(), unsupportedSource, "Load", Options{})
+	if err != nil {
+		t.Fatalf("AnalyseSource: %v", err)
+	}
+	if res.Complete {
+		t.Error("expected an analysis with unsupported paths to be incomplete")
+	}
+
+	outcomes := make(map[Outcome]int)
+	for _, path := range res.Paths {
+		outcomes[path.Outcome]++
+		if path.Outcome == Unsupported && !strings.Contains(path.Reason, "main.go:7:") {
+			t.Errorf("reason = %q, want the position of the load", path.Reason)
+		}
+	}
+	if outcomes[Return] != 1 || outcomes[Unsupported] != 1 {
+		t.Errorf("outcomes = %v, want 1 return and 1 unsupported", outcomes)
+	}
+}
+
+func TestAnalyseSourceCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res, err := AnalyseSource(ctx, source, "Classify", Options{})
+	if err != nil {
+		t.Fatalf("AnalyseSource: %v", err)
+	}
+	if res.Complete {
+		t.Error("expected a canceled analysis to be incomplete")
+	}
+	if res.Cuts["canceled"] == 0 {
+		t.Errorf("cuts = %v, want states cut by cancellation", res.Cuts)
+	}
+}
+
+func TestAnalyseSourceResume(t *testing.T) {
+	ctx := context.Background()
+	checkpoint := filepath.Join(t.TempDir(), "Classify.checkpoint.json")
+
+	partial, err := AnalyseSource(ctx, source, "Classify", Options{MaxSteps: 5, Checkpoint: checkpoint})
+	if err != nil {
+		t.Fatalf("AnalyseSource: %v", err)
+	}
+	if partial.Complete {
+		t.Fatal("expected the step budget to cut the analysis")
+	}
+
+	res, err := AnalyseSource(ctx, source, "Classify", Options{Checkpoint: checkpoint, Resume: true})
+	if err != nil {
+		t.Fatalf("AnalyseSource: %v", err)
+	}
+	if !res.Complete {
+		t.Error("expected the resumed analysis to explore all paths")
+	}
+	outcomes := make(map[Outcome]int)
+	for _, path := range res.Paths {
+		outcomes[path.Outcome]++
+	}
+	if outcomes[Return] != 2 || outcomes[Panic] != 1 {
+		t.Errorf("outcomes = %v, want 2 returns and 1 panic", outcomes)
+	}
+}
+
+func TestAnalyseSourceCallDepth(t *testing.T) {
+	const calls = `package main
+
+func Sum(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	return n + Sum(n-1)
+}
+
+func Wrap(x int) int {
+	return twice(x)
+}
+
+func twice(x int) int {
+	return double(x)
+}
+
+func double(x int) int {
+	return x * 2
+}
+`
+	tests := []struct {
+		function string
+		options  Options
+		cut      bool
+	}{
+		{"Sum", Options{}, true},
+		{"Wrap", Options{MaxCallDepth: 1}, true},
+		{"Wrap", Options{}, false},
+	}
+	for _, tt := range tests {
+		res, err := AnalyseSource(context.Background(), calls, tt.function, tt.options)
+		if err != nil {
+			t.Fatalf("AnalyseSource(%s): %v", tt.function, err)
+		}
+		if cut := res.Cuts["call-depth"] > 0; cut != tt.cut || res.Complete == tt.cut {
+			t.Errorf("%s with %+v: complete %v, cuts %v; want call-depth cut %v", tt.function, tt.options, res.Complete, res.Cuts, tt.cut)
+		}
+	}
+}
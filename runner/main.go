@@ -1,35 +1,345 @@
 package main
 
 import (
+    "context"
+    "encoding/json"
+    "errors"
     "flag"
     "fmt"
     "go/ast"
     "go/parser"
     "go/token"
+    "io/fs"
+    "log/slog"
     "os"
     "path/filepath"
+    "runtime/debug"
     "strings"
+    "time"
 
     "symbolic-execution-course/internal"
+    "symbolic-execution-course/internal/ssabuilder"
+    "symbolic-execution-course/internal/translator"
+
+    "golang.org/x/tools/go/ssa"
 )
 
-func runTest(name, source, funcName string) {
+// newScheduler creates a state scheduler from the command line settings
+func newScheduler(policy string, spillDir string, workers int) (*internal.Scheduler, error) {
+    scheduler := internal.NewScheduler()
+    scheduler.Workers = workers
+    scheduler.SpillDir = spillDir
+
+    switch policy {
+    case "drop":
+        scheduler.Policy = internal.EvictDrop
+    case "replay":
+        scheduler.Policy = internal.EvictReplay
+    case "spill":
+        scheduler.Policy = internal.EvictSpill
+    default:
+        return nil, fmt.Errorf("unknown eviction policy %q", policy)
+    }
+    return scheduler, nil
+}
+
+// output describes where results of each analysed function go
+type output struct {
+    format  string
+    smt2Dir string
+    dotDir  string
+}
+
+func runTest(ctx context.Context, name, fileName, source, funcName string, out output, options internal.Options) {
+    if out.format != "text" {
+        report, err := internal.AnalyseSources(ctx, map[string]string{fileName: source}, funcName, options)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "failed to analyse %s: %v\n", funcName, err)
+            return
+        }
+        writeReport(funcName, out, report)
+        return
+    }
+
     fmt.Printf("\n======== Test %s =========\n", name)
 
     // print file content
     fmt.Println("File content:")
     fmt.Println(source)
 
-    results := internal.Analyse(source, funcName)
+    fn, err := internal.BuildFunction(map[string]string{fileName: source}, funcName)
+    if err == nil {
+        var results []*internal.Interpreter
+        if results, err = internal.ExploreFunction(ctx, fn, options); err == nil {
+            printResults(funcName, out, options.Selector, results)
+        }
+    }
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "failed to analyse %s: %v\n", funcName, err)
+    }
+    fmt.Printf("\n======== End of Test %s =========\n", name)
+}
+
+// runFunction analyses a function loaded with go/packages
+func runFunction(ctx context.Context, fn *ssa.Function, out output, options internal.Options) {
+    if out.format != "text" {
+        report, err := internal.AnalyseFunctionWithOptions(ctx, fn, options)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "failed to analyse %s: %v\n", fn, err)
+            return
+        }
+        writeReport(fn.String(), out, report)
+        return
+    }
+
+    fmt.Printf("\n======== Test %s =========\n", fn)
+
+    results, err := internal.ExploreFunction(ctx, fn, options)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "failed to analyse %s: %v\n", fn, err)
+    }
+    printResults(fn.String(), out, options.Selector, results)
+    fmt.Printf("\n======== End of Test %s =========\n", fn)
+}
 
+func printResults(funcName string, out output, selector internal.PathSelector, results []*internal.Interpreter) {
     for i, interpreter := range results {
         fmt.Printf("* Path %d:\n", i)
         fmt.Printf("  - Path condition: %s\n", interpreter.PathCondition.String())
+        if interpreter.Terminal == internal.TerminalTruncated {
+            fmt.Printf("  - Cut by: %s\n", interpreter.Limit)
+        }
+        if interpreter.Terminal == internal.TerminalUnsupported {
+            fmt.Printf("  - Unsupported: %s\n", interpreter.Reason)
+        }
         if frame := interpreter.GetCurrentFrame(); frame != nil && frame.ReturnValue != nil {
             fmt.Printf("  - Return value: %s\n\n", frame.ReturnValue.String())
         }
+        if out.smt2Dir != "" {
+            if err := dumpSMT2(out.smt2Dir, funcName, i, interpreter); err != nil {
+                fmt.Fprintf(os.Stderr, "failed to dump path %d of %s: %v\n", i, funcName, err)
+            }
+        }
+    }
+    if out.dotDir != "" {
+        if err := dumpDOT(out.dotDir, funcName, results); err != nil {
+            fmt.Fprintf(os.Stderr, "failed to dump execution tree of %s: %v\n", funcName, err)
+        }
+    }
+    if coverProfile != nil {
+        coverProfile.Add(results)
+    }
+    if coverage, ok := selector.(*internal.CoverageSelector); ok {
+        fmt.Printf("Covered blocks: %d, covered edges: %d\n", coverage.CoveredBlocks(), coverage.CoveredEdges())
     }
-    fmt.Printf("\n======== End of Test %s =========\n", name)
+}
+
+// writeReport prints the report as one JSON document per line,
+// or keeps it for the SARIF log written when the run ends
+func writeReport(funcName string, out output, report *internal.Report) {
+    if out.format == "sarif" {
+        sarifReports = append(sarifReports, report)
+    } else {
+        encoder := json.NewEncoder(os.Stdout)
+        encoder.SetEscapeHTML(false)
+        if err := encoder.Encode(report); err != nil {
+            fmt.Fprintf(os.Stderr, "failed to encode report of %s: %v\n", funcName, err)
+        }
+    }
+    if out.smt2Dir != "" {
+        for i, interpreter := range report.Results {
+            if err := dumpSMT2(out.smt2Dir, funcName, i, interpreter); err != nil {
+                fmt.Fprintf(os.Stderr, "failed to dump path %d of %s: %v\n", i, funcName, err)
+            }
+        }
+    }
+    if out.dotDir != "" {
+        if err := dumpDOT(out.dotDir, funcName, report.Results); err != nil {
+            fmt.Fprintf(os.Stderr, "failed to dump execution tree of %s: %v\n", funcName, err)
+        }
+    }
+    if coverProfile != nil {
+        coverProfile.Add(report.Results)
+    }
+}
+
+// runTarget searches for inputs that drive funcName to the target instruction
+func runTarget(ctx context.Context, fileName, source, funcName string, target internal.Target, options internal.Options) {
+    fmt.Printf("\n======== Target %s from %s =========\n", target, funcName)
+
+    result, err := internal.AnalyseTarget(ctx, map[string]string{fileName: source}, funcName, target, options)
+    printTargetResult(target, result, err)
+}
+
+// runTargetFunction is runTarget for a function loaded with go/packages
+func runTargetFunction(ctx context.Context, fn *ssa.Function, target internal.Target, options internal.Options) {
+    fmt.Printf("\n======== Target %s from %s =========\n", target, fn)
+
+    result, err := internal.AnalyseTargetFunction(ctx, fn, target, options)
+    printTargetResult(target, result, err)
+}
+
+func printTargetResult(target internal.Target, result *internal.TargetResult, err error) {
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "failed to analyse target: %v\n", err)
+        return
+    }
+
+    fmt.Printf("  - Status: %s after %d steps\n", result.Status, result.Steps)
+    if result.State != nil {
+        fmt.Printf("  - Path condition: %s\n", result.State.PathCondition.String())
+        fmt.Printf("  - Inputs: %s\n", result.Inputs.String())
+    }
+    fmt.Printf("\n======== End of Target %s =========\n", target)
+}
+
+// dumpSMT2 writes the path condition of one path as a standalone SMT-LIB2 script
+func dumpSMT2(dir, funcName string, index int, interpreter *internal.Interpreter) error {
+    comment := fmt.Sprintf("function: %s\npath: %d\npath condition: %s", funcName, index, interpreter.PathCondition.String())
+    script, err := translator.SMTLibScript(comment, interpreter.PathCondition.Constraints())
+    if err != nil {
+        return err
+    }
+
+    if err := os.MkdirAll(dir, 0o755); err != nil {
+        return err
+    }
+    path := filepath.Join(dir, fmt.Sprintf("%s_path%d.smt2", fileKey(funcName), index))
+    return os.WriteFile(path, []byte(script), 0o644)
+}
+
+// setupLogging routes the analysis log to stderr at the given level
+func setupLogging(level string) error {
+    if level == "off" {
+        return nil
+    }
+    var parsed slog.Level
+    if err := parsed.UnmarshalText([]byte(level)); err != nil {
+        return fmt.Errorf("unknown log level %q: expected debug, info, warn, error or off", level)
+    }
+    logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: parsed}))
+    internal.Logger = logger
+    ssabuilder.Logger = logger
+    return nil
+}
+
+// coverProfile accumulates source coverage of all analysed functions for -coverprofile
+var coverProfile *internal.CoverageProfile
+
+// writeCoverProfile writes the accumulated coverage profile to path
+func writeCoverProfile(path string) error {
+    file, err := os.Create(path)
+    if err != nil {
+        return err
+    }
+    if _, err := coverProfile.WriteTo(file); err != nil {
+        file.Close()
+        return err
+    }
+    return file.Close()
+}
+
+// dumpDOT writes the execution tree that produced results as a Graphviz file
+func dumpDOT(dir, funcName string, results []*internal.Interpreter) error {
+    if len(results) == 0 || results[0].Node == nil {
+        return nil
+    }
+    if err := os.MkdirAll(dir, 0o755); err != nil {
+        return err
+    }
+    file, err := os.Create(filepath.Join(dir, fileKey(funcName)+".dot"))
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+    return internal.WriteDOT(file, results[0].Node.Root(), results)
+}
+
+// fileKey turns a function name into a file name. Functions loaded with
+// go/packages are named by fn.String(), so functions and methods with the same
+// name in different packages or on different types get different files.
+func fileKey(funcName string) string {
+    return strings.NewReplacer("/", "_", "\\", "_", "(", "", ")", "", "*", "", " ", "_").Replace(funcName)
+}
+
+// checkpointFor returns the checkpoint file of funcName in dir and, when
+// resuming, the checkpoint previously written there
+func checkpointFor(dir, funcName string, resume bool) (string, *internal.Checkpoint) {
+    if dir == "" {
+        return "", nil
+    }
+    path := filepath.Join(dir, fileKey(funcName)+".checkpoint.json")
+    if !resume {
+        return path, nil
+    }
+    checkpoint, err := internal.ReadCheckpoint(path)
+    if errors.Is(err, fs.ErrNotExist) {
+        // Nothing to resume from yet, the function starts from scratch
+        return path, nil
+    }
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(1)
+    }
+    return path, checkpoint
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+    var res []string
+    for _, part := range strings.Split(value, ",") {
+        if item := strings.TrimSpace(part); item != "" {
+            res = append(res, item)
+        }
+    }
+    return res
+}
+
+// runPackages loads packages with go/packages and analyses their functions.
+// Function names may be qualified with the package path.
+func runPackages(ctx context.Context, dir string, patterns, fnNames []string, target *internal.Target, out output, newOptions func(funcName string) internal.Options) {
+    program, err := ssabuilder.LoadPackages(dir, patterns...)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(1)
+    }
+
+    var fns []*ssa.Function
+    if len(fnNames) == 0 {
+        fns = program.Functions()
+    }
+    for _, name := range fnNames {
+        fn, err := program.Func(name)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            os.Exit(2)
+        }
+        fns = append(fns, fn)
+    }
+    if len(fns) == 0 {
+        fmt.Fprintln(os.Stderr, "no functions found in the loaded packages")
+        return
+    }
+
+    for _, fn := range fns {
+        if target != nil {
+            guard(fn.String(), func() { runTargetFunction(ctx, fn, *target, newOptions(fn.String())) })
+            continue
+        }
+        guard(fn.String(), func() { runFunction(ctx, fn, out, newOptions(fn.String())) })
+    }
+}
+
+// guard runs the analysis of one function so that an engine failure on it
+// is reported and the batch goes on with the other functions
+func guard(funcName string, analyse func()) {
+    defer func() {
+        if r := recover(); r != nil {
+            fmt.Fprintf(os.Stderr, "failed to analyse %s: internal error: %v\n", funcName, r)
+            internal.Logger.Debug("internal error", "function", funcName, "stack", string(debug.Stack()))
+        }
+    }()
+    analyse()
 }
 
 func loadSource(root string) (string, error) {
@@ -62,27 +372,12 @@ func loadSource(root string) (string, error) {
         return nil
     }
 
-    if info.IsDir() {
-        err = filepath.WalkDir(absRoot, func(p string, d os.DirEntry, walkErr error) error {
-            if walkErr != nil || d.IsDir() {
-                return walkErr
-            }
-            if strings.HasSuffix(p, ".go") {
-                return readFile(p)
-            }
-            return nil
-        })
-        if err != nil {
-            return "", err
-        }
-    } else {
-        if strings.HasSuffix(absRoot, ".go") {
-            if err := readFile(absRoot); err != nil {
-                return "", err
-            }
-        } else {
-            return "", fmt.Errorf("%s is not a .go file", absRoot)
-        }
+    // Directories are loaded as packages, see runPackages
+    if info.IsDir() || !strings.HasSuffix(absRoot, ".go") {
+        return "", fmt.Errorf("%s is not a .go file", absRoot)
+    }
+    if err := readFile(absRoot); err != nil {
+        return "", err
     }
     return sb.String(), nil
 }
@@ -95,10 +390,22 @@ func collectFuncNames(source string) ([]string, error) {
     }
     var names []string
     ast.Inspect(file, func(n ast.Node) bool {
-        if fn, ok := n.(*ast.FuncDecl); ok && fn.Name != nil {
-            if fn.Recv == nil {
-                names = append(names, fn.Name.Name)
+        fn, ok := n.(*ast.FuncDecl)
+        if !ok || fn.Name == nil || fn.Type.TypeParams != nil {
+            return true
+        }
+        if fn.Recv == nil {
+            names = append(names, fn.Name.Name)
+            return true
+        }
+        // Methods are listed as Type.Method; generic receivers need type arguments and are skipped
+        switch recv := fn.Recv.List[0].Type.(type) {
+        case *ast.StarExpr:
+            if ident, ok := recv.X.(*ast.Ident); ok {
+                names = append(names, ident.Name+"."+fn.Name.Name)
             }
+        case *ast.Ident:
+            names = append(names, recv.Name+"."+fn.Name.Name)
         }
         return true
     })
@@ -106,37 +413,195 @@ func collectFuncNames(source string) ([]string, error) {
 }
 
 func main() {
-    pathFlag := flag.String("path", ".", "relative path to a .go file or a directory containing .go files")
-    funcFlag := flag.String("func", "", "comma‑separated list of function names to test (optional). If omitted, all functions are tested.")
+    pathFlag := flag.String("path", ".", "relative path to a .go file or a package directory")
+    funcFlag := flag.String("func", "", "comma‑separated list of function names to test (optional). If omitted, all functions are tested. With -pkg or a directory, names may be qualified: path/to/pkg.Func")
+    smt2Flag := flag.String("smt2-dir", "", "directory to dump one SMT-LIB2 file per found path (optional)")
+    selectorFlag := flag.String("selector", "dfs", "path selection strategy: dfs, bfs, random, random-path or coverage; join with + to interleave, e.g. random-path:3+coverage:1")
+    seedFlag := flag.Int64("seed", 0, "seed for the random path selectors")
+    maxStepsFlag := flag.Int("max-steps", 2000, "maximum number of analysis steps per function")
+    timeoutFlag := flag.Duration("timeout", 0, "time budget per function, e.g. 30s (0 for no limit)")
+    solverTimeoutFlag := flag.Duration("solver-timeout", 0, "time budget per solver query; a query that runs out is treated as satisfiable (0 for no limit)")
+    solverFlag := flag.String("solver", "", "external SMT solver run as a process: z3, cvc5 or yices (default: Z3 through the bindings)")
+    loopBoundFlag := flag.Int("loop-bound", 10, "how many times a path may enter the same block through a jump")
+    callDepthFlag := flag.Int("call-depth", 50, "call depth from which calls are not executed")
+    pathLengthFlag := flag.Int("max-path-length", 50, "maximum number of conjuncts in a path condition")
+    maxStatesFlag := flag.Int("max-states", 100, "maximum number of states kept in the queue (0 for no limit)")
+    evictFlag := flag.String("evict", "drop", "what to do with states evicted from a full queue: drop, replay or spill")
+    memoryFlag := flag.Uint64("memory-limit", 0, "heap size in MiB above which states are evicted (0 for no limit)")
+    spillDirFlag := flag.String("spill-dir", "", "directory for spilled states (default: system temp directory)")
+    mergeFlag := flag.Bool("merge", false, "merge states at control flow join points")
+    mergeCostFlag := flag.Int("merge-cost", 64, "maximum size of ite terms and path disjunction a merge may add")
+    globalsFlag := flag.String("globals", "zero", "initial package-level variables: zero, init (run the package init first) or symbolic")
+    workersFlag := flag.Int("workers", 1, "number of workers stepping states from the shared queue in parallel")
+    pkgFlag := flag.String("pkg", "", "comma-separated go/packages patterns to analyse instead of -path, e.g. ./... or a package import path (optional)")
+    targetFlag := flag.String("target", "", "file.go:line or Function#instr to reach from each tested function (optional)")
+    dotFlag := flag.String("dot-dir", "", "directory to write the execution tree of each function as a Graphviz DOT file (optional)")
+    checkpointFlag := flag.String("checkpoint-dir", "", "directory to periodically write a checkpoint of each function's analysis to (optional)")
+    checkpointIntervalFlag := flag.Duration("checkpoint-interval", time.Minute, "how often to write checkpoints")
+    resumeFlag := flag.Bool("resume", false, "continue each function from its checkpoint in -checkpoint-dir, if there is one")
+    coverFlag := flag.String("coverprofile", "", "file to write source lines reached by the found paths to, in go tool cover format (optional)")
+    logLevelFlag := flag.String("log-level", "off", "analysis log written to stderr: debug (every step), info (per-function summary), warn, error or off")
+    formatFlag := flag.String("format", "text", "output format: text, json (one JSON document per function) or sarif (findings as SARIF 2.1.0)")
     flag.Parse()
 
+    if err := setupLogging(*logLevelFlag); err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(2)
+    }
+
+    switch *formatFlag {
+    case "text":
+    case "json", "sarif":
+        if *targetFlag != "" {
+            fmt.Fprintf(os.Stderr, "-format %s is not supported with -target\n", *formatFlag)
+            os.Exit(2)
+        }
+        if *formatFlag == "sarif" {
+            defer func() {
+                if err := writeSARIF(os.Stdout, sarifReports); err != nil {
+                    fmt.Fprintf(os.Stderr, "failed to write SARIF log: %v\n", err)
+                }
+            }()
+        }
+    default:
+        fmt.Fprintf(os.Stderr, "unknown output format %q: expected text, json or sarif\n", *formatFlag)
+        os.Exit(2)
+    }
+
+    if *coverFlag != "" {
+        coverProfile = internal.NewCoverageProfile()
+        defer func() {
+            if err := writeCoverProfile(*coverFlag); err != nil {
+                fmt.Fprintf(os.Stderr, "failed to write coverage profile: %v\n", err)
+            }
+        }()
+    }
+
+    fnNames := splitList(*funcFlag)
+
+    globals, err := internal.ParseGlobalsMode(*globalsFlag)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(2)
+    }
+
+    var target *internal.Target
+    if *targetFlag != "" {
+        parsed, err := internal.ParseTarget(*targetFlag)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            os.Exit(2)
+        }
+        target = &parsed
+    }
+
+    // Time budgets are per function, see -timeout
+    ctx := context.Background()
+
+    out := output{format: *formatFlag, smt2Dir: *smt2Flag, dotDir: *dotFlag}
+
+    if *resumeFlag && *checkpointFlag == "" {
+        fmt.Fprintln(os.Stderr, "-resume needs -checkpoint-dir")
+        os.Exit(2)
+    }
+    if *checkpointFlag != "" {
+        if err := os.MkdirAll(*checkpointFlag, 0o755); err != nil {
+            fmt.Fprintf(os.Stderr, "failed to create checkpoint directory: %v\n", err)
+            os.Exit(1)
+        }
+    }
+
+    // Selectors, schedulers and mergers keep state, so each function gets its own
+    newOptions := func(funcName string) internal.Options {
+        selector, err := internal.ParseSelector(*selectorFlag, *seedFlag)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            os.Exit(2)
+        }
+        scheduler, err := newScheduler(*evictFlag, *spillDirFlag, *workersFlag)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            os.Exit(2)
+        }
+        var merger *internal.StateMerger
+        if *mergeFlag {
+            merger = internal.NewStateMerger()
+            merger.MaxCost = *mergeCostFlag
+        }
+        maxStates := *maxStatesFlag
+        if maxStates == 0 {
+            maxStates = -1
+        }
+        checkpoint, resume := checkpointFor(*checkpointFlag, funcName, *resumeFlag)
+        return internal.Options{
+            Selector:      selector,
+            Scheduler:     scheduler,
+            Merger:        merger,
+            Globals:       globals,
+            Solver:        *solverFlag,
+            MaxSteps:      *maxStepsFlag,
+            Timeout:       *timeoutFlag,
+            SolverTimeout: *solverTimeoutFlag,
+            MemoryLimit:   *memoryFlag << 20,
+            MaxStates:     maxStates,
+            LoopBound:     *loopBoundFlag,
+            MaxCallDepth:  *callDepthFlag,
+            MaxPathLength: *pathLengthFlag,
+            Checkpoint:    checkpoint,
+            Resume:        resume,
+
+            CheckpointInterval: *checkpointIntervalFlag,
+        }
+    }
+
+    // Packages and directories are loaded with go/packages, so files keep
+    // their own names and imports get real SSA bodies
+    if *pkgFlag != "" {
+        runPackages(ctx, ".", splitList(*pkgFlag), fnNames, target, out, newOptions)
+        return
+    }
+    if info, err := os.Stat(*pathFlag); err == nil && info.IsDir() {
+        runPackages(ctx, *pathFlag, nil, fnNames, target, out, newOptions)
+        return
+    }
+
     source, err := loadSource(*pathFlag)
     if err != nil {
         fmt.Fprintf(os.Stderr, "failed to load source: %v\n", err)
         os.Exit(1)
     }
-
-    var fnNames []string
-    if strings.TrimSpace(*funcFlag) != "" {
-        for _, part := range strings.Split(*funcFlag, ",") {
-            name := strings.TrimSpace(part)
-            if name != "" {
-                fnNames = append(fnNames, name)
-            }
+    if coverProfile != nil {
+        // The source is parsed under its base name; go tool cover needs a path it can open
+        if abs, err := filepath.Abs(*pathFlag); err == nil {
+            coverProfile.Files[filepath.Base(*pathFlag)] = abs
         }
-    } else {
+    }
+
+    if len(fnNames) == 0 {
         fnNames, err = collectFuncNames(source)
         if err != nil {
             fmt.Fprintf(os.Stderr, "failed to parse source for function names: %v\n", err)
             os.Exit(1)
         }
         if len(fnNames) == 0 {
-            fmt.Println("no functions found in the supplied source")
+            fmt.Fprintln(os.Stderr, "no functions found in the supplied source")
             return
         }
     }
 
+    if target != nil {
+        // The analysed source is a single file, so only its lines can be addressed
+        if target.File != "" && filepath.Base(target.File) != filepath.Base(*pathFlag) {
+            fmt.Fprintf(os.Stderr, "target file %s is not the analysed file %s\n", target.File, *pathFlag)
+            os.Exit(2)
+        }
+        for _, fn := range fnNames {
+            guard(fn, func() { runTarget(ctx, filepath.Base(*pathFlag), source, fn, *target, newOptions(fn)) })
+        }
+        return
+    }
+
     for _, fn := range fnNames {
-        runTest(fn, source, fn)
+        guard(fn, func() { runTest(ctx, fn, filepath.Base(*pathFlag), source, fn, out, newOptions(fn)) })
     }
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "testing"
+
+    "symbolic-execution-course/internal"
+)
+
+const signSource = `package main
+
+func Sign(x int) int {
+    if x > 0 {
+        return 1
+    }
+    return -1
+}
+`
+
+func TestDumpSMT2(t *testing.T) {
+    dir := t.TempDir()
+    runTest(context.Background(), "Sign", "main.go", signSource, "Sign", output{format: "text", smt2Dir: dir}, internal.Options{})
+
+    files, err := filepath.Glob(filepath.Join(dir, "*.smt2"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    sort.Strings(files)
+    var asserts []string
+    for i, file := range files {
+        if want := filepath.Join(dir, fmt.Sprintf("Sign_path%d.smt2", i)); file != want {
+            t.Errorf("file %d = %s, want %s", i, file, want)
+        }
+        data, err := os.ReadFile(file)
+        if err != nil {
+            t.Fatal(err)
+        }
+        script := string(data)
+        // Every script is standalone: it declares its inputs and checks them
+        for _, line := range []string{"; function: Sign", "(declare-const x Int)", "(check-sat)"} {
+            if !strings.Contains(script, line+"\n") {
+                t.Errorf("%s has no line %q:\n%s", file, line, script)
+            }
+        }
+        for _, line := range strings.Split(script, "\n") {
+            if strings.HasPrefix(line, "(assert ") {
+                asserts = append(asserts, line)
+            }
+        }
+    }
+    sort.Strings(asserts)
+    if want := []string{"(assert (> x 0))", "(assert (not (> x 0)))"}; strings.Join(asserts, "\n") != strings.Join(want, "\n") {
+        t.Errorf("asserted %q, want %q", asserts, want)
+    }
+}
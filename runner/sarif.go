@@ -0,0 +1,168 @@
+package main
+
+import (
+    "encoding/json"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "symbolic-execution-course/internal"
+)
+
+// Minimal subset of SARIF 2.1.0 needed to report findings
+
+type sarifLog struct {
+    Version string     `json:"version"`
+    Schema  string     `json:"$schema"`
+    Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+    Tool    sarifTool     `json:"tool"`
+    Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+    Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+    Name  string      `json:"name"`
+    Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+    ID               string       `json:"id"`
+    ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+    Text string `json:"text"`
+}
+
+type sarifResult struct {
+    RuleID     string                 `json:"ruleId"`
+    Level      string                 `json:"level"`
+    Message    sarifMessage           `json:"message"`
+    Locations  []sarifLocation        `json:"locations"`
+    CodeFlows  []sarifCodeFlow        `json:"codeFlows,omitempty"`
+    Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+    PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+    Message          *sarifMessage          `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+    ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+    Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+    URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+    StartLine   int `json:"startLine"`
+    StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifCodeFlow struct {
+    ThreadFlows []sarifThreadFlow `json:"threadFlows"`
+}
+
+type sarifThreadFlow struct {
+    Locations []sarifThreadFlowLocation `json:"locations"`
+}
+
+type sarifThreadFlowLocation struct {
+    Location sarifLocation `json:"location"`
+}
+
+// sarifReports collects reports of all analysed functions for -format sarif
+var sarifReports []*internal.Report
+
+// writeSARIF writes findings of the collected reports as one SARIF run
+func writeSARIF(w io.Writer, reports []*internal.Report) error {
+    run := sarifRun{
+        Tool:    sarifTool{Driver: sarifDriver{Name: "symbolic-execution-course"}},
+        Results: []sarifResult{},
+    }
+    for _, kind := range internal.FindingKinds {
+        run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+            ID:               kind.String(),
+            ShortDescription: sarifMessage{Text: kind.Description()},
+        })
+    }
+
+    for _, report := range reports {
+        for _, finding := range report.Findings {
+            result := sarifResult{
+                RuleID:    finding.Kind.String(),
+                Level:     "error",
+                Message:   sarifMessage{Text: finding.Message},
+                Locations: []sarifLocation{sarifLocationOf(finding.Location, "")},
+                Properties: map[string]interface{}{
+                    "function": finding.Function,
+                },
+            }
+            if finding.Kind == internal.FindingUnreachable {
+                result.Level = "warning"
+            }
+            if finding.Inputs != nil {
+                result.Properties["input"] = finding.Inputs
+            }
+            if finding.PathCondition != "" {
+                result.Properties["pathCondition"] = finding.PathCondition
+            }
+
+            if len(finding.Flow) > 0 {
+                var flow sarifThreadFlow
+                for _, step := range finding.Flow {
+                    flow.Locations = append(flow.Locations, sarifThreadFlowLocation{Location: sarifLocationOf(step.Location, step.Message)})
+                }
+                flow.Locations = append(flow.Locations, sarifThreadFlowLocation{Location: sarifLocationOf(finding.Location, finding.Message)})
+                result.CodeFlows = []sarifCodeFlow{{ThreadFlows: []sarifThreadFlow{flow}}}
+            }
+            run.Results = append(run.Results, result)
+        }
+    }
+
+    encoder := json.NewEncoder(w)
+    encoder.SetIndent("", "  ")
+    encoder.SetEscapeHTML(false)
+    return encoder.Encode(sarifLog{
+        Version: "2.1.0",
+        Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+        Runs:    []sarifRun{run},
+    })
+}
+
+func sarifLocationOf(location internal.Location, message string) sarifLocation {
+    var res sarifLocation
+    if location.File != "" {
+        res.PhysicalLocation = &sarifPhysicalLocation{
+            ArtifactLocation: sarifArtifactLocation{URI: artifactURI(location.File)},
+            Region:           &sarifRegion{StartLine: location.Line, StartColumn: location.Column},
+        }
+    }
+    if message != "" {
+        res.Message = &sarifMessage{Text: message}
+    }
+    return res
+}
+
+// artifactURI makes file paths relative to the working directory,
+// as code review tools resolve them against the repository root
+func artifactURI(file string) string {
+    if filepath.IsAbs(file) {
+        if wd, err := os.Getwd(); err == nil {
+            if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+                file = rel
+            }
+        }
+    }
+    return filepath.ToSlash(file)
+}